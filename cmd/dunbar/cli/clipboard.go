@@ -0,0 +1,19 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// copyToClipboard puts text on the system clipboard. Without a clipboard
+// tool (e.g. over SSH) it falls back to the OSC 52 escape sequence, which
+// most terminals use to set the local clipboard.
+func copyToClipboard(text string) error {
+	if err := clipboard.WriteAll(text); err == nil {
+		return nil
+	}
+	_, err := osc52.New(text).WriteTo(os.Stderr)
+	return err
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/arjungandhi/dunbar/pkg/config"
+)
+
+// deleteConfirm holds how a TUI confirms deletes (the delete_confirm
+// setting) and, for config.DeleteConfirmName, what's been typed so far
+type deleteConfirm struct {
+	mode  string
+	input textinput.Model
+}
+
+// newDeleteConfirm creates the delete confirmation for a delete_confirm
+// setting, where "" means config.DeleteConfirmKey
+func newDeleteConfirm(mode string) deleteConfirm {
+	if mode == "" {
+		mode = config.DeleteConfirmKey
+	}
+	ti := textinput.New()
+	ti.CharLimit = 256
+	return deleteConfirm{mode: mode, input: ti}
+}
+
+// skip reports whether deletes happen without asking
+func (c deleteConfirm) skip() bool {
+	return c.mode == config.DeleteConfirmNone
+}
+
+// typed reports whether deletes are confirmed by typing the name
+func (c deleteConfirm) typed() bool {
+	return c.mode == config.DeleteConfirmName
+}
+
+// matches reports whether what's been typed confirms deleting target
+func (c deleteConfirm) matches(target string) bool {
+	typed := strings.TrimSpace(c.input.Value())
+	return typed != "" && strings.EqualFold(typed, strings.TrimSpace(target))
+}
+
+// prompt renders the typed confirmation for a dialog: what to type, the
+// input, and a key hint, with extra naming any other keys the dialog takes
+func (c deleteConfirm) prompt(target, extra string) string {
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	targetStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("196"))
+
+	var sb strings.Builder
+	sb.WriteString("Type " + targetStyle.Render(target) + " to delete permanently:\n")
+	sb.WriteString(c.input.View())
+	sb.WriteString("\n\n")
+	keys := "enter: delete"
+	if extra != "" {
+		keys += " • " + extra
+	}
+	sb.WriteString(hintStyle.Render(keys + " • esc: cancel"))
+	return sb.String()
+}
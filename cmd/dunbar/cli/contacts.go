@@ -1,29 +1,54 @@
 package cli
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/arjungandhi/dunbar/pkg/config"
 	"github.com/arjungandhi/dunbar/pkg/contacts"
+	"github.com/arjungandhi/dunbar/pkg/messages"
+	"github.com/arjungandhi/dunbar/pkg/util"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
 	Z "github.com/rwxrob/bonzai/z"
 	"github.com/rwxrob/help"
+	"golang.org/x/term"
 )
 
 var Contacts = &Z.Cmd{
 	Name:     "contacts",
 	Summary:  "Manage your contacts",
-	Commands: []*Z.Cmd{help.Cmd, ContactsInit, ContactsList, ContactsSync},
+	Commands: []*Z.Cmd{help.Cmd, ContactsInit, ContactsList, ContactsSync, ContactsQuota, ContactsFields, ContactsEvents, ContactsNote, ContactsShow, ContactsExport, ContactsFavorite, ContactsUnfavorite, ContactsIgnore, ContactsUnignore, ContactsDelete, ContactsTimeline, ContactsReport, ContactsGraph, ContactsPick},
+	Description: `
+Without a command, open the contacts TUI. It reopens on the contact it was
+last left on, with the same sort ('o') and filter ('/'); pass --reset to
+start at the top of the full list instead.
+
+The list shows names only unless the contact_column setting in config.json
+adds a column: "company", "phone", or "last_contacted" (when you last
+talked in a synced direct conversation), e.g. "contact_column": "phone".
+
+Where a contact has several phone numbers, the mobile one is shown and
+called. Set phone_type_order to prefer others, e.g. "phone_type_order":
+["work", "mobile"], and email_type_order to do the same for addresses.
+
+Deleting asks for a key press. Set "delete_confirm": "name" to have the
+contact's name typed instead (or the count, when several are selected),
+or "none" to delete without asking.
+`,
 	Call: func(x *Z.Cmd, args ...string) error {
 		// Default action: open TUI
 		return runContactsTUI(x, args...)
@@ -33,43 +58,62 @@ var Contacts = &Z.Cmd{
 var ContactsInit = &Z.Cmd{
 	Name:    "init",
 	Summary: "Initialize contacts provider",
+	Usage:   "[--provider NAME] [--credentials FILE]",
+	Description: `
+Choose a contacts provider and authorize dunbar to use it. The provider
+picker is skipped when --provider names one (e.g. --provider google) or a
+provider was chosen before (the "provider" setting in config.json).
+
+For Google, --credentials reads the client ID and secret from the
+client_secret.json downloaded from the Google Cloud console (a "Desktop
+app" OAuth client) instead of prompting for them.
+`,
 	Call: func(x *Z.Cmd, args ...string) error {
-		cfg := config.New()
+		cfg := loadConfig()
 		if err := cfg.EnsureDunbarDir(); err != nil {
 			return fmt.Errorf("failed to create dunbar directory: %w", err)
 		}
 
-		// Run provider selection in Bubble Tea
-		m := newProviderSelectModel()
-		p := tea.NewProgram(m)
-		result, err := p.Run()
-		if err != nil {
-			return fmt.Errorf("provider selection failed: %w", err)
+		// A client secret file is only issued by Google, so it picks the provider
+		if path := flagValue(args, "--credentials"); path != "" {
+			creds, err := contacts.LoadGoogleClientSecret(path)
+			if err != nil {
+				return err
+			}
+			cfg.ContactsProvider = "google"
+			if err := cfg.Save(); err != nil {
+				return err
+			}
+			return authorizeGoogleProvider(cfg, creds)
 		}
 
-		providerModel := result.(providerSelectModel)
-		if providerModel.cancelled {
-			return fmt.Errorf("initialization cancelled")
+		// Only ask which provider to use if it isn't already known
+		providerType := flagValue(args, "--provider")
+		if providerType == "" {
+			providerType = cfg.ContactsProvider
 		}
+		if providerType == "" {
+			m := newProviderSelectModel()
+			p := tea.NewProgram(m)
+			result, err := p.Run()
+			if err != nil {
+				return fmt.Errorf("provider selection failed: %w", err)
+			}
 
-		providerType := providerModel.selectedProvider
-
-		// Save provider type to config
-		configPath := filepath.Join(cfg.DunbarDir, "config.json")
-		configData := map[string]string{
-			"provider": providerType,
-		}
-		data, err := json.MarshalIndent(configData, "", "  ")
-		if err != nil {
-			return fmt.Errorf("failed to marshal config: %w", err)
-		}
-		if err := os.WriteFile(configPath, data, 0644); err != nil {
-			return fmt.Errorf("failed to write config: %w", err)
+			providerModel := result.(providerSelectModel)
+			if providerModel.cancelled {
+				return fmt.Errorf("initialization cancelled")
+			}
+			providerType = providerModel.selectedProvider
 		}
 
-		// Initialize the selected provider
+		// Initialize the selected provider, saving it to config
 		switch providerType {
 		case "google":
+			cfg.ContactsProvider = providerType
+			if err := cfg.Save(); err != nil {
+				return err
+			}
 			return initGoogleProvider(cfg)
 		default:
 			return fmt.Errorf("unsupported provider: %s", providerType)
@@ -227,17 +271,21 @@ func initGoogleProvider(cfg *config.Config) error {
 		return fmt.Errorf("setup cancelled: %w", err)
 	}
 
-	// Create and initialize provider
+	return authorizeGoogleProvider(cfg, &contacts.GoogleCredentials{
+		ClientID:     strings.TrimSpace(clientID),
+		ClientSecret: strings.TrimSpace(clientSecret),
+	})
+}
+
+// authorizeGoogleProvider saves new client credentials and runs the browser
+// authorization flow
+func authorizeGoogleProvider(cfg *config.Config, creds *contacts.GoogleCredentials) error {
 	provider, err := contacts.NewGoogleContactsProvider(cfg.DunbarDir)
 	if err != nil {
 		return fmt.Errorf("failed to create provider: %w", err)
 	}
 
 	// Save credentials
-	creds := &contacts.GoogleCredentials{
-		ClientID:     strings.TrimSpace(clientID),
-		ClientSecret: strings.TrimSpace(clientSecret),
-	}
 	if err := provider.SaveCredentials(creds); err != nil {
 		return fmt.Errorf("failed to save credentials: %w", err)
 	}
@@ -279,8 +327,7 @@ func initGoogleProvider(cfg *config.Config) error {
 	}
 
 	// Exchange auth code for token
-	ctx := context.Background()
-	if err := provider.ExchangeAuthCode(ctx, strings.TrimSpace(authCode)); err != nil {
+	if err := provider.ExchangeAuthCode(cmdCtx, strings.TrimSpace(authCode)); err != nil {
 		return fmt.Errorf("failed to exchange auth code: %w", err)
 	}
 
@@ -325,8 +372,7 @@ func reauthorizeGoogleProvider(cfg *config.Config, provider *contacts.GoogleCont
 	}
 
 	// Exchange auth code for token
-	ctx := context.Background()
-	if err := provider.ExchangeAuthCode(ctx, strings.TrimSpace(authCode)); err != nil {
+	if err := provider.ExchangeAuthCode(cmdCtx, strings.TrimSpace(authCode)); err != nil {
 		return fmt.Errorf("failed to exchange auth code: %w", err)
 	}
 
@@ -339,8 +385,27 @@ func reauthorizeGoogleProvider(cfg *config.Config, provider *contacts.GoogleCont
 var ContactsList = &Z.Cmd{
 	Name:    "list",
 	Summary: "List all contacts",
+	Usage:   "[--include-archived] [--favorites] [--group NAME] [--sort name|email|modified|synced]",
+	Description: `
+List contacts one per line, sorted by name unless --sort says otherwise:
+email sorts by primary email address, and modified and synced put the most
+recently changed or synced contacts first. Contacts without the field go
+last.
+
+--group lists only the contacts in a Google contact group (a label in
+Google Contacts), ignoring case, e.g. --group Friends. Groups are synced
+from Google and edited there.
+`,
 	Call: func(x *Z.Cmd, args ...string) error {
-		cfg := config.New()
+		sortKey := flagValue(args, "--sort")
+		if sortKey == "" {
+			sortKey = "name"
+		}
+		if !slices.Contains(contactSortKeys, sortKey) {
+			return fmt.Errorf("invalid --sort %q: use %s", sortKey, strings.Join(contactSortKeys, ", "))
+		}
+
+		cfg := loadConfig()
 		cm, err := getContactManager(cfg)
 		if err != nil {
 			return err
@@ -351,12 +416,44 @@ var ContactsList = &Z.Cmd{
 			return fmt.Errorf("failed to list contacts: %w", err)
 		}
 
+		if hasFlag(args, "--include-archived") {
+			archived, err := cm.ListArchivedContacts()
+			if err != nil {
+				return fmt.Errorf("failed to list archived contacts: %w", err)
+			}
+			contacts = append(contacts, archived...)
+		}
+
+		if hasFlag(args, "--favorites") {
+			favorites := contacts[:0]
+			for _, c := range contacts {
+				if c.IsFavorite {
+					favorites = append(favorites, c)
+				}
+			}
+			contacts = favorites
+		}
+
+		if group := flagValue(args, "--group"); group != "" {
+			members := contacts[:0]
+			for _, c := range contacts {
+				if c.InGroup(group) {
+					members = append(members, c)
+				}
+			}
+			contacts = members
+		}
+
+		if err := sortContactsBy(contacts, sortKey); err != nil {
+			return err
+		}
+
 		// Output in a bash-friendly format: one contact per line
-		// Format: UID|FullName|PrimaryEmail|PrimaryPhone
+		// Format: UID|DisplayName|PrimaryEmail|PrimaryPhone
 		for _, contact := range contacts {
 			fmt.Printf("%s|%s|%s|%s\n",
 				contact.UID,
-				contact.FullName,
+				contact.DisplayName(),
 				contact.PrimaryEmail(),
 				contact.PrimaryPhone(),
 			)
@@ -366,19 +463,100 @@ var ContactsList = &Z.Cmd{
 	},
 }
 
+var ContactsQuota = &Z.Cmd{
+	Name:    "quota",
+	Summary: "Check whether the Google People API quota allows a sync",
+	Description: `
+Make one cheap People API request and report whether it went through, how
+many contacts Google holds, and roughly how many read requests a full sync
+makes. Google doesn't say how much quota is left, so the limits and usage
+are linked instead. If a quota is used up, say when to retry and where to
+request more; the exit status is then non-zero.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		cfg := loadConfig()
+		if cfg.ContactsProvider != "google" {
+			return fmt.Errorf("quota checks need the Google contacts provider. Run 'dunbar contacts init' first")
+		}
+		provider, err := getGoogleProvider(cfg)
+		if err != nil {
+			return err
+		}
+
+		status, err := provider.CheckQuota(cmdCtx)
+		if err != nil {
+			var apiErr *contacts.GoogleAPIError
+			if errors.As(err, &apiErr) && apiErr.IsQuotaExceeded() {
+				fmt.Println("People API: quota exceeded")
+			}
+			return explainContactsError(err)
+		}
+
+		fmt.Printf("People API: OK (request took %s)\n", status.Latency.Round(time.Millisecond))
+		fmt.Printf("Address book: %s\n", plural(status.TotalContacts, "contact"))
+		fmt.Printf("A full sync makes about %s\n", plural(status.SyncRequests, "read request"))
+		fmt.Printf("Limits and usage: %s\n", contacts.PeopleAPIQuotaURL)
+		return nil
+	},
+}
+
 var ContactsSync = &Z.Cmd{
 	Name:    "sync",
 	Summary: "Sync contacts with provider",
+	Usage:   "[--show-changes] [--keep-empty] [--force]",
+	Description: `
+Fetch contacts from the provider and store them locally. With
+--show-changes, report which fields changed on which contacts compared to
+the local copies from before the sync.
+
+Syncing only pulls, so contacts edited locally since they were last synced
+would lose the edits. They're kept and listed with what the sync would
+change, and in a terminal you're asked whether to overwrite them. Pass
+--force to overwrite them without asking.
+
+Entries with no name, phone number, or email address (Google lists some
+metadata-only "contacts") are skipped and counted, and copies stored by
+earlier syncs are removed. Pass --keep-empty to keep them.
+
+Progress is shown as pages arrive, and each page is saved as it comes, so
+stopping a long first sync with ctrl+c keeps what was fetched.
+`,
 	Call: func(x *Z.Cmd, args ...string) error {
-		cfg := config.New()
+		cfg := loadConfig()
 		cm, err := getContactManager(cfg)
 		if err != nil {
 			return err
 		}
 
-		fmt.Println("Syncing contacts...")
-		if err := cm.SyncContacts(); err != nil {
-			return fmt.Errorf("failed to sync contacts: %w", err)
+		showChanges := hasFlag(args, "--show-changes")
+		var before []contacts.Contact
+		if showChanges {
+			before, err = cm.ListContacts()
+			if err != nil {
+				return fmt.Errorf("failed to list contacts: %w", err)
+			}
+		}
+
+		fields := cfg.GooglePersonFields
+		if len(fields) == 0 {
+			fields = contacts.DefaultGooglePersonFields
+		}
+		fmt.Printf("Syncing contacts (fields: %s)...\n", strings.Join(fields, ", "))
+		cm.SetKeepEmpty(hasFlag(args, "--keep-empty"))
+		cm.SetOverwriteLocalEdits(hasFlag(args, "--force"))
+		cm.SetSyncProgress(func(fetched, total int) {
+			fmt.Printf("\r\033[KFetched %d/%d contacts...", fetched, total)
+		})
+		err = cm.SyncContacts(cmdCtx)
+		fmt.Println() // New line after progress
+		if err != nil {
+			if cmdCtx.Err() != nil {
+				fmt.Println("Sync stopped early; the contacts fetched so far were saved.")
+			}
+			return explainContactsError(fmt.Errorf("failed to sync contacts: %w", err))
+		}
+		if err := resolveLocalEdits(cm); err != nil {
+			return err
 		}
 
 		contacts, err := cm.ListContacts()
@@ -387,236 +565,1736 @@ var ContactsSync = &Z.Cmd{
 		}
 
 		fmt.Printf("Sync complete! Total contacts: %d\n", len(contacts))
+		if skipped := cm.SkippedEmpty(); skipped > 0 {
+			fmt.Printf("Skipped %s with no name, phone, or email (--keep-empty keeps them)\n", emptyEntries(skipped))
+		}
+		if showChanges {
+			printContactChanges(before, contacts)
+		}
 		return nil
 	},
 }
 
-// Helper function to get or create ContactManager
-func getContactManager(cfg *config.Config) (*contacts.ContactManager, error) {
-	if err := cfg.EnsureDunbarDir(); err != nil {
-		return nil, fmt.Errorf("failed to create dunbar directory: %w", err)
+// printContactChanges reports contacts added by a sync and the fields that
+// changed on existing ones
+func printContactChanges(before, after []contacts.Contact) {
+	previous := make(map[string]contacts.Contact, len(before))
+	for _, c := range before {
+		previous[c.UID] = c
 	}
 
-	// Read provider config
-	configPath := filepath.Join(cfg.DunbarDir, "config.json")
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("contacts not initialized. Run 'dunbar contacts init' first")
+	sortContacts(after)
+	changed := 0
+	for _, c := range after {
+		old, ok := previous[c.UID]
+		if !ok {
+			fmt.Printf("%s: new contact\n", c.DisplayName())
+			changed++
+			continue
 		}
-		return nil, fmt.Errorf("failed to read config: %w", err)
-	}
-
-	var configData map[string]string
-	if err := json.Unmarshal(data, &configData); err != nil {
-		return nil, fmt.Errorf("failed to parse config: %w", err)
-	}
 
-	providerType := configData["provider"]
-	if providerType != "google" {
-		return nil, fmt.Errorf("unsupported provider: %s", providerType)
+		diff := contacts.DiffContacts(old, c)
+		if len(diff) == 0 {
+			continue
+		}
+		fmt.Printf("%s: %s\n", c.DisplayName(), describeChanges(diff))
+		changed++
 	}
 
-	// Create Google provider
-	provider, err := contacts.NewGoogleContactsProvider(cfg.DunbarDir)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create provider: %w", err)
+	if changed == 0 {
+		fmt.Println("No changes")
 	}
+}
 
-	if err := provider.Initialize(); err != nil {
-		return nil, fmt.Errorf("failed to initialize provider: %w", err)
+// describeChanges lists field changes on one line, e.g.
+// `phone added "+1555", org changed "Acme" -> "Initech"`
+func describeChanges(diff []contacts.FieldChange) string {
+	descriptions := make([]string, len(diff))
+	for i, change := range diff {
+		descriptions[i] = change.String()
 	}
-
-	// Create ContactManager
-	return contacts.NewContactManager(provider, *cfg, cfg.DunbarDir)
+	return strings.Join(descriptions, ", ")
 }
 
-// TUI implementation
-func runContactsTUI(x *Z.Cmd, args ...string) error {
-	cfg := config.New()
-	cm, err := getContactManager(cfg)
-	if err != nil {
-		return err
+// resolveLocalEdits lists the locally edited contacts the last sync kept,
+// and in a terminal offers to overwrite them with the provider's versions
+func resolveLocalEdits(cm *contacts.ContactManager) error {
+	edits := cm.LocalEdits()
+	if len(edits) == 0 {
+		return nil
 	}
 
-	contactsList, err := cm.ListContacts()
-	if err != nil {
-		return fmt.Errorf("failed to list contacts: %w", err)
+	fmt.Printf("Kept %s edited here since the last sync, which the sync would change:\n", plural(len(edits), "contact"))
+	for _, e := range edits {
+		fmt.Printf("  %s: %s\n", e.Local.DisplayName(), describeChanges(e.Changes))
 	}
 
-	m := newContactsModel(contactsList, cm)
-	p := tea.NewProgram(m, tea.WithAltScreen())
-
-	if _, err := p.Run(); err != nil {
-		return fmt.Errorf("TUI error: %w", err)
+	overwrite := false
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		form := huh.NewForm(
+			huh.NewGroup(
+				huh.NewConfirm().
+					Title("Overwrite them with the provider's versions?").
+					Description("Your local edits to these contacts will be lost.").
+					Affirmative("Yes, overwrite").
+					Negative("No, keep mine").
+					Value(&overwrite),
+			),
+		)
+		if err := form.Run(); err != nil {
+			overwrite = false
+		}
+	}
+	if !overwrite {
+		fmt.Println("Run 'dunbar contacts sync --force' to overwrite them.")
+		return nil
 	}
 
+	if err := cm.OverwriteLocalEdits(edits); err != nil {
+		return err
+	}
+	fmt.Printf("Overwrote %s\n", plural(len(edits), "contact"))
 	return nil
 }
 
-// Bubble Tea model for contacts TUI
-type contactsModel struct {
-	contacts         []contacts.Contact
-	cursor           int
-	viewportTop      int
-	height           int
-	width            int
-	cm               *contacts.ContactManager
-	confirmingDelete bool
-	deleteUID        string
-}
-
-func newContactsModel(contactsList []contacts.Contact, cm *contacts.ContactManager) contactsModel {
-	// Sort contacts alphabetically by name
-	sort.Slice(contactsList, func(i, j int) bool {
-		return strings.ToLower(contactsList[i].FullName) < strings.ToLower(contactsList[j].FullName)
-	})
+var ContactsEvents = &Z.Cmd{
+	Name:    "events",
+	Summary: "List upcoming birthdays and anniversaries",
+	Usage:   "[days]",
+	MaxArgs: 1,
+	Description: `
+List birthdays and anniversaries occurring in the next [days] days
+(default 30), soonest first. Events without a known year omit the age.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		days := 30
+		if len(args) > 0 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid number of days: %s", args[0])
+			}
+			days = n
+		}
 
-	return contactsModel{
-		contacts:         contactsList,
-		cursor:           0,
-		viewportTop:      0,
-		height:           25, // Default height, will be updated with window size
-		width:            80, // Default width, will be updated with window size
-		cm:               cm,
-		confirmingDelete: false,
-		deleteUID:        "",
-	}
-}
+		cfg := loadConfig()
+		cm, err := getContactManager(cfg)
+		if err != nil {
+			return err
+		}
 
-func (m contactsModel) Init() tea.Cmd {
-	return nil
-}
+		contactsList, err := cm.ListContacts()
+		if err != nil {
+			return fmt.Errorf("failed to list contacts: %w", err)
+		}
 
-func (m contactsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	switch msg := msg.(type) {
-	case tea.WindowSizeMsg:
-		m.height = msg.Height - 3 // Reserve space for header and footer
-		m.width = msg.Width
+		// Output in a bash-friendly format: one event per line
+		// Format: Date|Kind|UID|DisplayName|Years (empty if the year is unknown)
+		for _, event := range contacts.UpcomingEvents(contactsList, time.Now(), days) {
+			years := ""
+			if event.HasYear() {
+				years = strconv.Itoa(event.Years())
+			}
+			fmt.Printf("%s|%s|%s|%s|%s\n",
+				event.Next.Format("2006-01-02"),
+				event.Kind,
+				event.Contact.UID,
+				event.Contact.DisplayName(),
+				years,
+			)
+		}
 
-	case tea.KeyMsg:
-		// Handle delete confirmation
-		if m.confirmingDelete {
-			switch msg.String() {
-			case "y", "Y":
-				// Delete the contact
-				if err := m.cm.DeleteContact(m.deleteUID); err == nil {
-					// Remove from local list
-					for i, c := range m.contacts {
-						if c.UID == m.deleteUID {
-							m.contacts = append(m.contacts[:i], m.contacts[i+1:]...)
-							break
-						}
-					}
-					// Adjust cursor if needed
-					if m.cursor >= len(m.contacts) && len(m.contacts) > 0 {
-						m.cursor = len(m.contacts) - 1
-					}
-				}
-				m.confirmingDelete = false
-				m.deleteUID = ""
-				return m, nil
+		return nil
+	},
+}
 
-			case "n", "N", "esc":
-				// Cancel deletion
-				m.confirmingDelete = false
-				m.deleteUID = ""
-				return m, nil
-			}
-			return m, nil
+var ContactsNote = &Z.Cmd{
+	Name:    "note",
+	Summary: "Add a dated note to a contact, or list their notes",
+	Usage:   "[uid|name] [text]",
+	Description: `
+Append a timestamped note to a contact's activity log. Notes are kept
+locally and are never pushed to the contacts provider. With only a
+contact, print the contact's notes newest first. If the contact is
+omitted or ambiguous, an interactive picker is shown.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		cfg := loadConfig()
+		cm, err := getContactManager(cfg)
+		if err != nil {
+			return err
 		}
 
-		// Normal key handling
-		switch msg.String() {
-		case "q", "ctrl+c":
-			return m, tea.Quit
+		query := ""
+		if len(args) > 0 {
+			query = args[0]
+		}
+		uid, err := resolveContactUID(cm, query)
+		if err != nil {
+			return err
+		}
 
-		case "d":
-			// Start delete confirmation
-			if len(m.contacts) > 0 && m.cursor < len(m.contacts) {
-				m.confirmingDelete = true
-				m.deleteUID = m.contacts[m.cursor].UID
+		if len(args) <= 1 {
+			notes, err := cm.ListNotes(uid)
+			if err != nil {
+				return fmt.Errorf("failed to list notes: %w", err)
 			}
 
-		case "up", "k":
-			if m.cursor > 0 {
-				m.cursor--
-				if m.cursor < m.viewportTop {
-					m.viewportTop = m.cursor
-				}
+			// Format: Timestamp|Text
+			for _, note := range notes {
+				fmt.Printf("%s|%s\n", note.Timestamp.Format(time.RFC3339), note.Text)
 			}
+			return nil
+		}
 
-		case "down", "j":
-			if m.cursor < len(m.contacts)-1 {
-				m.cursor++
-				if m.cursor >= m.viewportTop+m.height {
-					m.viewportTop = m.cursor - m.height + 1
-				}
-			}
+		if _, err := cm.AddNote(uid, strings.Join(args[1:], " ")); err != nil {
+			return fmt.Errorf("failed to add note: %w", err)
+		}
 
-		case "g", "home":
-			m.cursor = 0
-			m.viewportTop = 0
+		fmt.Println("Note added.")
+		return nil
+	},
+}
 
-		case "G", "end":
-			m.cursor = len(m.contacts) - 1
-			m.viewportTop = max(0, len(m.contacts)-m.height)
+var ContactsShow = &Z.Cmd{
+	Name:    "show",
+	Summary: "Print a contact as JSON",
+	Usage:   "[uid|name]",
+	MaxArgs: 1,
+	Description: `
+Print a single contact as indented JSON. If the contact is omitted or
+ambiguous, an interactive picker is shown.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		cfg := loadConfig()
+		cm, err := getContactManager(cfg)
+		if err != nil {
+			return err
+		}
 
-		case "pgup":
-			m.cursor = max(0, m.cursor-m.height)
-			m.viewportTop = max(0, m.viewportTop-m.height)
+		query := ""
+		if len(args) > 0 {
+			query = args[0]
+		}
+		uid, err := resolveContactUID(cm, query)
+		if err != nil {
+			return err
+		}
 
-		case "pgdown":
-			m.cursor = min(len(m.contacts)-1, m.cursor+m.height)
-			m.viewportTop = min(max(0, len(m.contacts)-m.height), m.viewportTop+m.height)
+		contact, err := cm.GetContact(uid)
+		if err != nil {
+			return fmt.Errorf("failed to read contact: %w", err)
+		}
+		if contact == nil {
+			return fmt.Errorf("%w: %s", contacts.ErrNotFound, uid)
 		}
-	}
 
-	return m, nil
+		data, err := json.MarshalIndent(contact, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal contact: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	},
 }
 
-func (m contactsModel) View() string {
-	if len(m.contacts) == 0 {
-		return "No contacts found. Run 'dunbar contacts sync' to sync your contacts.\n\nPress 'q' to quit."
-	}
+var ContactsExport = &Z.Cmd{
+	Name:    "export",
+	Summary: "Export contacts as vCard or JSON",
+	Usage:   "[--format vcard|json] [--tag TAG] [--search QUERY] [--output FILE]",
+	Description: `
+Write contacts to standard output, or to FILE with --output. The default
+format is vcard. --tag keeps only contacts with that tag and --search only
+contacts whose name, nickname, email, phone, organization, tags, or groups
+contain QUERY; both can be combined to export a subset for sharing or backup.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		format := contacts.FormatVCard
+		if value := flagValue(args, "--format"); value != "" {
+			format = value
+		}
 
-	// Show delete confirmation dialog
-	if m.confirmingDelete {
-		var contact contacts.Contact
-		for _, c := range m.contacts {
-			if c.UID == m.deleteUID {
-				contact = c
-				break
-			}
+		cfg := loadConfig()
+		cm, err := getContactManager(cfg)
+		if err != nil {
+			return err
 		}
 
-		// Styles for the dialog
-		titleStyle := lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("196")).
-			Padding(0, 1)
+		contactsList, err := cm.ListContacts()
+		if err != nil {
+			return fmt.Errorf("failed to list contacts: %w", err)
+		}
+		sortContacts(contactsList)
+		contactsList = contacts.FilterContacts(contactsList, flagValue(args, "--tag"), flagValue(args, "--search"))
+
+		out := os.Stdout
+		if path := flagValue(args, "--output"); path != "" {
+			f, err := os.Create(path)
+			if err != nil {
+				return fmt.Errorf("failed to create export file: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
 
-		nameStyle := lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("39")).
-			Padding(0, 1)
+		if err := contacts.Export(out, contactsList, format); err != nil {
+			return err
+		}
 
-		buttonStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("240")).
-			Padding(0, 1)
+		if out != os.Stdout {
+			fmt.Printf("Exported %d contacts to %s\n", len(contactsList), out.Name())
+		}
+		return nil
+	},
+}
 
-		yesButtonStyle := lipgloss.NewStyle().
-			Bold(true).
-			Foreground(lipgloss.Color("46")).
-			Background(lipgloss.Color("22")).
-			Padding(0, 2)
+var ContactsFavorite = &Z.Cmd{
+	Name:    "favorite",
+	Summary: "Mark a contact as a favorite",
+	Usage:   "[uid|name]",
+	MaxArgs: 1,
+	Description: `
+Mark a contact as a favorite. Favorites are listed first and are kept
+across syncs; they are never sent to the provider.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		return setFavorite(args, true)
+	},
+}
 
-		noButtonStyle := lipgloss.NewStyle().
-			Bold(true).
+var ContactsUnfavorite = &Z.Cmd{
+	Name:    "unfavorite",
+	Summary: "Remove a contact from favorites",
+	Usage:   "[uid|name]",
+	MaxArgs: 1,
+	Call: func(x *Z.Cmd, args ...string) error {
+		return setFavorite(args, false)
+	},
+}
+
+// setFavorite resolves the contact named in args and marks or unmarks it as a favorite
+func setFavorite(args []string, favorite bool) error {
+	cfg := loadConfig()
+	cm, err := getContactManager(cfg)
+	if err != nil {
+		return err
+	}
+
+	query := ""
+	if len(args) > 0 {
+		query = args[0]
+	}
+	uid, err := resolveContactUID(cm, query)
+	if err != nil {
+		return err
+	}
+
+	if err := cm.SetFavorite(uid, favorite); err != nil {
+		return err
+	}
+
+	contact, err := cm.GetContact(uid)
+	if err != nil || contact == nil {
+		return nil
+	}
+	if favorite {
+		fmt.Printf("Added %s to favorites\n", contact.DisplayName())
+	} else {
+		fmt.Printf("Removed %s from favorites\n", contact.DisplayName())
+	}
+	return nil
+}
+
+var ContactsDelete = &Z.Cmd{
+	Name:    "delete",
+	Summary: "Delete contacts locally and from the provider",
+	Usage:   "[--yes] [--keep-notes] <uid|name>...",
+	MinArgs: 1,
+	Description: `
+Delete one or more contacts, e.g. 'dunbar contacts delete uid1 uid2 uid3'.
+Each contact is given by UID or part of its name, as for 'favorite'. One
+confirmation lists them all (--yes skips it); provider contacts are then
+deleted in a batch and the local copies removed. A contact that can't be
+deleted is reported without stopping the rest.
+
+The confirmation also says how many notes and synced conversations the
+contacts have, and asks whether to delete the notes too. With --yes, notes
+are deleted unless --keep-notes is given. Conversations are always kept in
+messages.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		cfg := loadConfig()
+		cm, err := getContactManager(cfg)
+		if err != nil {
+			return err
+		}
+
+		var uids []string
+		var names []string
+		var targets []contacts.Contact
+		for _, arg := range args {
+			if arg == "--yes" || arg == "--keep-notes" {
+				continue
+			}
+			uid, err := resolveContactUID(cm, arg)
+			if err != nil {
+				return err
+			}
+			if slices.Contains(uids, uid) {
+				continue
+			}
+			contact, err := cm.GetContact(uid)
+			if err != nil {
+				return err
+			}
+			uids = append(uids, uid)
+			names = append(names, contact.DisplayName())
+			targets = append(targets, *contact)
+		}
+		if len(uids) == 0 {
+			return fmt.Errorf("no contacts given")
+		}
+
+		deleteNotes := !hasFlag(args, "--keep-notes")
+		if !hasFlag(args, "--yes") {
+			links, err := countContactLinks(cfg, cm, targets)
+			if err != nil {
+				return err
+			}
+			description := strings.Join(names, "\n") + "\n\nThey are also deleted from the provider."
+			if !links.empty() {
+				description += "\n" + links.String()
+			}
+
+			confirmed := false
+			fields := []huh.Field{
+				huh.NewConfirm().
+					Title(fmt.Sprintf("Delete %d contacts?", len(uids))).
+					Description(description).
+					Affirmative("Delete").
+					Negative("Cancel").
+					Value(&confirmed),
+			}
+			if links.notes > 0 {
+				fields = append(fields, huh.NewConfirm().
+					Title(fmt.Sprintf("Delete their %s too?", plural(links.notes, "note"))).
+					Affirmative("Delete notes").
+					Negative("Keep notes").
+					Value(&deleteNotes))
+			}
+			if err := huh.NewForm(huh.NewGroup(fields...)).Run(); err != nil {
+				return fmt.Errorf("prompt failed: %w", err)
+			}
+			if !confirmed {
+				return fmt.Errorf("cancelled")
+			}
+		}
+
+		deleted, err := cm.DeleteContacts(cmdCtx, uids, deleteNotes)
+		fmt.Printf("Deleted %d of %d contacts\n", len(deleted), len(uids))
+		if err != nil {
+			return explainContactsError(fmt.Errorf("some contacts couldn't be deleted:\n%w", err))
+		}
+		return nil
+	},
+}
+
+// resolveContactUID turns a UID or name fragment into a contact UID. An exact
+// UID or a single name match resolves directly; otherwise the user picks from
+// the matching contacts (or all contacts if nothing matches).
+func resolveContactUID(cm *contacts.ContactManager, query string) (string, error) {
+	contactsList, err := cm.ListContacts()
+	if err != nil {
+		return "", fmt.Errorf("failed to list contacts: %w", err)
+	}
+	if len(contactsList) == 0 {
+		return "", fmt.Errorf("no contacts found. Run 'dunbar contacts sync' first")
+	}
+
+	candidates := contactsList
+	if query != "" {
+		var matches []contacts.Contact
+		for _, c := range contactsList {
+			if c.UID == query {
+				return c.UID, nil
+			}
+			if strings.Contains(strings.ToLower(c.DisplayName()), strings.ToLower(query)) {
+				matches = append(matches, c)
+			}
+		}
+		if len(matches) == 1 {
+			return matches[0].UID, nil
+		}
+		if len(matches) > 1 {
+			candidates = matches
+		}
+	}
+
+	return pickContact(candidates)
+}
+
+// pickContact shows an interactive, type-to-filter contact picker and returns the chosen UID
+func pickContact(contactsList []contacts.Contact) (string, error) {
+	sort.Slice(contactsList, func(i, j int) bool {
+		return strings.ToLower(contactsList[i].DisplayName()) < strings.ToLower(contactsList[j].DisplayName())
+	})
+
+	options := make([]huh.Option[string], len(contactsList))
+	for i, c := range contactsList {
+		label := c.DisplayName()
+		if detail := c.PrimaryEmail(); detail != "" {
+			label += " <" + detail + ">"
+		} else if detail := c.PrimaryPhone(); detail != "" {
+			label += " (" + detail + ")"
+		}
+		options[i] = huh.NewOption(label, c.UID)
+	}
+
+	var uid string
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Select a contact (type to filter)").
+				Options(options...).
+				Filtering(true).
+				Height(15).
+				Value(&uid),
+		),
+	)
+
+	if err := form.Run(); err != nil {
+		return "", fmt.Errorf("contact selection cancelled: %w", err)
+	}
+
+	return uid, nil
+}
+
+// explainContactsError replaces errors the user can fix themselves with instructions
+func explainContactsError(err error) error {
+	if errors.Is(err, contacts.ErrReauthRequired) {
+		return errors.New("Your Google authorization expired. Run 'dunbar contacts init' to re-authorize.")
+	}
+	if errors.Is(err, contacts.ErrNotInitialized) {
+		return errors.New("Contacts aren't set up yet. Run 'dunbar contacts init' first.")
+	}
+	var conflict *contacts.ConflictError
+	if errors.As(err, &conflict) {
+		return explainConflict(conflict)
+	}
+	return err
+}
+
+// explainConflict describes what changed at the provider when an update was
+// rejected, so the user can decide which version to keep
+func explainConflict(conflict *contacts.ConflictError) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s was changed at the provider since it was last synced, so it wasn't updated.", conflict.Local.DisplayName())
+	if conflict.Remote == nil {
+		sb.WriteString("\nIt has since been deleted there.")
+	} else if changes := contacts.DiffContacts(conflict.Local, *conflict.Remote); len(changes) > 0 {
+		sb.WriteString("\nThe provider's version differs from yours:")
+		for _, change := range changes {
+			sb.WriteString("\n  " + change.String())
+		}
+	}
+	sb.WriteString("\nRun 'dunbar contacts sync' to fetch the latest version, then make your change again.")
+	return errors.New(sb.String())
+}
+
+// Helper function to get or create ContactManager
+func getContactManager(cfg *config.Config) (*contacts.ContactManager, error) {
+	if err := cfg.EnsureDunbarDir(); err != nil {
+		return nil, fmt.Errorf("failed to create dunbar directory: %w", err)
+	}
+
+	// Read provider config
+	switch cfg.ContactsProvider {
+	case "":
+		return nil, fmt.Errorf("%w. Run 'dunbar contacts init' first", contacts.ErrNotInitialized)
+	case "google":
+	default:
+		return nil, fmt.Errorf("unsupported provider: %s", cfg.ContactsProvider)
+	}
+
+	provider, err := getGoogleProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// The provider loads credentials lazily on its first API call, so
+	// read-only commands work offline and without valid credentials
+
+	// Create ContactManager
+	return contacts.NewContactManager(provider, *cfg, cfg.DataDir)
+}
+
+// getGoogleProvider creates the Google provider with the settings from config.json
+func getGoogleProvider(cfg *config.Config) (*contacts.GoogleContactsProvider, error) {
+	provider, err := contacts.NewGoogleContactsProvider(cfg.DunbarDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create provider: %w", err)
+	}
+	if len(cfg.GooglePersonFields) > 0 {
+		if err := provider.SetPersonFields(cfg.GooglePersonFields); err != nil {
+			return nil, fmt.Errorf("invalid google_person_fields in %s: %w", cfg.Path(), err)
+		}
+	}
+	provider.SetPageSize(cfg.GooglePageSize)
+	provider.SetMaxContacts(cfg.GoogleMaxContacts)
+	return provider, nil
+}
+
+// TUI implementation
+func runContactsTUI(x *Z.Cmd, args ...string) error {
+	_, err := contactsTUI(false, hasFlag(args, "--reset"))
+	return err
+}
+
+var ContactsPick = &Z.Cmd{
+	Name:    "pick",
+	Summary: "Choose a contact in the TUI and print its UID",
+	Usage:   "[--reset]",
+	Description: `
+Open the contacts TUI as a picker: enter quits and prints the UID of the
+contact under the cursor to standard output, so other tools can capture it,
+e.g. 'dunbar contacts show "$(dunbar contacts pick)"'. The TUI itself is
+drawn on standard error. Quitting without picking exits with an error.
+Like the TUI, it starts on the last contact chosen unless --reset is given.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		uid, err := contactsTUI(true, hasFlag(args, "--reset"))
+		if err != nil {
+			return err
+		}
+		if uid == "" {
+			return fmt.Errorf("no contact picked")
+		}
+		fmt.Println(uid)
+		return nil
+	},
+}
+
+// contactsTUI runs the contacts TUI. In pick mode, enter quits and the
+// chosen contact's UID is returned, and the TUI is drawn on stderr so
+// stdout stays free for the result. The cursor starts on the contact the
+// TUI was last left on, unless reset is set.
+func contactsTUI(pick, reset bool) (string, error) {
+	cfg := loadConfig()
+	cm, err := getContactManager(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	// Large address books take a moment to read, so a spinner shows meanwhile
+	result, err := runLoadingTUI("contacts", pick, func() (tea.Model, error) {
+		contactsList, err := cm.ListContacts()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list contacts: %w", err)
+		}
+
+		notes, err := cm.ListAllNotes()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load notes: %w", err)
+		}
+
+		m := newContactsModel(contactsList, cm)
+		m.notes = notes
+		m.pick = pick
+		m.cfg = cfg
+		m.splitRatio = cfg.PaneSplit()
+		m.column = cfg.ContactColumn
+		m.deleteConfirm = newDeleteConfirm(cfg.DeleteConfirm)
+		m.timeFormat = cfg.DisplayTimeFormat()
+		if m.column == config.ContactColumnLastContacted {
+			m.lastContacted = loadLastContacted(cfg, contactsList)
+		}
+		state := loadTUIState(cfg, reset)
+		if slices.Contains(contactSortKeys, state.ContactSort) {
+			m.sortKey = state.ContactSort
+		}
+		m.filter = state.ContactFilter
+		m.showContacts()
+		// A contact deleted or filtered out since last time leaves the cursor at the top
+		if uid := state.ContactUID; uid != "" {
+			m.cursor = max(0, slices.IndexFunc(m.contacts, func(c contacts.Contact) bool { return c.UID == uid }))
+			m.clampViewport()
+		}
+		return m, nil
+	})
+	if err != nil || result == nil {
+		return "", err
+	}
+
+	final := result.(contactsModel)
+	saveTUIState(cfg, func(s *config.TUIState) {
+		s.ContactSort = final.sortKey
+		s.ContactFilter = final.filter
+		if final.cursor < len(final.contacts) {
+			s.ContactUID = final.contacts[final.cursor].UID
+		}
+	})
+	return final.picked, nil
+}
+
+// Bubble Tea model for contacts TUI
+type contactsModel struct {
+	contacts         []contacts.Contact // What's listed: all, filtered and sorted
+	all              []contacts.Contact // Every loaded contact
+	cursor           int
+	viewportTop      int
+	height           int
+	width            int
+	cm               *contacts.ContactManager
+	confirmingDelete bool
+	deleteUIDs       []string                   // Contacts the delete confirmation is for
+	deleteLinks      contactLinks               // Notes and conversations of the contacts being deleted
+	deleteConfirm    deleteConfirm              // How deletes are confirmed (the delete_confirm setting)
+	notes            map[string][]contacts.Note // Dated notes keyed by contact UID
+	syncing          bool                       // True while a provider sync runs in the background
+	spinner          spinner.Model
+	status           string // Result of the last background action
+	statusID         int    // Incremented on each transient status so stale expiries are ignored
+	undoStack        []contactUndo
+	stats            contactStats    // Summary shown above the footer, updated when the list changes
+	selected         map[string]bool // UIDs marked with space for bulk actions; kept when the list changes
+	tagging          bool            // True while the tag prompt is open
+	tagRemove        bool            // True if the tag prompt removes rather than adds the tag
+	tagInput         textinput.Model
+	sortKey          string // How the list is sorted below pins and favorites, one of contactSortKeys; cycled with 'o'
+	filter           string // Query the list is narrowed to (see Contact.Matches), typed after '/'
+	filtering        bool   // True while the filter prompt is open
+	filterInput      textinput.Model
+	pick             bool                 // Picker mode: enter chooses the contact under the cursor and quits
+	picked           string               // UID chosen in picker mode
+	splitRatio       float64              // Share of the width given to the list pane, moved with '<' and '>'
+	cfg              *config.Config       // Where a moved divider is saved
+	showHelp         bool                 // True while the '?' help overlay is open
+	chooser          *huh.Form            // Open while choosing which phone or email to reach a contact on
+	reachAction      reachAction          // What to do with the chooser's choice
+	reachUID         string               // Contact the chooser is for
+	reachValue       *string              // Bound to the chooser's selection
+	noteForm         *huh.Form            // Open while editing notes without an external editor
+	noteUID          string               // Contact whose notes are being edited
+	noteValue        *string              // Bound to the notes text area
+	column           string               // What's listed next to each name (the contact_column setting)
+	lastContacted    map[string]time.Time // When each contact was last talked to, for the last_contacted column
+	timeFormat       util.TimeFormat
+}
+
+// contactLinks counts what's tied to contacts about to be deleted
+type contactLinks struct {
+	contacts      int // How many contacts are being deleted
+	conversations int // Synced direct conversations with them, kept in messages
+	notes         int // Local notes, deleted with them unless kept
+}
+
+// countContactLinks counts the notes of the given contacts and the synced
+// direct conversations with them. Conversations aren't counted if messages
+// haven't been synced or can't be read.
+func countContactLinks(cfg *config.Config, cm *contacts.ContactManager, contactsList []contacts.Contact) (contactLinks, error) {
+	links := contactLinks{contacts: len(contactsList)}
+	for _, contact := range contactsList {
+		notes, err := cm.ListNotes(contact.UID)
+		if err != nil {
+			return links, err
+		}
+		links.notes += len(notes)
+	}
+	links.conversations = countLinkedConversations(cfg, contactsList)
+	return links, nil
+}
+
+// countLinkedConversations counts the synced direct conversations with the
+// given contacts, or returns 0 if messages haven't been synced
+func countLinkedConversations(cfg *config.Config, contactsList []contacts.Contact) int {
+	if cfg == nil {
+		return 0
+	}
+	if _, err := os.Stat(messages.DBPath(*cfg)); err != nil {
+		return 0
+	}
+	mm, err := newMessageManager(cfg, io.Discard)
+	if err != nil {
+		return 0
+	}
+	defer mm.Close()
+
+	count := 0
+	for _, contact := range contactsList {
+		conversations, err := mm.GetConversationsWithContact(contact)
+		if err != nil {
+			return 0
+		}
+		count += len(conversations)
+	}
+	return count
+}
+
+// plural formats a count with a noun, adding "s" unless the count is one
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// emptyEntries describes a count of skipped empty contacts, e.g. "3 empty entries"
+func emptyEntries(n int) string {
+	if n == 1 {
+		return "1 empty entry"
+	}
+	return fmt.Sprintf("%d empty entries", n)
+}
+
+func (l contactLinks) empty() bool {
+	return l.conversations == 0 && l.notes == 0
+}
+
+// String describes the links, e.g. "This contact has 3 linked conversations
+// and 5 notes; the conversations stay in messages."
+func (l contactLinks) String() string {
+	var parts []string
+	if l.conversations > 0 {
+		parts = append(parts, plural(l.conversations, "linked conversation"))
+	}
+	if l.notes > 0 {
+		parts = append(parts, plural(l.notes, "note"))
+	}
+	text := "This contact has "
+	if l.contacts > 1 {
+		text = "These contacts have "
+	}
+	text += strings.Join(parts, " and ")
+	if l.conversations > 0 {
+		text += "; the conversations stay in messages"
+	}
+	return text + "."
+}
+
+// loadLastContacted works out when each contact was last talked to from the
+// synced messages. It's nil if messages haven't been synced or can't be read.
+func loadLastContacted(cfg *config.Config, contactsList []contacts.Contact) map[string]time.Time {
+	if _, err := os.Stat(messages.DBPath(*cfg)); err != nil {
+		return nil
+	}
+	mm, err := newMessageManager(cfg, io.Discard)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		return nil
+	}
+	defer mm.Close()
+
+	last, err := mm.LastContacted(contactsList)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		return nil
+	}
+	return last
+}
+
+// columnValue returns what's listed next to a contact's name for the
+// contact_column setting
+func (m contactsModel) columnValue(contact contacts.Contact) string {
+	switch m.column {
+	case config.ContactColumnCompany:
+		if contact.Organization != nil {
+			return contact.Organization.Name
+		}
+	case config.ContactColumnPhone:
+		return contact.PrimaryPhone()
+	case config.ContactColumnLastContacted:
+		if m.lastContacted == nil {
+			return ""
+		}
+		if t, ok := m.lastContacted[contact.UID]; ok {
+			return util.TimeAgo(t, m.timeFormat)
+		}
+		return "never"
+	}
+	return ""
+}
+
+// listColumns lays out a list row in width columns: name on the left and
+// value, if any, right-aligned. The value gets at most half the width and
+// the name is truncated to fit beside it.
+func listColumns(name, value string, width int) string {
+	if value == "" || width < 10 {
+		return util.Truncate(name, width)
+	}
+	value = util.Truncate(value, width/2)
+	nameWidth := width - lipgloss.Width(value) - 1
+	name = util.Truncate(name, nameWidth)
+	return name + strings.Repeat(" ", nameWidth-lipgloss.Width(name)+1) + value
+}
+
+// contactStats summarizes the contact list for the TUI's stats line
+type contactStats struct {
+	total       int
+	withPhone   int
+	withEmail   int
+	missingBoth int // Contacts with no way to reach them
+}
+
+// computeContactStats counts contacts with phones, emails, and neither
+func computeContactStats(contactsList []contacts.Contact) contactStats {
+	stats := contactStats{total: len(contactsList)}
+	for _, c := range contactsList {
+		hasPhone := len(c.PhoneNumbers) > 0
+		hasEmail := len(c.EmailAddresses) > 0
+		if hasPhone {
+			stats.withPhone++
+		}
+		if hasEmail {
+			stats.withEmail++
+		}
+		if !hasPhone && !hasEmail {
+			stats.missingBoth++
+		}
+	}
+	return stats
+}
+
+func (s contactStats) String() string {
+	return fmt.Sprintf("%d contacts • %d with phone • %d with email • %d missing both",
+		s.total, s.withPhone, s.withEmail, s.missingBoth)
+}
+
+// contactUndo records a removed contact so it can be brought back
+type contactUndo struct {
+	contact  contacts.Contact // Full contact captured before removal
+	archived bool             // True if archived rather than deleted
+}
+
+// maxUndo is how many removals can be undone in one session
+const maxUndo = 10
+
+// undoStatusDuration is how long the undo hint stays in the footer
+const undoStatusDuration = 5 * time.Second
+
+// statusExpiredMsg clears a transient status once its time is up
+type statusExpiredMsg struct {
+	id int
+}
+
+// contactsSyncedMsg is sent when a background contacts sync finishes
+type contactsSyncedMsg struct {
+	contacts   []contacts.Contact
+	skipped    int // Empty entries the sync skipped
+	localEdits int // Locally edited contacts the sync didn't overwrite
+	err        error
+}
+
+// syncContactsCmd syncs with the provider in the background. The provider is
+// only initialized here, so the TUI opens instantly from local data.
+func syncContactsCmd(cm *contacts.ContactManager) tea.Cmd {
+	return func() tea.Msg {
+		if err := cm.SyncContacts(cmdCtx); err != nil {
+			return contactsSyncedMsg{err: explainContactsError(err)}
+		}
+		contactsList, err := cm.ListContacts()
+		return contactsSyncedMsg{contacts: contactsList, skipped: cm.SkippedEmpty(), localEdits: len(cm.LocalEdits()), err: err}
+	}
+}
+
+// sortContacts sorts favorites first, then alphabetically by name
+func sortContacts(contactsList []contacts.Contact) {
+	sortContactsWithin(contactsList, "name")
+}
+
+// contactNameLess orders contacts alphabetically by name, ignoring case
+func contactNameLess(a, b contacts.Contact) bool {
+	return strings.ToLower(a.DisplayName()) < strings.ToLower(b.DisplayName())
+}
+
+// contactSortKeys are the orders 'contacts list --sort' accepts
+var contactSortKeys = []string{"name", "email", "modified", "synced"}
+
+// sortContactsBy sorts contacts by one of contactSortKeys: alphabetically by
+// name or primary email, or most recently modified or synced first.
+// Contacts missing the field go last, and ties fall back to name order.
+func sortContactsBy(contactsList []contacts.Contact, key string) error {
+	switch key {
+	case "name":
+		sort.SliceStable(contactsList, func(i, j int) bool {
+			return contactNameLess(contactsList[i], contactsList[j])
+		})
+	case "email":
+		sort.SliceStable(contactsList, func(i, j int) bool {
+			ei := strings.ToLower(contactsList[i].PrimaryEmail())
+			ej := strings.ToLower(contactsList[j].PrimaryEmail())
+			if ei != ej {
+				if ei == "" || ej == "" {
+					return ej == ""
+				}
+				return ei < ej
+			}
+			return contactNameLess(contactsList[i], contactsList[j])
+		})
+	case "modified":
+		sortNewestFirst(contactsList, func(c contacts.Contact) *time.Time { return c.LastModified })
+	case "synced":
+		sortNewestFirst(contactsList, func(c contacts.Contact) *time.Time { return c.LastSynced })
+	default:
+		return fmt.Errorf("invalid --sort %q: use %s", key, strings.Join(contactSortKeys, ", "))
+	}
+	return nil
+}
+
+// nextSortKey returns the contactSortKeys entry after key, wrapping around.
+// An unknown or empty key is taken as "name".
+func nextSortKey(key string) string {
+	i := max(0, slices.Index(contactSortKeys, key))
+	return contactSortKeys[(i+1)%len(contactSortKeys)]
+}
+
+// sortContactsWithin sorts contacts the way the TUI lists them: pinned
+// contacts first in pin order, then favorites, then the rest, each group
+// ordered by one of contactSortKeys (name if key is empty or unknown)
+func sortContactsWithin(contactsList []contacts.Contact, key string) {
+	if sortContactsBy(contactsList, key) != nil {
+		sortContactsBy(contactsList, "name")
+	}
+	sort.SliceStable(contactsList, func(i, j int) bool {
+		pi, pj := contactsList[i].PinOrder, contactsList[j].PinOrder
+		if (pi > 0) != (pj > 0) {
+			return pi > 0
+		}
+		if pi != pj {
+			return pi < pj
+		}
+		return contactsList[i].IsFavorite && !contactsList[j].IsFavorite
+	})
+}
+
+// sortNewestFirst sorts contacts by a timestamp, newest first, with contacts
+// that have none last
+func sortNewestFirst(contactsList []contacts.Contact, field func(contacts.Contact) *time.Time) {
+	sort.SliceStable(contactsList, func(i, j int) bool {
+		ti, tj := field(contactsList[i]), field(contactsList[j])
+		switch {
+		case (ti == nil) != (tj == nil):
+			return tj == nil
+		case ti != nil && !ti.Equal(*tj):
+			return ti.After(*tj)
+		}
+		return contactNameLess(contactsList[i], contactsList[j])
+	})
+}
+
+func newContactsModel(contactsList []contacts.Contact, cm *contacts.ContactManager) contactsModel {
+	m := contactsModel{
+		all:              contactsList,
+		cursor:           0,
+		viewportTop:      0,
+		height:           25, // Default height, will be updated with window size
+		width:            80, // Default width, will be updated with window size
+		cm:               cm,
+		confirmingDelete: false,
+		spinner:          spinner.New(spinner.WithSpinner(spinner.Dot)),
+		selected:         make(map[string]bool),
+		tagInput:         newTagInput(),
+		filterInput:      newFilterInput(),
+	}
+	m.showContacts()
+	return m
+}
+
+// newFilterInput creates the text input used by the filter prompt
+func newFilterInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "name, email, phone, tag..."
+	return ti
+}
+
+// newTagInput creates the text input used by the tag prompt
+func newTagInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "tag"
+	ti.CharLimit = 64
+	return ti
+}
+
+func (m contactsModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m contactsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// While the phone/email chooser is open it gets keys and its own
+	// messages; the list's background messages are still handled below
+	if m.chooser != nil {
+		switch msg.(type) {
+		case tea.WindowSizeMsg, spinner.TickMsg, statusExpiredMsg, contactsSyncedMsg:
+		default:
+			return m.updateChooser(msg)
+		}
+	}
+	// Likewise for the notes text area
+	if m.noteForm != nil {
+		switch msg.(type) {
+		case tea.WindowSizeMsg, spinner.TickMsg, statusExpiredMsg, contactsSyncedMsg:
+		default:
+			return m.updateNoteForm(msg)
+		}
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = max(1, msg.Height-4) // Reserve space for header, stats line, and footer
+		m.width = msg.Width
+		m.clampViewport()
+
+	case spinner.TickMsg:
+		if !m.syncing {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case statusExpiredMsg:
+		if msg.id == m.statusID {
+			m.status = ""
+		}
+		return m, nil
+
+	case noteEditedMsg:
+		return m.finishNoteEdit(msg)
+
+	case contactsSyncedMsg:
+		m.syncing = false
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Sync failed: %v", msg.err)
+			return m, nil
+		}
+		m.all = msg.contacts
+		m.showContacts()
+		m.status = fmt.Sprintf("Synced %d contacts", len(m.all))
+		if msg.skipped > 0 {
+			m.status += fmt.Sprintf(", skipped %s", emptyEntries(msg.skipped))
+		}
+		if msg.localEdits > 0 {
+			m.status += fmt.Sprintf(", kept local edits to %s (see 'dunbar contacts sync')", plural(msg.localEdits, "contact"))
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		// Handle delete confirmation
+		if m.confirmingDelete && m.deleteConfirm.typed() {
+			switch msg.String() {
+			case "enter":
+				if m.deleteConfirm.matches(m.deleteTarget()) {
+					return m.deletePending(true)
+				}
+				return m, nil
+			case "tab":
+				return m.archivePending()
+			case "esc":
+				m.confirmingDelete = false
+				m.deleteUIDs = nil
+				m.deleteConfirm.input.Blur()
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.deleteConfirm.input, cmd = m.deleteConfirm.input.Update(msg)
+			return m, cmd
+		}
+		if m.confirmingDelete {
+			switch msg.String() {
+			case "a", "A":
+				return m.archivePending()
+
+			case "y", "Y", "d", "D", "k", "K":
+				// 'k' keeps their notes
+				return m.deletePending(!strings.EqualFold(msg.String(), "k"))
+
+			case "n", "N", "esc":
+				// Cancel deletion
+				m.confirmingDelete = false
+				m.deleteUIDs = nil
+				return m, nil
+			}
+			return m, nil
+		}
+
+		// Handle the tag prompt
+		if m.tagging {
+			switch msg.String() {
+			case "enter":
+				return m.applyTag()
+			case "esc":
+				m.tagging = false
+				m.tagInput.Blur()
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.tagInput, cmd = m.tagInput.Update(msg)
+			return m, cmd
+		}
+
+		// Handle the filter prompt, narrowing the list as the query is typed
+		if m.filtering {
+			switch msg.String() {
+			case "enter":
+				m.filtering = false
+				m.filterInput.Blur()
+				return m, nil
+			case "esc":
+				m.filtering = false
+				m.filterInput.Blur()
+				m.filter = ""
+				m.showContacts()
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.filterInput, cmd = m.filterInput.Update(msg)
+			if m.filter != m.filterInput.Value() {
+				m.filter = m.filterInput.Value()
+				m.showContacts()
+			}
+			return m, cmd
+		}
+
+		// The help overlay swallows keys until it's closed
+		if m.showHelp {
+			switch msg.String() {
+			case "?", "esc", "q":
+				m.showHelp = false
+			case "ctrl+c":
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+
+		// Normal key handling
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+
+		case "?":
+			m.showHelp = true
+
+		case "enter":
+			if m.pick && m.cursor < len(m.contacts) {
+				m.picked = m.contacts[m.cursor].UID
+				return m, tea.Quit
+			}
+
+		case "<", ">":
+			// Move the divider between the panes
+			delta := splitStep
+			if msg.String() == "<" {
+				delta = -splitStep
+			}
+			var status string
+			m.splitRatio, status = nudgeSplit(m.cfg, m.splitRatio, delta)
+			return m, m.setTransientStatus(status)
+
+		case " ":
+			// Toggle selection and move to the next contact
+			if m.cursor < len(m.contacts) {
+				uid := m.contacts[m.cursor].UID
+				if m.selected[uid] {
+					delete(m.selected, uid)
+				} else {
+					m.selected[uid] = true
+				}
+				if m.cursor < len(m.contacts)-1 {
+					m.cursor++
+					if m.cursor >= m.viewportTop+m.height {
+						m.viewportTop = m.cursor - m.height + 1
+					}
+				}
+			}
+
+		case "esc":
+			// Clear the selection
+			clear(m.selected)
+
+		case "t", "T":
+			// Add (t) or remove (T) a tag on the selected contacts, or the
+			// contact under the cursor if none are selected
+			if len(m.contacts) > 0 {
+				m.tagging = true
+				m.tagRemove = msg.String() == "T"
+				m.tagInput.Reset()
+				return m, m.tagInput.Focus()
+			}
+
+		case "/":
+			// Filter the list, starting from the current filter
+			m.filtering = true
+			m.filterInput.SetValue(m.filter)
+			m.filterInput.CursorEnd()
+			return m, m.filterInput.Focus()
+
+		case "o":
+			// Cycle the sort order
+			m.sortKey = nextSortKey(m.sortKey)
+			m.showContacts()
+			return m, m.setTransientStatus("Sorted by " + m.sortKey)
+
+		case "s":
+			// Sync with the provider in the background
+			if !m.syncing {
+				m.syncing = true
+				m.status = ""
+				return m, tea.Batch(m.spinner.Tick, syncContactsCmd(m.cm))
+			}
+
+		case "u":
+			// Undo the most recent archive or delete
+			return m.undo()
+
+		case "c", "m", "y", "Y":
+			// Call, mail, or copy a phone number or email address
+			if m.cursor < len(m.contacts) {
+				return m.startReach(reachKeys[msg.String()])
+			}
+
+		case "E":
+			// Edit the selected contact's notes in $EDITOR
+			if m.cursor < len(m.contacts) {
+				return m.startNoteEdit()
+			}
+
+		case "f":
+			// Toggle favorite on the selected contact
+			if m.cursor < len(m.contacts) {
+				return m.toggleFavorite()
+			}
+
+		case "p":
+			// Pin or unpin the selected contact
+			if m.cursor < len(m.contacts) {
+				uid := m.contacts[m.cursor].UID
+				return m.setPinned(togglePin(m.pinnedUIDs(), uid), uid)
+			}
+
+		case "J", "K":
+			// Move the selected pinned contact down/up within the pinned block
+			if m.cursor < len(m.contacts) {
+				delta := 1
+				if msg.String() == "K" {
+					delta = -1
+				}
+				uid := m.contacts[m.cursor].UID
+				if pinned, ok := movePin(m.pinnedUIDs(), uid, delta); ok {
+					return m.setPinned(pinned, uid)
+				}
+			}
+
+		case "d":
+			// Start delete confirmation for the selected contacts, or the one
+			// under the cursor
+			if len(m.contacts) > 0 && m.cursor < len(m.contacts) {
+				m.deleteUIDs = m.targets()
+				if m.deleteConfirm.skip() {
+					return m.deletePending(true)
+				}
+				m.confirmingDelete = true
+				m.deleteLinks = m.linksOf(m.deleteUIDs)
+				if m.deleteConfirm.typed() {
+					m.deleteConfirm.input.Reset()
+					return m, m.deleteConfirm.input.Focus()
+				}
+			}
+
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+				if m.cursor < m.viewportTop {
+					m.viewportTop = m.cursor
+				}
+			}
+
+		case "down", "j":
+			if m.cursor < len(m.contacts)-1 {
+				m.cursor++
+				if m.cursor >= m.viewportTop+m.height {
+					m.viewportTop = m.cursor - m.height + 1
+				}
+			}
+
+		case "g", "home":
+			m.cursor = 0
+			m.viewportTop = 0
+
+		case "G", "end":
+			m.cursor = max(0, len(m.contacts)-1)
+			m.viewportTop = max(0, len(m.contacts)-m.height)
+
+		case "pgup":
+			m.cursor = max(0, m.cursor-m.height)
+			m.viewportTop = max(0, m.viewportTop-m.height)
+
+		case "pgdown":
+			m.cursor = max(0, min(len(m.contacts)-1, m.cursor+m.height))
+			m.viewportTop = min(max(0, len(m.contacts)-m.height), m.viewportTop+m.height)
+		}
+	}
+
+	return m, nil
+}
+
+// contactByUID returns the loaded contact with the given UID
+func (m contactsModel) contactByUID(uid string) contacts.Contact {
+	for _, c := range m.all {
+		if c.UID == uid {
+			return c
+		}
+	}
+	return contacts.Contact{UID: uid}
+}
+
+// linksOf counts the notes of the given contacts and the synced direct
+// conversations with them
+func (m contactsModel) linksOf(uids []string) contactLinks {
+	links := contactLinks{contacts: len(uids)}
+	var targets []contacts.Contact
+	for _, uid := range uids {
+		links.notes += len(m.notes[uid])
+		targets = append(targets, m.contactByUID(uid))
+	}
+	links.conversations = countLinkedConversations(m.cfg, targets)
+	return links
+}
+
+// pushUndo records a removal, dropping the oldest once the stack is full
+func (m *contactsModel) pushUndo(u contactUndo) {
+	m.undoStack = append(m.undoStack, u)
+	if len(m.undoStack) > maxUndo {
+		m.undoStack = m.undoStack[len(m.undoStack)-maxUndo:]
+	}
+}
+
+// setTransientStatus shows a footer status that clears itself after a few seconds
+func (m *contactsModel) setTransientStatus(status string) tea.Cmd {
+	m.statusID++
+	m.status = status
+	id := m.statusID
+	return tea.Tick(undoStatusDuration, func(time.Time) tea.Msg {
+		return statusExpiredMsg{id: id}
+	})
+}
+
+// undo brings back the most recently archived or deleted contact
+func (m contactsModel) undo() (tea.Model, tea.Cmd) {
+	if len(m.undoStack) == 0 {
+		return m, m.setTransientStatus("Nothing to undo")
+	}
+
+	last := m.undoStack[len(m.undoStack)-1]
+	// An archived contact keeps its UID; an undeleted one may get a new one
+	uid := last.contact.UID
+	var err error
+	if last.archived {
+		err = m.cm.RestoreContact(uid)
+	} else {
+		uid, err = m.cm.UndeleteContact(cmdCtx, last.contact)
+	}
+	if err != nil {
+		m.status = fmt.Sprintf("Undo failed: %v", err)
+		return m, nil
+	}
+	m.undoStack = m.undoStack[:len(m.undoStack)-1]
+
+	contactsList, err := m.cm.ListContacts()
+	if err != nil {
+		m.status = fmt.Sprintf("Undo failed: %v", err)
+		return m, nil
+	}
+	m.all = contactsList
+	m.showContacts()
+	// Kept notes follow the contact to its new UID
+	if notes, err := m.cm.ListAllNotes(); err == nil {
+		m.notes = notes
+	}
+
+	// Move the cursor to the restored contact, if the filter lists it
+	if i := slices.IndexFunc(m.contacts, func(c contacts.Contact) bool { return c.UID == uid }); i >= 0 {
+		m.cursor = i
+	}
+	m.clampViewport()
+
+	return m, m.setTransientStatus(fmt.Sprintf("Restored %s", last.contact.DisplayName()))
+}
+
+// targets returns the UIDs a tag or delete applies to: the selected contacts that
+// are still listed, or the contact under the cursor if none are
+func (m contactsModel) targets() []string {
+	var uids []string
+	for _, c := range m.contacts {
+		if m.selected[c.UID] {
+			uids = append(uids, c.UID)
+		}
+	}
+	if len(uids) == 0 && m.cursor < len(m.contacts) {
+		uids = append(uids, m.contacts[m.cursor].UID)
+	}
+	return uids
+}
+
+// applyTag adds or removes the tag typed into the prompt on the targeted
+// contacts, then reloads the list
+func (m contactsModel) applyTag() (tea.Model, tea.Cmd) {
+	m.tagging = false
+	m.tagInput.Blur()
+
+	tag := strings.TrimSpace(m.tagInput.Value())
+	if tag == "" {
+		return m, nil
+	}
+
+	uids := m.targets()
+	if err := m.cm.SetTags(uids, tag, !m.tagRemove); err != nil {
+		m.status = fmt.Sprintf("Tagging failed: %v", err)
+		return m, nil
+	}
+
+	contactsList, err := m.cm.ListContacts()
+	if err != nil {
+		m.status = fmt.Sprintf("Tagging failed: %v", err)
+		return m, nil
+	}
+	m.all = contactsList
+	m.showContacts()
+	clear(m.selected)
+
+	if m.tagRemove {
+		return m, m.setTransientStatus(fmt.Sprintf("Removed tag %q from %d contacts", tag, len(uids)))
+	}
+	return m, m.setTransientStatus(fmt.Sprintf("Tagged %d contacts with %q", len(uids), tag))
+}
+
+// toggleFavorite flips the selected contact's favorite flag and re-sorts the
+// list, keeping the cursor on that contact
+func (m contactsModel) toggleFavorite() (tea.Model, tea.Cmd) {
+	contact := m.contacts[m.cursor]
+	favorite := !contact.IsFavorite
+	if err := m.cm.SetFavorite(contact.UID, favorite); err != nil {
+		m.status = fmt.Sprintf("Favorite failed: %v", err)
+		return m, nil
+	}
+
+	for i := range m.all {
+		if m.all[i].UID == contact.UID {
+			m.all[i].IsFavorite = favorite
+		}
+	}
+	m.showContacts()
+
+	if favorite {
+		return m, m.setTransientStatus(fmt.Sprintf("Added %s to favorites", contact.DisplayName()))
+	}
+	return m, m.setTransientStatus(fmt.Sprintf("Removed %s from favorites", contact.DisplayName()))
+}
+
+// pinnedUIDs returns the UIDs of the pinned contacts in pin order, which is
+// how they're sorted at the top of the list
+func (m contactsModel) pinnedUIDs() []string {
+	var pinned []contacts.Contact
+	for _, c := range m.all {
+		if c.PinOrder > 0 {
+			pinned = append(pinned, c)
+		}
+	}
+	slices.SortFunc(pinned, func(a, b contacts.Contact) int { return a.PinOrder - b.PinOrder })
+
+	var uids []string
+	for _, c := range pinned {
+		uids = append(uids, c.UID)
+	}
+	return uids
+}
+
+// setPinned saves the pinned contacts and their order, then re-sorts the
+// list keeping the cursor on the contact with the given UID
+func (m contactsModel) setPinned(pinned []string, uid string) (tea.Model, tea.Cmd) {
+	if err := m.cm.SetPinned(pinned); err != nil {
+		m.status = fmt.Sprintf("Pin failed: %v", err)
+		return m, nil
+	}
+
+	for i := range m.all {
+		m.all[i].PinOrder = slices.Index(pinned, m.all[i].UID) + 1
+	}
+	m.showContacts()
+
+	if slices.Contains(pinned, uid) {
+		return m, m.setTransientStatus(fmt.Sprintf("Pinned %s (J/K to reorder)", m.contacts[m.cursor].DisplayName()))
+	}
+	return m, m.setTransientStatus(fmt.Sprintf("Unpinned %s", m.contacts[m.cursor].DisplayName()))
+}
+
+// deleteTarget is what has to be typed to confirm deleting the pending
+// contacts: the name of a single contact, or how many there are
+func (m contactsModel) deleteTarget() string {
+	if len(m.deleteUIDs) == 1 {
+		contact := m.contactByUID(m.deleteUIDs[0])
+		return contact.DisplayName()
+	}
+	return strconv.Itoa(len(m.deleteUIDs))
+}
+
+// archivePending archives the contacts the delete confirmation is for,
+// keeping their provider records
+func (m contactsModel) archivePending() (tea.Model, tea.Cmd) {
+	uids := m.deleteUIDs
+	m.confirmingDelete = false
+	m.deleteUIDs = nil
+	m.deleteConfirm.input.Blur()
+	var archived []contacts.Contact
+	var errs []error
+	for _, uid := range uids {
+		contact := m.contactByUID(uid)
+		if err := m.cm.ArchiveContact(cmdCtx, uid, false); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		archived = append(archived, contact)
+	}
+	return m.finishRemoval(archived, true, errors.Join(errs...))
+}
+
+// deletePending deletes the contacts the delete confirmation is for
+// permanently, in one batch at the provider
+func (m contactsModel) deletePending(deleteNotes bool) (tea.Model, tea.Cmd) {
+	uids := m.deleteUIDs
+	m.confirmingDelete = false
+	m.deleteUIDs = nil
+	m.deleteConfirm.input.Blur()
+	deleted, err := m.cm.DeleteContacts(cmdCtx, uids, deleteNotes)
+	if deleteNotes {
+		for _, contact := range deleted {
+			delete(m.notes, contact.UID)
+		}
+	}
+	return m.finishRemoval(deleted, false, err)
+}
+
+// finishRemoval takes archived or deleted contacts out of the list, makes
+// them undoable, and reports how it went, including any failures
+func (m contactsModel) finishRemoval(removed []contacts.Contact, archived bool, err error) (tea.Model, tea.Cmd) {
+	for _, contact := range removed {
+		m.removeContact(contact.UID)
+		delete(m.selected, contact.UID)
+		m.pushUndo(contactUndo{contact: contact, archived: archived})
+	}
+
+	verb := "Deleted"
+	if archived {
+		verb = "Archived"
+	}
+	if err != nil {
+		m.status = fmt.Sprintf("%s %d, failed: %v", verb, len(removed), strings.ReplaceAll(err.Error(), "\n", "; "))
+		return m, nil
+	}
+	if len(removed) == 1 {
+		return m, m.setTransientStatus(fmt.Sprintf("%s %s — press 'u' to undo", verb, removed[0].DisplayName()))
+	}
+	return m, m.setTransientStatus(fmt.Sprintf("%s %d contacts — press 'u' to undo each", verb, len(removed)))
+}
+
+// removeContact removes a contact from the list and keeps the cursor in range
+func (m *contactsModel) removeContact(uid string) {
+	m.all = slices.DeleteFunc(m.all, func(c contacts.Contact) bool { return c.UID == uid })
+	m.contacts = slices.DeleteFunc(m.contacts, func(c contacts.Contact) bool { return c.UID == uid })
+	m.stats = computeContactStats(m.all)
+	m.clampViewport()
+}
+
+// showContacts rebuilds the list from all, filtered by filter and sorted by
+// sortKey, keeping the cursor on the same contact if it's still listed
+func (m *contactsModel) showContacts() {
+	current := ""
+	if m.cursor < len(m.contacts) {
+		current = m.contacts[m.cursor].UID
+	}
+	m.contacts = contacts.FilterContacts(m.all, "", m.filter)
+	sortContactsWithin(m.contacts, m.sortKey)
+	if i := slices.IndexFunc(m.contacts, func(c contacts.Contact) bool { return c.UID == current }); i >= 0 {
+		m.cursor = i
+	}
+	m.stats = computeContactStats(m.all)
+	m.clampViewport()
+}
+
+// clampViewport keeps the cursor in range and on screen, e.g. after the
+// terminal shrinks or the list changes
+func (m *contactsModel) clampViewport() {
+	m.cursor = max(0, min(m.cursor, len(m.contacts)-1))
+	m.viewportTop = max(0, min(m.viewportTop, len(m.contacts)-m.height))
+	if m.cursor < m.viewportTop {
+		m.viewportTop = m.cursor
+	} else if m.cursor >= m.viewportTop+m.height {
+		m.viewportTop = m.cursor - m.height + 1
+	}
+}
+
+func (m contactsModel) View() string {
+	if m.showHelp {
+		return renderKeyHelp("Contacts keys", contactsKeyHelp, m.width, m.height+3)
+	}
+	if m.chooser != nil {
+		return renderChooser(m.chooser, m.width, m.height+3)
+	}
+	if m.noteForm != nil {
+		return renderFormBox(m.noteForm, "enter: save • alt+enter: new line • esc: cancel", m.width, m.height+3)
+	}
+
+	if len(m.all) == 0 {
+		view := "No contacts found. Press 's' to sync your contacts.\n\nPress 'q' to quit."
+		if status := syncStatus(m.syncing, m.spinner, m.status); status != "" {
+			view += "\n\n" + status
+		}
+		return view
+	}
+
+	// Show delete confirmation dialog
+	if m.confirmingDelete {
+		var names []string
+		for _, uid := range m.deleteUIDs {
+			contact := m.contactByUID(uid)
+			names = append(names, contact.DisplayName())
+		}
+		// Long selections are summarized so the dialog fits
+		const maxNames = 8
+		if len(names) > maxNames {
+			names = append(names[:maxNames-1], fmt.Sprintf("…and %d more", len(names)-maxNames+1))
+		}
+
+		// Styles for the dialog
+		titleStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("196")).
+			Padding(0, 1)
+
+		nameStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("39")).
+			Padding(0, 1)
+
+		buttonStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("240")).
+			Padding(0, 1)
+
+		archiveButtonStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("46")).
+			Background(lipgloss.Color("22")).
+			Padding(0, 2)
+
+		deleteButtonStyle := lipgloss.NewStyle().
+			Bold(true).
 			Foreground(lipgloss.Color("196")).
 			Background(lipgloss.Color("52")).
 			Padding(0, 2)
 
+		cancelButtonStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("255")).
+			Background(lipgloss.Color("240")).
+			Padding(0, 2)
+
 		boxStyle := lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
 			BorderForeground(lipgloss.Color("196")).
@@ -625,14 +2303,33 @@ func (m contactsModel) View() string {
 
 		// Build the dialog content
 		var dialogContent strings.Builder
-		dialogContent.WriteString(titleStyle.Render("⚠️  Delete Contact?"))
+		title := "⚠️  Remove Contact?"
+		if len(m.deleteUIDs) > 1 {
+			title = fmt.Sprintf("⚠️  Remove %d Contacts?", len(m.deleteUIDs))
+		}
+		dialogContent.WriteString(titleStyle.Render(title))
 		dialogContent.WriteString("\n\n")
-		dialogContent.WriteString("Are you sure you want to delete:\n")
-		dialogContent.WriteString(nameStyle.Render(contact.FullName))
+		dialogContent.WriteString("What would you like to do with:\n")
+		dialogContent.WriteString(nameStyle.Render(strings.Join(names, "\n")))
 		dialogContent.WriteString("\n\n")
-		dialogContent.WriteString(buttonStyle.Render("This action cannot be undone."))
+		dialogContent.WriteString(buttonStyle.Render("Archiving hides the contact locally and can be undone.\nDeleting permanently also removes it from the provider."))
+		if !m.deleteLinks.empty() {
+			dialogContent.WriteString("\n\n")
+			dialogContent.WriteString(m.deleteLinks.String())
+		}
 		dialogContent.WriteString("\n\n\n")
-		dialogContent.WriteString(yesButtonStyle.Render("Y") + "  " + noButtonStyle.Render("N"))
+		if m.deleteConfirm.typed() {
+			dialogContent.WriteString(m.deleteConfirm.prompt(m.deleteTarget(), "tab: archive instead"))
+		} else if m.deleteLinks.notes > 0 {
+			dialogContent.WriteString(archiveButtonStyle.Render("A Archive") + "  " +
+				deleteButtonStyle.Render("D Delete with notes") + "\n\n" +
+				deleteButtonStyle.Render("K Delete, keep notes") + "  " +
+				cancelButtonStyle.Render("N Cancel"))
+		} else {
+			dialogContent.WriteString(archiveButtonStyle.Render("A Archive") + "  " +
+				deleteButtonStyle.Render("D Delete permanently") + "  " +
+				cancelButtonStyle.Render("N Cancel"))
+		}
 
 		dialog := boxStyle.Render(dialogContent.String())
 
@@ -642,8 +2339,8 @@ func (m contactsModel) View() string {
 			dialog)
 	}
 
-	// Calculate pane widths - left pane takes 40%, right pane takes 60%
-	leftWidth := max(30, m.width*2/5)
+	// Calculate pane widths - the left pane's share is adjustable with '<' and '>'
+	leftWidth := leftPaneWidth(m.width, m.splitRatio, 30)
 
 	// Styles
 	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
@@ -654,7 +2351,14 @@ func (m contactsModel) View() string {
 
 	// Build left pane (contact list)
 	var leftPane strings.Builder
-	leftPane.WriteString(headerStyle.Render(fmt.Sprintf("Contacts (%d)", len(m.contacts))))
+	header := fmt.Sprintf("Contacts (%d)", len(m.contacts))
+	if m.filter != "" {
+		header = fmt.Sprintf("Contacts (%d of %d) matching %q", len(m.contacts), len(m.all), m.filter)
+	}
+	if m.sortKey != "" && m.sortKey != "name" {
+		header += " by " + m.sortKey
+	}
+	leftPane.WriteString(headerStyle.Render(util.Truncate(header, leftWidth)))
 	leftPane.WriteString("\n")
 
 	// Calculate viewport
@@ -668,7 +2372,17 @@ func (m contactsModel) View() string {
 			style = selectedStyle
 		}
 
-		line := fmt.Sprintf(" %s", truncate(contact.FullName, leftWidth-2))
+		name := contact.DisplayName()
+		if contact.IsFavorite {
+			name = "⭐ " + name
+		}
+		if contact.PinOrder > 0 {
+			name = "📌 " + name
+		}
+		if m.selected[contact.UID] {
+			name = "✓ " + name
+		}
+		line := fmt.Sprintf(" %s", listColumns(name, m.columnValue(contact), leftWidth-2))
 		leftPane.WriteString(style.Render(line))
 		leftPane.WriteString("\n")
 	}
@@ -701,7 +2415,11 @@ func (m contactsModel) View() string {
 		divider := dividerStyle.Render("─────────────────────────────────")
 
 		// Title with name
-		rightPane.WriteString(titleStyle.Render("👤 " + contact.FullName))
+		title := "👤 " + contact.DisplayName()
+		if contact.IsFavorite {
+			title += " ⭐"
+		}
+		rightPane.WriteString(titleStyle.Render(title))
 		rightPane.WriteString("\n")
 
 		if contact.Nickname != "" {
@@ -807,7 +2525,40 @@ func (m contactsModel) View() string {
 			rightPane.WriteString("\n")
 			rightPane.WriteString(sectionHeaderStyle.Render("🎂 Birthday"))
 			rightPane.WriteString("\n\n")
-			rightPane.WriteString(fieldValueStyle.Render("  " + contact.Birthday.Format("January 2, 2006")))
+			rightPane.WriteString(fieldValueStyle.Render("  " + formatEventDate(*contact.Birthday)))
+			rightPane.WriteString("\n")
+		}
+
+		// Anniversary
+		if contact.Anniversary != nil {
+			rightPane.WriteString("\n")
+			rightPane.WriteString(divider)
+			rightPane.WriteString("\n")
+			rightPane.WriteString(sectionHeaderStyle.Render("💍 Anniversary"))
+			rightPane.WriteString("\n\n")
+			rightPane.WriteString(fieldValueStyle.Render("  " + formatEventDate(*contact.Anniversary)))
+			rightPane.WriteString("\n")
+		}
+
+		// Tags
+		if len(contact.Tags) > 0 {
+			rightPane.WriteString("\n")
+			rightPane.WriteString(divider)
+			rightPane.WriteString("\n")
+			rightPane.WriteString(sectionHeaderStyle.Render("🏷️  Tags"))
+			rightPane.WriteString("\n\n")
+			rightPane.WriteString(fieldValueStyle.Render("  " + strings.Join(contact.Tags, ", ")))
+			rightPane.WriteString("\n")
+		}
+
+		// Groups
+		if len(contact.Groups) > 0 {
+			rightPane.WriteString("\n")
+			rightPane.WriteString(divider)
+			rightPane.WriteString("\n")
+			rightPane.WriteString(sectionHeaderStyle.Render("👥 Groups"))
+			rightPane.WriteString("\n\n")
+			rightPane.WriteString(fieldValueStyle.Render("  " + strings.Join(contact.Groups, ", ")))
 			rightPane.WriteString("\n")
 		}
 
@@ -821,6 +2572,21 @@ func (m contactsModel) View() string {
 			rightPane.WriteString(fieldValueStyle.Render("  " + contact.Notes))
 			rightPane.WriteString("\n")
 		}
+
+		// Dated notes log (newest first)
+		if notes := m.notes[contact.UID]; len(notes) > 0 {
+			rightPane.WriteString("\n")
+			rightPane.WriteString(divider)
+			rightPane.WriteString("\n")
+			rightPane.WriteString(sectionHeaderStyle.Render("🗒️  Log"))
+			rightPane.WriteString("\n\n")
+			for _, note := range notes {
+				rightPane.WriteString(fieldLabelStyle.Render("  " + note.Timestamp.Format("Jan 2, 2006") + ":"))
+				rightPane.WriteString(" ")
+				rightPane.WriteString(fieldValueStyle.Render(note.Text))
+				rightPane.WriteString("\n")
+			}
+		}
 	}
 
 	// Combine panes with separator
@@ -849,23 +2615,54 @@ func (m contactsModel) View() string {
 		combined.WriteString("\n")
 	}
 
-	// Footer
+	// Stats and footer
+	combined.WriteString("\n")
+	stats := m.stats.String()
+	if len(m.selected) > 0 {
+		stats += fmt.Sprintf(" • %d selected", len(m.selected))
+	}
+	combined.WriteString(footerStyle.Render(stats))
 	combined.WriteString("\n")
-	footer := "j/k: down/up • g/G: top/bottom • pgup/pgdn: page up/down • d: delete • q: quit"
+	if m.tagging {
+		prompt := "Add tag: "
+		if m.tagRemove {
+			prompt = "Remove tag: "
+		}
+		combined.WriteString(prompt + m.tagInput.View() + footerStyle.Render("  (enter: apply • esc: cancel)"))
+		return combined.String()
+	}
+	if m.filtering {
+		combined.WriteString("Filter: " + m.filterInput.View() + footerStyle.Render("  (enter: keep • esc: clear)"))
+		return combined.String()
+	}
+	footer := "j/k: down/up • g/G: top/bottom • pgup/pgdn: page up/down • /: filter • o: sort • space: select • t/T: tag/untag • </>: resize • s: sync • c/m: call/mail • y/Y: copy phone/email • E: edit notes • f: favorite • p: pin • J/K: move pin • d: delete • u: undo • ?: help • q: quit"
+	if m.pick {
+		footer = "enter: pick • " + footer
+	}
 	combined.WriteString(footerStyle.Render(footer))
+	if status := syncStatus(m.syncing, m.spinner, m.status); status != "" {
+		combined.WriteString(footerStyle.Render("  " + status))
+	}
 
 	return combined.String()
 }
 
 // Helper functions
-func truncate(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
+
+// formatEventDate formats a birthday or anniversary, omitting the year when unknown
+func formatEventDate(t time.Time) string {
+	if t.Year() <= 0 {
+		return t.Format("January 2")
 	}
-	if maxLen <= 3 {
-		return s[:maxLen]
+	return t.Format("January 2, 2006")
+}
+
+// syncStatus renders the footer status for a background sync
+func syncStatus(syncing bool, sp spinner.Model, status string) string {
+	if syncing {
+		return sp.View() + " Syncing..."
 	}
-	return s[:maxLen-3] + "..."
+	return status
 }
 
 func padRight(s string, width int) string {
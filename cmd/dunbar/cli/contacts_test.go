@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/arjungandhi/dunbar/pkg/config"
+	"github.com/arjungandhi/dunbar/pkg/contacts"
+	"github.com/arjungandhi/dunbar/pkg/util"
+)
+
+func TestContactsResizeKeepsCursorOnScreen(t *testing.T) {
+	list := make([]contacts.Contact, 50)
+	for i := range list {
+		list[i] = contacts.Contact{UID: fmt.Sprint(i), FullName: fmt.Sprintf("Contact %02d", i)}
+	}
+
+	tests := []struct {
+		name   string
+		cursor int
+		sizes  [][2]int // Width, height of each resize, in order
+	}{
+		{"shrink with cursor at bottom", 49, [][2]int{{80, 60}, {80, 10}, {80, 5}}},
+		{"shrink then grow", 30, [][2]int{{120, 8}, {40, 3}, {200, 100}}},
+		{"tiny terminal", 10, [][2]int{{10, 1}, {10, 2}}},
+		{"taller than the list", 0, [][2]int{{80, 200}, {80, 20}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := contactsModel{contacts: list, cursor: tt.cursor, height: 25, width: 80, selected: map[string]bool{}}
+			m.clampViewport()
+			for _, size := range tt.sizes {
+				updated, _ := m.Update(tea.WindowSizeMsg{Width: size[0], Height: size[1]})
+				m = updated.(contactsModel)
+
+				if m.cursor != tt.cursor {
+					t.Errorf("after %dx%d: cursor moved from %d to %d", size[0], size[1], tt.cursor, m.cursor)
+				}
+				if m.height < 1 {
+					t.Errorf("after %dx%d: height %d, want at least 1", size[0], size[1], m.height)
+				}
+				if m.viewportTop < 0 || m.cursor < m.viewportTop || m.cursor >= m.viewportTop+m.height {
+					t.Errorf("after %dx%d: cursor %d off screen (top %d, height %d)",
+						size[0], size[1], m.cursor, m.viewportTop, m.height)
+				}
+			}
+		})
+	}
+}
+
+func TestListColumnsAlignsWideNames(t *testing.T) {
+	names := []string{"Ada Lovelace", "山田太郎", "李小龍 Bruce Lee", "김민준 김민준 김민준", "👩‍💻 Grace Hopper"}
+	values := []string{"東京", "+1 555 0100", "ada@example.com"}
+
+	for _, width := range []int{20, 21, 30} {
+		for _, value := range values {
+			t.Run(fmt.Sprintf("%d columns, %s", width, value), func(t *testing.T) {
+				for _, name := range names {
+					row := listColumns(name, value, width)
+					// Every row is exactly width columns, so the values line up
+					// at the right edge whatever the names are made of
+					if got := lipgloss.Width(row); got != width {
+						t.Errorf("listColumns(%q, %q, %d) = %q, %d columns wide", name, value, width, row, got)
+					}
+					if !strings.HasSuffix(row, " "+util.Truncate(value, width/2)) {
+						t.Errorf("listColumns(%q, %q, %d) = %q, want the value right-aligned", name, value, width, row)
+					}
+				}
+			})
+		}
+	}
+
+	for _, name := range names {
+		if row := listColumns(name, "", 10); lipgloss.Width(row) > 10 {
+			t.Errorf("listColumns(%q, \"\", 10) = %q, wider than 10 columns", name, row)
+		}
+	}
+}
+
+func TestContactsSortAndFilter(t *testing.T) {
+	modified := func(day int) *time.Time {
+		t := time.Date(2026, 1, day, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	list := []contacts.Contact{
+		{UID: "ada", FullName: "Ada Lovelace", LastModified: modified(1)},
+		{UID: "alan", FullName: "Alan Turing", LastModified: modified(3)},
+		{UID: "grace", FullName: "Grace Hopper", LastModified: modified(2), IsFavorite: true},
+		{UID: "linus", FullName: "Linus Torvalds", Tags: []string{"kernel"}, LastModified: modified(4)},
+	}
+	m := newContactsModel(list, nil)
+	m.cursor = 1 // Ada, below the favorite
+
+	press := func(keys ...tea.KeyMsg) {
+		t.Helper()
+		for _, key := range keys {
+			updated, _ := m.Update(key)
+			m = updated.(contactsModel)
+		}
+	}
+	listed := func() []string {
+		var uids []string
+		for _, c := range m.contacts {
+			uids = append(uids, c.UID)
+		}
+		return uids
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	if want := []string{"grace", "ada", "alan", "linus"}; !slices.Equal(listed(), want) {
+		t.Fatalf("listed %v, want %v", listed(), want)
+	}
+
+	// 'o' steps through the sort orders, keeping favorites on top and the
+	// cursor on the same contact
+	press(runes("o"), runes("o"))
+	if m.sortKey != "modified" {
+		t.Fatalf("sortKey = %q after two presses, want modified", m.sortKey)
+	}
+	if want := []string{"grace", "linus", "alan", "ada"}; !slices.Equal(listed(), want) {
+		t.Errorf("sorted by modified: listed %v, want %v", listed(), want)
+	}
+	if m.contacts[m.cursor].UID != "ada" {
+		t.Errorf("cursor on %s after sorting, want ada", m.contacts[m.cursor].UID)
+	}
+
+	// '/' narrows the list as the query is typed; enter keeps the filter
+	press(runes("/"), runes("t"), runes("u"), tea.KeyMsg{Type: tea.KeyEnter})
+	if m.filtering || m.filter != "tu" {
+		t.Fatalf("filtering = %v, filter = %q after enter, want false, \"tu\"", m.filtering, m.filter)
+	}
+	if want := []string{"alan"}; !slices.Equal(listed(), want) {
+		t.Errorf("filtered by %q: listed %v, want %v", m.filter, listed(), want)
+	}
+
+	// Tags match too, and a filter matching nothing leaves an empty list
+	press(runes("/"), tea.KeyMsg{Type: tea.KeyBackspace}, tea.KeyMsg{Type: tea.KeyBackspace}, runes("kern"), tea.KeyMsg{Type: tea.KeyEnter})
+	if want := []string{"linus"}; !slices.Equal(listed(), want) {
+		t.Errorf("filtered by %q: listed %v, want %v", m.filter, listed(), want)
+	}
+	press(runes("/"), runes("zzz"), tea.KeyMsg{Type: tea.KeyEnter})
+	if len(m.contacts) != 0 || m.cursor != 0 {
+		t.Errorf("filtered by %q: listed %v with cursor %d, want nothing at 0", m.filter, listed(), m.cursor)
+	}
+	press(runes("G"), runes("f"), runes("p")) // Must not index the empty list
+
+	// esc in the prompt clears the filter and shows everything again
+	press(runes("/"), tea.KeyMsg{Type: tea.KeyEsc})
+	if m.filter != "" || len(m.contacts) != len(list) {
+		t.Errorf("after esc: filter %q, %d listed, want no filter and all %d", m.filter, len(m.contacts), len(list))
+	}
+	if m.stats.total != len(list) {
+		t.Errorf("stats count %d contacts, want all %d whatever the filter", m.stats.total, len(list))
+	}
+}
+
+// acceptingProvider is a contacts provider with nothing in it that accepts
+// every write
+type acceptingProvider struct{}
+
+func (acceptingProvider) FetchContacts(context.Context) ([]contacts.Contact, error) { return nil, nil }
+func (acceptingProvider) FetchContact(context.Context, string) (*contacts.Contact, error) {
+	return nil, nil
+}
+func (acceptingProvider) WriteContact(context.Context, contacts.Contact) (string, error) {
+	return "etag", nil
+}
+func (acceptingProvider) DeleteContact(context.Context, string) error { return nil }
+
+func TestContactsUndoFindsRestoredContactByUID(t *testing.T) {
+	for _, archive := range []bool{false, true} {
+		t.Run(fmt.Sprintf("archived %v", archive), func(t *testing.T) {
+			cm, err := contacts.NewContactManager(acceptingProvider{}, config.Config{}, t.TempDir())
+			if err != nil {
+				t.Fatalf("NewContactManager: %v", err)
+			}
+			// Same name twice, so only the UID tells them apart
+			list := []contacts.Contact{
+				{UID: "first", FullName: "Ada Lovelace"},
+				{UID: "second", FullName: "Ada Lovelace"},
+				{UID: "third", FullName: "Grace Hopper"},
+			}
+			for _, c := range list {
+				if err := cm.WriteContact(cmdCtx, c); err != nil {
+					t.Fatalf("WriteContact: %v", err)
+				}
+			}
+
+			m := newContactsModel(list, cm)
+			m.deleteConfirm = newDeleteConfirm(config.DeleteConfirmKey)
+			m.cursor = slices.IndexFunc(m.contacts, func(c contacts.Contact) bool { return c.UID == "second" })
+			m.deleteUIDs = []string{"second"}
+			var updated tea.Model
+			if archive {
+				updated, _ = m.archivePending()
+			} else {
+				updated, _ = m.deletePending(true)
+			}
+			m = updated.(contactsModel)
+			m.cursor = len(m.contacts) - 1 // Away from either Ada
+
+			updated, _ = m.undo()
+			m = updated.(contactsModel)
+			if len(m.contacts) != len(list) {
+				t.Fatalf("%d contacts listed after undo, want %d (status %q)", len(m.contacts), len(list), m.status)
+			}
+			if got := m.contacts[m.cursor].UID; got != "second" {
+				t.Errorf("cursor on %q after undo, want the restored contact \"second\"", got)
+			}
+		})
+	}
+}
@@ -1,18 +1,115 @@
 package cli
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
 	Z "github.com/rwxrob/bonzai/z"
 	"github.com/rwxrob/help"
+
+	"github.com/arjungandhi/dunbar/pkg/config"
+	"github.com/arjungandhi/dunbar/pkg/contacts"
+	"github.com/arjungandhi/dunbar/pkg/logging"
 )
 
 var Cmd = &Z.Cmd{
 	Name:    "dunbar",
 	Summary: "Personal Relationship Manager CLI",
+	Usage:   "[--verbose] [--timeout DURATION] [--dir DIR] COMMAND",
 	Commands: []*Z.Cmd{
 		help.Cmd,
 		Version,
 		Contacts,
 		Messages,
+		Search,
+		Sync,
+		Schedule,
+		Ignores,
 	},
-	Description: `dunbar did not have the internet`,
+	Description: `dunbar did not have the internet
+
+Configuration and credentials are kept in $XDG_CONFIG_HOME/dunbar
+(~/.config/dunbar) and synced data (messages.db, contacts, and dunbar.log)
+in $XDG_DATA_HOME/dunbar (~/.local/share/dunbar). Installs that already
+keep data in ~/.config/dunbar continue to use it, and the data directory
+is recorded in config.json once settings are saved. Set DUNBAR_DIR, or pass
+--dir, to keep everything in a single directory instead.
+
+Info, warnings, and errors are logged to dunbar.log in the data directory,
+which is moved to dunbar.log.1 once it passes 5 MB. Pass --verbose (or -v)
+to also log debug detail such as each provider request, and to print the
+logs to stderr.
+
+Pass --timeout (e.g. --timeout 2m) to give up on slow provider calls.
+These flags, like --dir, go before the command, e.g. 'dunbar -v sync'.
+Ctrl+C stops a running sync cleanly, keeping whatever was already
+fetched.`,
+}
+
+// cmdCtx is the context for provider operations. It is cancelled on Ctrl+C
+// or when the --timeout deadline passes.
+var cmdCtx = context.Background()
+
+// configDir is the --dir flag: the dunbar directory to use instead of
+// DUNBAR_DIR or the default directories
+var configDir string
+
+// runConfig is the config loaded for the running command, shared by every
+// loadConfig call
+var runConfig *config.Config
+
+// loadConfig returns the config for the running command, loading it on the
+// first call. Commands should use it rather than config.New so --dir is
+// honored. A config file that can't be read is warned about, once, rather
+// than failing, so a broken file doesn't make every command unusable.
+func loadConfig() *config.Config {
+	if runConfig != nil {
+		return runConfig
+	}
+	cfg, err := config.OpenIn(configDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	runConfig = cfg
+	return cfg
+}
+
+// Run handles global flags, sets up logging, and runs the command tree
+func Run() {
+	args, flags, err := extractGlobalFlags(os.Args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	os.Args = args
+
+	// Commands get the config loaded here through loadConfig, which applies --dir
+	configDir = flags.dir
+
+	cfg := loadConfig()
+	if err := logging.Setup(cfg.DataDir, flags.verbose); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		logging.Discard()
+	}
+	contacts.SetPrimaryTypeOrder(cfg.PhoneTypeOrder, cfg.EmailTypeOrder)
+
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	// Once interrupted, a second Ctrl+C kills the process as usual
+	go func() {
+		<-sigCtx.Done()
+		stop()
+	}()
+
+	ctx := sigCtx
+	if flags.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, flags.timeout)
+		defer cancel()
+	}
+	cmdCtx = ctx
+
+	Cmd.Run()
 }
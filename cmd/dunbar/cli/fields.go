@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+
+	Z "github.com/rwxrob/bonzai/z"
+
+	"github.com/arjungandhi/dunbar/pkg/contacts"
+)
+
+var ContactsFields = &Z.Cmd{
+	Name:    "fields",
+	Summary: "Report which fields your contacts have filled in",
+	Usage:   "[--missing phone|email|birthday|org|address|photo]",
+	Description: `
+Count, for each of phone, email, birthday, org (company or job title),
+address, and photo, how many contacts have it and how many don't.
+
+With --missing FIELD, list the contacts without that field instead, sorted
+by name, in the same format as 'dunbar contacts list':
+
+  UID|DisplayName|PrimaryEmail|PrimaryPhone
+
+Archived contacts aren't counted.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		cfg := loadConfig()
+		cm, err := getContactManager(cfg)
+		if err != nil {
+			return err
+		}
+
+		contactsList, err := cm.ListContacts()
+		if err != nil {
+			return fmt.Errorf("failed to list contacts: %w", err)
+		}
+
+		if field := flagValue(args, "--missing"); field != "" {
+			missing, err := contacts.MissingField(contactsList, field)
+			if err != nil {
+				return err
+			}
+			if err := sortContactsBy(missing, "name"); err != nil {
+				return err
+			}
+			for _, contact := range missing {
+				fmt.Printf("%s|%s|%s|%s\n",
+					contact.UID,
+					contact.DisplayName(),
+					contact.PrimaryEmail(),
+					contact.PrimaryPhone(),
+				)
+			}
+			return nil
+		}
+
+		if len(contactsList) == 0 {
+			fmt.Println("No contacts. Run 'dunbar contacts sync' first.")
+			return nil
+		}
+
+		coverage := contacts.FieldCoverage(contactsList)
+		total := len(contactsList)
+		fmt.Printf("%-9s %8s %8s\n", "Field", "Have", "Missing")
+		for _, field := range contacts.CompletenessFields {
+			have := coverage[field]
+			fmt.Printf("%-9s %8d %8d  %3d%%\n", field, have, total-have, have*100/total)
+		}
+		fmt.Printf("\nOf %s. List the gaps with --missing FIELD.\n", plural(total, "contact"))
+		return nil
+	},
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// hasFlag reports whether a boolean flag such as "--json" was passed
+func hasFlag(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == name {
+			return true
+		}
+	}
+	return false
+}
+
+// globalFlags are flags accepted anywhere on the command line
+type globalFlags struct {
+	verbose bool          // --verbose/-v: also print logs to stderr
+	timeout time.Duration // --timeout: overall deadline for the command
+	dir     string        // --dir: dunbar directory, overriding DUNBAR_DIR
+}
+
+// extractGlobalFlags removes global flags from args, the program name
+// followed by its arguments, and returns them parsed. Bonzai doesn't parse
+// flags, so this runs before it sees the arguments. Global flags only come
+// before the command: scanning stops at the first other argument, or after
+// "--", so arguments meant for the command such as a note reading "-v" are
+// passed on untouched.
+func extractGlobalFlags(args []string) ([]string, globalFlags, error) {
+	var flags globalFlags
+	if len(args) == 0 {
+		return args, flags, nil
+	}
+	kept := []string{args[0]}
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return append(kept, args[i+1:]...), flags, nil
+		case arg == "--verbose" || arg == "-v":
+			flags.verbose = true
+		case arg == "--timeout" || strings.HasPrefix(arg, "--timeout="):
+			value, ok := strings.CutPrefix(arg, "--timeout=")
+			if !ok {
+				if i+1 >= len(args) {
+					return nil, flags, fmt.Errorf("--timeout requires a duration, e.g. 30s or 5m")
+				}
+				i++
+				value = args[i]
+			}
+			timeout, err := time.ParseDuration(value)
+			if err != nil || timeout <= 0 {
+				return nil, flags, fmt.Errorf("invalid --timeout %q: use a duration like 30s or 5m", value)
+			}
+			flags.timeout = timeout
+		case arg == "--dir" || strings.HasPrefix(arg, "--dir="):
+			value, ok := strings.CutPrefix(arg, "--dir=")
+			if !ok {
+				if i+1 >= len(args) {
+					return nil, flags, fmt.Errorf("--dir requires a directory")
+				}
+				i++
+				value = args[i]
+			}
+			flags.dir = value
+		default:
+			return append(kept, args[i:]...), flags, nil
+		}
+	}
+	return kept, flags, nil
+}
+
+// flagValue returns the value of a flag passed as "--name value" or
+// "--name=value", or "" if it wasn't passed
+func flagValue(args []string, name string) string {
+	for i, arg := range args {
+		if arg == name && i+1 < len(args) {
+			return args[i+1]
+		}
+		if value, ok := strings.CutPrefix(arg, name+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestExtractGlobalFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		flags   globalFlags
+		wantErr bool
+	}{
+		{"empty", nil, nil, globalFlags{}, false},
+		{"no flags", []string{"dunbar", "sync"}, []string{"dunbar", "sync"}, globalFlags{}, false},
+		{"verbose", []string{"dunbar", "-v", "sync"}, []string{"dunbar", "sync"}, globalFlags{verbose: true}, false},
+		{"all flags", []string{"dunbar", "--verbose", "--timeout", "30s", "--dir", "/tmp/d", "sync"},
+			[]string{"dunbar", "sync"}, globalFlags{verbose: true, timeout: 30 * time.Second, dir: "/tmp/d"}, false},
+		{"flags with =", []string{"dunbar", "--timeout=5m", "--dir=/tmp/d", "contacts", "list"},
+			[]string{"dunbar", "contacts", "list"}, globalFlags{timeout: 5 * time.Minute, dir: "/tmp/d"}, false},
+		{"flags only", []string{"dunbar", "-v"}, []string{"dunbar"}, globalFlags{verbose: true}, false},
+
+		// Arguments after the command are the command's, even when they look
+		// like global flags
+		{"note reading -v", []string{"dunbar", "contacts", "note", "add", "c1", "-v"},
+			[]string{"dunbar", "contacts", "note", "add", "c1", "-v"}, globalFlags{}, false},
+		{"note reading --dir", []string{"dunbar", "-v", "contacts", "note", "add", "c1", "--dir"},
+			[]string{"dunbar", "contacts", "note", "add", "c1", "--dir"}, globalFlags{verbose: true}, false},
+		{"command flag named like a global", []string{"dunbar", "messages", "sync", "--timeout", "1s"},
+			[]string{"dunbar", "messages", "sync", "--timeout", "1s"}, globalFlags{}, false},
+		{"double dash", []string{"dunbar", "-v", "--", "-v", "sync"},
+			[]string{"dunbar", "-v", "sync"}, globalFlags{verbose: true}, false},
+		{"double dash after command", []string{"dunbar", "contacts", "--", "-v"},
+			[]string{"dunbar", "contacts", "--", "-v"}, globalFlags{}, false},
+
+		{"timeout missing", []string{"dunbar", "--timeout"}, nil, globalFlags{}, true},
+		{"timeout invalid", []string{"dunbar", "--timeout", "soon", "sync"}, nil, globalFlags{}, true},
+		{"timeout negative", []string{"dunbar", "--timeout=-1s", "sync"}, nil, globalFlags{}, true},
+		{"dir missing", []string{"dunbar", "--dir"}, nil, globalFlags{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, flags, err := extractGlobalFlags(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("extractGlobalFlags(%q) = %q, want an error", tt.args, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extractGlobalFlags(%q) error = %v", tt.args, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("extractGlobalFlags(%q) args = %q, want %q", tt.args, got, tt.want)
+			}
+			if flags != tt.flags {
+				t.Errorf("extractGlobalFlags(%q) flags = %+v, want %+v", tt.args, flags, tt.flags)
+			}
+		})
+	}
+}
+
+func TestFlagValue(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"separate value", []string{"c1", "--format", "json"}, "json"},
+		{"joined value", []string{"--format=json", "c1"}, "json"},
+		{"empty joined value", []string{"--format="}, ""},
+		{"missing", []string{"c1", "--output", "out.json"}, ""},
+		{"no value at the end", []string{"c1", "--format"}, ""},
+		{"longer flag with the same prefix", []string{"--formats", "json", "--formatx=csv"}, ""},
+		{"first wins", []string{"--format", "json", "--format", "csv"}, "json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := flagValue(tt.args, "--format"); got != tt.want {
+				t.Errorf("flagValue(%q, \"--format\") = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasFlag(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"--yes"}, true},
+		{[]string{"c1", "--keep-notes", "--yes"}, true},
+		{[]string{"c1"}, false},
+		{[]string{"--yes=false"}, false},
+		{[]string{"--yesterday"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := hasFlag(tt.args, "--yes"); got != tt.want {
+			t.Errorf("hasFlag(%q, \"--yes\") = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/arjungandhi/dunbar/pkg/messages"
+	Z "github.com/rwxrob/bonzai/z"
+)
+
+var ContactsGraph = &Z.Cmd{
+	Name:    "graph",
+	Summary: "Export a graph of contacts who share group chats",
+	Usage:   "[--out FILE] [--format dot|json]",
+	Description: `
+Build a graph where nodes are contacts and edges connect contacts who are
+in the same group conversation, weighted by how many groups they share.
+Group participants are matched to contacts by phone number or email.
+
+The graph is written as Graphviz DOT, or JSON with --format json (the
+default when FILE ends in .json). Render DOT with e.g.
+'dot -Tsvg graph.dot -o graph.svg'.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		path := flagValue(args, "--out")
+		format := flagValue(args, "--format")
+		if format == "" {
+			format = "dot"
+			if filepath.Ext(path) == ".json" {
+				format = "json"
+			}
+		}
+		if format != "dot" && format != "json" {
+			return fmt.Errorf("unknown format %q (expected dot or json)", format)
+		}
+
+		cfg := loadConfig()
+		cm, err := getContactManager(cfg)
+		if err != nil {
+			return err
+		}
+		contactsList, err := cm.ListContacts()
+		if err != nil {
+			return fmt.Errorf("failed to list contacts: %w", err)
+		}
+
+		mm, err := newMessageManager(cfg, io.Discard)
+		if err != nil {
+			return err
+		}
+		defer mm.Close()
+
+		conversations, err := mm.ListAllConversations(messages.ConversationFilter{Type: "group"})
+		if err != nil {
+			return fmt.Errorf("failed to list conversations: %w", err)
+		}
+
+		graph := messages.BuildContactGraph(contactsList, conversations)
+
+		out := os.Stdout
+		if path != "" {
+			f, err := os.Create(path)
+			if err != nil {
+				return fmt.Errorf("failed to create graph file: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
+
+		if format == "json" {
+			err = graph.WriteJSON(out)
+		} else {
+			err = graph.WriteDOT(out)
+		}
+		if err != nil {
+			return err
+		}
+
+		if out != os.Stdout {
+			fmt.Printf("Wrote %d contacts and %d connections to %s\n", len(graph.Nodes), len(graph.Edges), path)
+		}
+		return nil
+	},
+}
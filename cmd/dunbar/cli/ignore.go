@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"fmt"
+
+	Z "github.com/rwxrob/bonzai/z"
+
+	"github.com/arjungandhi/dunbar/pkg/config"
+)
+
+var ContactsIgnore = &Z.Cmd{
+	Name:    "ignore",
+	Summary: "Keep contacts out of dunbar, even after syncing",
+	Usage:   "<uid|name>...",
+	MinArgs: 1,
+	Description: `
+Remove contacts from dunbar and add them to the ignore list, so syncs skip
+them from now on. They're left as they are at the provider, and their
+notes are kept in case they're unignored. 'dunbar ignores' lists ignored
+contacts and 'dunbar contacts unignore' brings one back.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		cfg := loadConfig()
+		cm, err := getContactManager(cfg)
+		if err != nil {
+			return err
+		}
+
+		for _, arg := range args {
+			uid, err := resolveContactUID(cm, arg)
+			if err != nil {
+				return err
+			}
+			contact, err := cm.GetContact(uid)
+			if err != nil {
+				return fmt.Errorf("failed to read contact: %w", err)
+			}
+			if err := cm.IgnoreContact(uid); err != nil {
+				return fmt.Errorf("failed to ignore %s: %w", uid, err)
+			}
+			if contact != nil {
+				fmt.Printf("Ignoring %s\n", contact.DisplayName())
+			} else {
+				fmt.Printf("Ignoring %s\n", uid)
+			}
+		}
+		return nil
+	},
+}
+
+var ContactsUnignore = &Z.Cmd{
+	Name:    "unignore",
+	Summary: "Let ignored contacts sync again",
+	Usage:   "<uid>...",
+	MinArgs: 1,
+	Description: `
+Take contacts off the ignore list by UID, as listed by 'dunbar ignores'.
+They come back with the next 'dunbar contacts sync'.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		cfg := loadConfig()
+		cm, err := getContactManager(cfg)
+		if err != nil {
+			return err
+		}
+
+		for _, uid := range args {
+			if err := cm.UnignoreContact(uid); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("Unignored %s. Run 'dunbar contacts sync' to fetch them again.\n", plural(len(args), "contact"))
+		return nil
+	},
+}
+
+var MessagesIgnore = &Z.Cmd{
+	Name:    "ignore",
+	Summary: "Keep conversations out of dunbar, even after syncing",
+	Usage:   "<conversation-id>...",
+	MinArgs: 1,
+	Description: `
+Delete conversations and their messages from the local database and add
+them to the ignore list, so syncs skip them from now on: handy for spam
+numbers and automated senders. Nothing is deleted on the messaging
+platform. 'dunbar ignores' lists ignored conversations and 'dunbar
+messages unignore' brings one back.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		cfg := loadConfig()
+		mm, err := getMessageManager(cfg)
+		if err != nil {
+			return err
+		}
+		defer mm.Close()
+
+		for _, id := range args {
+			conv, err := mm.GetConversation(id)
+			if err != nil {
+				return fmt.Errorf("failed to load conversation: %w", err)
+			}
+			if err := mm.IgnoreConversation(id); err != nil {
+				return fmt.Errorf("failed to ignore %s: %w", id, err)
+			}
+			if conv != nil {
+				fmt.Printf("Ignoring %s\n", conv.Title)
+			} else {
+				fmt.Printf("Ignoring %s\n", id)
+			}
+		}
+		return nil
+	},
+}
+
+var MessagesUnignore = &Z.Cmd{
+	Name:    "unignore",
+	Summary: "Let ignored conversations sync again",
+	Usage:   "<conversation-id>...",
+	MinArgs: 1,
+	Description: `
+Take conversations off the ignore list by ID, as listed by 'dunbar
+ignores'. They come back with the next 'dunbar messages sync', with as
+much history as the sync fetches.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		cfg := loadConfig()
+		mm, err := getMessageManager(cfg)
+		if err != nil {
+			return err
+		}
+		defer mm.Close()
+
+		for _, id := range args {
+			if err := mm.UnignoreConversation(id); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("Unignored %s. Run 'dunbar messages sync' to fetch them again.\n", plural(len(args), "conversation"))
+		return nil
+	},
+}
+
+var Ignores = &Z.Cmd{
+	Name:    "ignores",
+	Summary: "List ignored contacts and conversations",
+	Description: `
+Print the ignore list in two sections, each headed by its name and count,
+one entry per line as ID|Name, where Name is what it was called when it
+was ignored. The list is kept in ignore.json in the config directory.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		cfg := loadConfig()
+		list, err := cfg.LoadIgnoreList()
+		if err != nil {
+			return err
+		}
+
+		printIgnored := func(heading string, items []config.IgnoredItem) {
+			fmt.Printf("%s (%d)\n", heading, len(items))
+			for _, item := range items {
+				fmt.Printf("%s|%s\n", item.ID, item.Name)
+			}
+		}
+		printIgnored("Contacts", list.Contacts)
+		fmt.Println()
+		printIgnored("Conversations", list.Conversations)
+		return nil
+	},
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// keyBinding is one entry in a TUI's help overlay
+type keyBinding struct {
+	keys string
+	desc string
+}
+
+// keyGroup is a category of bindings shown together in the help overlay
+type keyGroup struct {
+	title    string
+	bindings []keyBinding
+}
+
+var contactsKeyHelp = []keyGroup{
+	{"Navigation", []keyBinding{
+		{"j/k, ↓/↑", "down/up"},
+		{"g/G, home/end", "top/bottom"},
+		{"pgup/pgdn", "page up/down"},
+		{"/", "filter by name, email, phone, or tag"},
+		{"o", "sort by name, email, modified, or synced"},
+		{"</>", "move the divider between the panes"},
+	}},
+	{"Selection", []keyBinding{
+		{"space", "select the contact and move down"},
+		{"esc", "clear the selection"},
+		{"t/T", "add/remove a tag on the selected contacts"},
+	}},
+	{"Actions", []keyBinding{
+		{"c/m", "call or email, choosing which number/address"},
+		{"y/Y", "copy a phone number/email address"},
+		{"E", "edit notes in $EDITOR"},
+		{"f", "toggle favorite"},
+		{"p", "pin or unpin at the top of the list"},
+		{"J/K", "move a pinned contact down/up"},
+		{"d", "archive or delete the selected contacts"},
+		{"u", "undo the last archive or delete"},
+		{"s", "sync with the provider"},
+	}},
+	{"General", []keyBinding{
+		{"?", "toggle this help"},
+		{"q", "quit"},
+	}},
+}
+
+var messagesKeyHelp = []keyGroup{
+	{"Conversations", []keyBinding{
+		{"j/k, ↓/↑", "down/up"},
+		{"g/G, home/end", "top/bottom"},
+		{"pgup/pgdn", "page up/down"},
+		{"enter", "open the conversation fullscreen"},
+		{"</>", "move the divider between the panes"},
+		{"p", "pin or unpin at the top of the list"},
+		{"J/K", "move a pinned conversation down/up"},
+		{"d", "remove from the list"},
+		{"s", "sync with the provider"},
+	}},
+	{"Open conversation", []keyBinding{
+		{"j/k, ↓/↑", "down/up"},
+		{"g/G, home/end", "oldest/newest"},
+		{"/", "search"},
+		{"n/N", "next/previous match"},
+		{"Y", "copy the transcript"},
+		{"esc, q", "clear the search or go back"},
+	}},
+	{"General", []keyBinding{
+		{"?", "toggle this help"},
+		{"q", "quit"},
+	}},
+}
+
+var timelineKeyHelp = []keyGroup{
+	{"Navigation", []keyBinding{
+		{"j/k, ↓/↑", "down/up"},
+		{"pgup/pgdn", "page up/down"},
+		{"g/G, home/end", "top/bottom"},
+	}},
+	{"General", []keyBinding{
+		{"?", "toggle this help"},
+		{"q", "quit"},
+	}},
+}
+
+// renderKeyHelp renders a TUI's help overlay, centered in width x height
+func renderKeyHelp(title string, groups []keyGroup, width, height int) string {
+	titleStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+	groupStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("170"))
+	keyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("255"))
+	descStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		Padding(1, 2)
+
+	keyWidth := 0
+	for _, g := range groups {
+		for _, b := range g.bindings {
+			keyWidth = max(keyWidth, lipgloss.Width(b.keys))
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(title))
+	sb.WriteString("\n")
+	for _, g := range groups {
+		sb.WriteString("\n")
+		sb.WriteString(groupStyle.Render(g.title))
+		sb.WriteString("\n")
+		for _, b := range g.bindings {
+			sb.WriteString("  " + keyStyle.Render(padRight(b.keys, keyWidth)) + "  " + descStyle.Render(b.desc))
+			sb.WriteString("\n")
+		}
+	}
+	sb.WriteString("\n")
+	sb.WriteString(footerStyle.Render("?/esc: close"))
+
+	return lipgloss.Place(width, height,
+		lipgloss.Center, lipgloss.Center,
+		boxStyle.Render(sb.String()))
+}
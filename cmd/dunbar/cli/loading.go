@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/arjungandhi/dunbar/pkg/logging"
+)
+
+// loadedMsg carries the model a loadingModel was waiting for
+type loadedMsg struct {
+	model tea.Model
+	err   error
+}
+
+// loadingModel shows a spinner while a TUI's data loads in the background,
+// then hands over to the model built from it
+type loadingModel struct {
+	spinner spinner.Model
+	what    string // What's loading, e.g. "contacts"
+	load    func() (tea.Model, error)
+	size    *tea.WindowSizeMsg // Passed on to the loaded model, which missed it
+	err     error              // Why loading failed
+}
+
+// runLoadingTUI runs a TUI whose model takes a while to build: a spinner
+// shows until load returns, then its model takes over. It returns the final
+// model, or nil if the user quit before loading finished. In pick mode the
+// TUI draws on stderr so stdout stays free for the result.
+func runLoadingTUI(what string, pick bool, load func() (tea.Model, error)) (tea.Model, error) {
+	// Logs go to the file only; writing to the terminal would corrupt the screen
+	logging.DisableConsole()
+	opts := []tea.ProgramOption{tea.WithAltScreen()}
+	if pick {
+		opts = append(opts, tea.WithOutput(os.Stderr))
+	}
+
+	m := loadingModel{
+		spinner: spinner.New(spinner.WithSpinner(spinner.Dot)),
+		what:    what,
+		load:    load,
+	}
+	result, err := tea.NewProgram(m, opts...).Run()
+	if err != nil {
+		return nil, fmt.Errorf("TUI error: %w", err)
+	}
+	if loading, ok := result.(loadingModel); ok {
+		return nil, loading.err
+	}
+	return result, nil
+}
+
+func (m loadingModel) Init() tea.Cmd {
+	load := m.load
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		model, err := load()
+		return loadedMsg{model: model, err: err}
+	})
+}
+
+func (m loadingModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.size = &msg
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		}
+		return m, nil
+
+	case spinner.TickMsg:
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case loadedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, tea.Quit
+		}
+		next := msg.model
+		cmds := []tea.Cmd{next.Init()}
+		if m.size != nil {
+			var cmd tea.Cmd
+			next, cmd = next.Update(*m.size)
+			cmds = append(cmds, cmd)
+		}
+		return next, tea.Batch(cmds...)
+	}
+	return m, nil
+}
+
+func (m loadingModel) View() string {
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	view := fmt.Sprintf("%s Loading %s...", m.spinner.View(), m.what)
+	if m.size == nil {
+		return view
+	}
+	return lipgloss.Place(m.size.Width, m.size.Height, lipgloss.Center, lipgloss.Center,
+		view+"\n\n"+dimStyle.Render("q: quit"))
+}
@@ -1,24 +1,49 @@
 package cli
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/arjungandhi/dunbar/pkg/config"
+	"github.com/arjungandhi/dunbar/pkg/contacts"
 	"github.com/arjungandhi/dunbar/pkg/messages"
+	"github.com/arjungandhi/dunbar/pkg/util"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/huh"
 	"github.com/charmbracelet/lipgloss"
 	Z "github.com/rwxrob/bonzai/z"
 	"github.com/rwxrob/help"
+	"golang.org/x/term"
 )
 
 var Messages = &Z.Cmd{
 	Name:     "messages",
 	Summary:  "Manage your messages and conversations",
-	Commands: []*Z.Cmd{help.Cmd, MessagesInit, MessagesList, MessagesSync},
+	Commands: []*Z.Cmd{help.Cmd, MessagesInit, MessagesList, MessagesListMessages, MessagesRename, MessagesMerge, MessagesSync, MessagesImport, MessagesStats, MessagesShow, MessagesAttachments, MessagesExport, MessagesExportAll, MessagesPick},
+	Description: `
+Without a command, open the messages TUI. It reopens on the conversation it
+was last left on; pass --reset to start at the top instead.
+
+The preview pane beside the list cuts each message to 200 columns and shows
+as many as fit. Set preview_max_chars and preview_max_messages in
+config.json to change either, e.g. "preview_max_messages": 5.
+
+Deleting asks for a key press. Set "delete_confirm": "name" to have the
+conversation's title typed instead, or "none" to delete without asking.
+`,
 	Call: func(x *Z.Cmd, args ...string) error {
 		// Default action: open TUI
 		return runMessagesTUI(x, args...)
@@ -28,30 +53,45 @@ var Messages = &Z.Cmd{
 var MessagesInit = &Z.Cmd{
 	Name:    "init",
 	Summary: "Initialize messages provider",
+	Usage:   "[--provider NAME]",
+	Description: `
+Choose a messages provider and set it up. The provider picker is skipped
+when --provider names one (e.g. --provider beeper) or a provider was chosen
+before (the messages_provider setting in config.json).
+`,
 	Call: func(x *Z.Cmd, args ...string) error {
-		cfg := config.New()
+		cfg := loadConfig()
 		if err := cfg.EnsureDunbarDir(); err != nil {
 			return fmt.Errorf("failed to create dunbar directory: %w", err)
 		}
 
-		// Run provider selection in Bubble Tea
-		m := newMessageProviderSelectModel()
-		p := tea.NewProgram(m)
-		result, err := p.Run()
-		if err != nil {
-			return fmt.Errorf("provider selection failed: %w", err)
+		// Only ask which provider to use if it isn't already known
+		providerType := flagValue(args, "--provider")
+		if providerType == "" {
+			providerType = cfg.MessagesProvider
 		}
+		if providerType == "" {
+			m := newMessageProviderSelectModel()
+			p := tea.NewProgram(m)
+			result, err := p.Run()
+			if err != nil {
+				return fmt.Errorf("provider selection failed: %w", err)
+			}
 
-		providerModel := result.(messageProviderSelectModel)
-		if providerModel.cancelled {
-			return fmt.Errorf("initialization cancelled")
+			providerModel := result.(messageProviderSelectModel)
+			if providerModel.cancelled {
+				return fmt.Errorf("initialization cancelled")
+			}
+			providerType = providerModel.selectedProvider
 		}
 
-		providerType := providerModel.selectedProvider
-
-		// Initialize the selected provider
+		// Initialize the selected provider, saving it to config
 		switch providerType {
 		case "beeper":
+			cfg.MessagesProvider = providerType
+			if err := cfg.Save(); err != nil {
+				return err
+			}
 			return initBeeperProvider(cfg)
 		default:
 			return fmt.Errorf("unsupported provider: %s", providerType)
@@ -144,6 +184,7 @@ func initBeeperProvider(cfg *config.Config) error {
 	if err != nil {
 		return fmt.Errorf("failed to create provider: %w", err)
 	}
+	provider.SetProgressOutput(os.Stdout)
 
 	// Check if credentials already exist
 	existingCreds, _ := provider.LoadCredentials()
@@ -221,7 +262,7 @@ func initBeeperProvider(cfg *config.Config) error {
 
 	// Test the connection by attempting a sync
 	fmt.Println("\nTesting connection to Beeper...")
-	_, _, err = provider.Sync()
+	_, _, err = provider.Sync(cmdCtx)
 	if err != nil {
 		return fmt.Errorf("failed to connect to Beeper: %w", err)
 	}
@@ -235,16 +276,23 @@ func initBeeperProvider(cfg *config.Config) error {
 var MessagesList = &Z.Cmd{
 	Name:    "list",
 	Summary: "List all conversations",
+	Usage:   "[--platform NAME] [--type single|group] [--account ID]",
 	Call: func(x *Z.Cmd, args ...string) error {
-		cfg := config.New()
+		filter := messages.ConversationFilter{
+			Platform:  flagValue(args, "--platform"),
+			Type:      flagValue(args, "--type"),
+			AccountID: flagValue(args, "--account"),
+		}
+
+		cfg := loadConfig()
 		mm, err := getMessageManager(cfg)
 		if err != nil {
 			return err
 		}
 		defer mm.Close()
 
-		// Get all conversations from the database
-		conversations, err := getAllConversations(mm)
+		// Get matching conversations from the database
+		conversations, err := mm.ListAllConversations(filter)
 		if err != nil {
 			return fmt.Errorf("failed to list conversations: %w", err)
 		}
@@ -266,19 +314,408 @@ var MessagesList = &Z.Cmd{
 	},
 }
 
+// defaultShowLimit is how many recent messages 'messages show' prints
+const defaultShowLimit = 30
+
+var MessagesShow = &Z.Cmd{
+	Name:    "show",
+	Summary: "Print a conversation transcript",
+	Usage:   "<conversation-id> [--limit N] [--all]",
+	MinArgs: 1,
+	Description: `
+Print the most recent messages of a conversation (30 by default) as a
+transcript, oldest first. Use --limit to change how many are shown or
+--all to print the whole thread. Conversation IDs are listed by
+'dunbar messages list'.
+
+Times follow the clock_24_hour, date_day_first, and time_zone settings in
+config.json, e.g. "time_zone": "UTC".
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		convID := args[0]
+
+		limit := defaultShowLimit
+		if value := flagValue(args, "--limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid --limit %q: must be a positive number", value)
+			}
+			limit = n
+		}
+		if hasFlag(args, "--all") {
+			limit = 0
+		}
+
+		cfg := loadConfig()
+		mm, err := getMessageManager(cfg)
+		if err != nil {
+			return err
+		}
+		defer mm.Close()
+
+		conv, err := mm.GetConversation(convID)
+		if err != nil {
+			return fmt.Errorf("failed to load conversation: %w", err)
+		}
+		if conv == nil {
+			return fmt.Errorf("conversation not found: %s", convID)
+		}
+
+		// Messages come newest first; keep the latest and print oldest first
+		var msgs []messages.Message
+		if limit > 0 {
+			msgs, err = mm.GetMessagesForConversationPage(conv.ID, "", limit)
+		} else {
+			msgs, err = mm.GetMessagesForConversation(conv.ID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load messages: %w", err)
+		}
+		slices.Reverse(msgs)
+		newSenderResolver(*conv, msgs, loadLocalContacts(cfg)).apply(msgs)
+
+		fmt.Print(renderTranscript(*conv, msgs, terminalWidth(), cfg.DisplayTimeFormat()))
+		return nil
+	},
+}
+
+var MessagesListMessages = &Z.Cmd{
+	Name:    "list-messages",
+	Summary: "List a conversation's messages, one per line",
+	Usage:   "<conversation-id>",
+	MinArgs: 1,
+	MaxArgs: 1,
+	Description: `
+Print every message of a conversation, oldest first, one per line as
+timestamp|sender|direction|text, where direction is "sent" or "received".
+Line breaks and backslashes in the text are escaped as \n and \\ so each
+message stays on one line for grep and cut.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		cfg := loadConfig()
+		mm, err := getMessageManager(cfg)
+		if err != nil {
+			return err
+		}
+		defer mm.Close()
+
+		conv, err := mm.GetConversation(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to load conversation: %w", err)
+		}
+		if conv == nil {
+			return fmt.Errorf("conversation not found: %s", args[0])
+		}
+
+		msgs, err := mm.GetMessagesForConversation(conv.ID)
+		if err != nil {
+			return fmt.Errorf("failed to load messages: %w", err)
+		}
+		slices.Reverse(msgs)
+		newSenderResolver(*conv, msgs, loadLocalContacts(cfg)).apply(msgs)
+
+		// Format: Timestamp|Sender|Direction|Text
+		for _, msg := range msgs {
+			direction := "received"
+			if msg.IsSent {
+				direction = "sent"
+			}
+			fmt.Printf("%s|%s|%s|%s\n",
+				msg.Timestamp.Format(time.RFC3339),
+				exportSender(msg),
+				direction,
+				escapeLineBreaks(exportMessageText(msg)),
+			)
+		}
+
+		return nil
+	},
+}
+
+var MessagesRename = &Z.Cmd{
+	Name:    "rename",
+	Summary: "Give a conversation a local title",
+	Usage:   "<conversation-id> [title...]",
+	MinArgs: 1,
+	Description: `
+Set a title for a conversation that's shown instead of the platform's, e.g.
+'dunbar messages rename <id> Family group'. The title is only stored locally
+and is kept across syncs. Without a title, the platform's title is restored.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		cfg := loadConfig()
+		mm, err := getMessageManager(cfg)
+		if err != nil {
+			return err
+		}
+		defer mm.Close()
+
+		conv, err := mm.GetConversation(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to load conversation: %w", err)
+		}
+		if conv == nil {
+			return fmt.Errorf("conversation not found: %s", args[0])
+		}
+
+		title := strings.TrimSpace(strings.Join(args[1:], " "))
+		if err := mm.RenameConversation(conv.ID, title); err != nil {
+			return err
+		}
+
+		if title == "" {
+			original := conv.Title
+			if conv.OriginalTitle != "" {
+				original = conv.OriginalTitle
+			}
+			fmt.Printf("Restored the title %q\n", original)
+		} else {
+			fmt.Printf("Renamed to %q\n", title)
+		}
+		return nil
+	},
+}
+
+var MessagesMerge = &Z.Cmd{
+	Name:    "merge",
+	Summary: "Merge duplicate conversations",
+	Usage:   "[<into-id> <from-id>]",
+	Description: `
+When the same chat is synced through two accounts it shows up as two
+conversations. 'dunbar messages merge <into-id> <from-id>' moves the second
+conversation's messages into the first, adds its unread count, and stops
+listing it. The merge is kept across syncs.
+
+Without arguments, list the conversations that look like duplicates (same
+platform, type, and title on different accounts), one group per paragraph.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		if len(args) != 0 && len(args) != 2 {
+			return fmt.Errorf("usage: dunbar messages merge [<into-id> <from-id>]")
+		}
+
+		cfg := loadConfig()
+		mm, err := getMessageManager(cfg)
+		if err != nil {
+			return err
+		}
+		defer mm.Close()
+
+		if len(args) == 2 {
+			if err := mm.MergeConversations(args[0], args[1]); err != nil {
+				return fmt.Errorf("failed to merge conversations: %w", err)
+			}
+			fmt.Printf("Merged %s into %s\n", args[1], args[0])
+			return nil
+		}
+
+		conversations, err := mm.ListAllConversations(messages.ConversationFilter{})
+		if err != nil {
+			return fmt.Errorf("failed to list conversations: %w", err)
+		}
+
+		// Format: ID|Title|Platform|AccountID|LastActivity
+		for i, group := range messages.FindDuplicateConversations(conversations) {
+			if i > 0 {
+				fmt.Println()
+			}
+			for _, conv := range group {
+				fmt.Printf("%s|%s|%s|%s|%s\n",
+					conv.ID,
+					conv.Title,
+					conv.Platform,
+					conv.AccountID,
+					conv.LastActivity.Format(time.RFC3339),
+				)
+			}
+		}
+		return nil
+	},
+}
+
+// escapeLineBreaks escapes backslashes and line breaks so text prints on one line
+func escapeLineBreaks(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\r", `\r`, "\n", `\n`).Replace(s)
+}
+
+// renderTranscript renders messages with date separators the same way the
+// messages view does
+func renderTranscript(conv messages.Conversation, msgs []messages.Message, width int, tf util.TimeFormat) string {
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+
+	var sb strings.Builder
+	sb.WriteString(headerStyle.Render(conv.Title))
+	sb.WriteString("\n\n")
+
+	if len(msgs) == 0 {
+		sb.WriteString("No messages found\n")
+		return sb.String()
+	}
+
+	var prevMsg *messages.Message
+	for _, item := range insertDateSeparators(msgs, tf) {
+		if item.isSeparator() {
+			sb.WriteString(renderDateSeparator(*item.dateSeparator, width))
+			prevMsg = nil // Reset grouping after date separator
+			continue
+		}
+		sb.WriteString(formatMessage(*item.message, width, prevMsg, tf, ""))
+		prevMsg = item.message
+	}
+
+	return sb.String()
+}
+
+var MessagesAttachments = &Z.Cmd{
+	Name:    "attachments",
+	Summary: "List or download a conversation's attachments",
+	Usage:   "<conversation-id> [--manifest FILE] [--download DIR]",
+	MinArgs: 1,
+	Description: `
+List every attachment in a conversation, oldest first, without downloading
+anything. Each line is MessageID|Timestamp|Type|FileName|Size|MimeType|SrcURL.
+With --manifest the list is written to FILE as JSON instead.
+
+With --download the attachments are saved to DIR instead, as DATE_HASH_NAME
+(e.g. 2024-03-01_1a2b3c4d_photo.jpg), through Beeper Desktop, which must be
+running. Downloads go to a .part file first and are checked against the
+attachment's size, so running the command again skips what's already been
+downloaded and resumes interrupted files. An attachment that still fails
+after a few tries is reported and the rest are downloaded anyway.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		convID := args[0]
+
+		cfg := loadConfig()
+		mm, err := getMessageManager(cfg)
+		if err != nil {
+			return err
+		}
+		defer mm.Close()
+
+		conv, err := mm.GetConversation(convID)
+		if err != nil {
+			return fmt.Errorf("failed to load conversation: %w", err)
+		}
+		if conv == nil {
+			return fmt.Errorf("conversation not found: %s", convID)
+		}
+
+		refs, err := mm.ListAttachments(convID)
+		if err != nil {
+			return fmt.Errorf("failed to list attachments: %w", err)
+		}
+
+		if dir := flagValue(args, "--download"); dir != "" {
+			return downloadAttachments(mm, refs, dir)
+		}
+
+		path := flagValue(args, "--manifest")
+		if path == "" {
+			for _, ref := range refs {
+				fmt.Printf("%s|%s|%s|%s|%d|%s|%s\n",
+					ref.MessageID,
+					ref.Timestamp.Format(time.RFC3339),
+					ref.Type,
+					ref.FileName,
+					int64(ref.FileSize),
+					ref.MimeType,
+					ref.SrcURL,
+				)
+			}
+			return nil
+		}
+
+		if refs == nil {
+			refs = []messages.AttachmentRef{}
+		}
+		data, err := json.MarshalIndent(refs, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal manifest: %w", err)
+		}
+		if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write manifest: %w", err)
+		}
+		fmt.Printf("Wrote %d attachments to %s\n", len(refs), path)
+		return nil
+	},
+}
+
+// downloadAttachments downloads refs into dir, printing a line for each, and
+// returns an error if any failed
+func downloadAttachments(mm *messages.MessageManager, refs []messages.AttachmentRef, dir string) error {
+	var downloaded, skipped, failed int
+	err := mm.DownloadAttachments(cmdCtx, refs, dir, func(r messages.DownloadResult) {
+		name := filepath.Base(r.Path)
+		switch {
+		case r.Err != nil:
+			failed++
+			fmt.Fprintf(os.Stderr, "Failed %s: %v\n", name, r.Err)
+		case r.Skipped:
+			skipped++
+			fmt.Printf("Skipped %s (already downloaded)\n", name)
+		case r.Resumed > 0:
+			downloaded++
+			fmt.Printf("Downloaded %s (resumed after %s)\n", name, util.HumanBytes(float64(r.Resumed)))
+		default:
+			downloaded++
+			fmt.Printf("Downloaded %s\n", name)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to download attachments: %w", err)
+	}
+
+	fmt.Printf("%d downloaded, %d skipped, %d failed\n", downloaded, skipped, failed)
+	if failed > 0 {
+		return fmt.Errorf("%s failed to download; run the command again to retry", plural(failed, "attachment"))
+	}
+	return nil
+}
+
+// terminalWidth returns the width of stdout, or 80 when it isn't a terminal
+func terminalWidth() int {
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width
+	}
+	return 80
+}
+
 var MessagesSync = &Z.Cmd{
 	Name:    "sync",
 	Summary: "Sync messages with Beeper",
+	Usage:   "[--network NETWORK,...] [--wait]",
+	Description: `
+Fetch conversations and messages from Beeper Desktop into the local
+database. The access token saved by 'dunbar messages init' is used if
+present; otherwise the BEEPER_ACCESS_TOKEN environment variable is read.
+Beeper Desktop must be running; --wait waits for it to start instead of
+failing.
+
+By default every chat on every account is synced. The messages_accounts
+and messages_networks settings in config.json limit syncs to those Beeper
+account IDs and networks; --network (e.g. --network whatsapp,telegram)
+overrides the configured networks for one sync.
+`,
 	Call: func(x *Z.Cmd, args ...string) error {
-		cfg := config.New()
+		cfg := loadConfig()
+		if value := flagValue(args, "--network"); value != "" {
+			cfg.MessagesNetworks = strings.Split(value, ",")
+		}
 		mm, err := getMessageManager(cfg)
 		if err != nil {
 			return err
 		}
 		defer mm.Close()
 
+		if hasFlag(args, "--wait") {
+			if err := messages.WaitForBeeper(cmdCtx, os.Stdout); err != nil {
+				return err
+			}
+		}
+
 		// Sync will print its own progress
-		if err := mm.Sync(); err != nil {
+		if err := mm.Sync(cmdCtx); err != nil {
 			return fmt.Errorf("failed to sync messages: %w", err)
 		}
 
@@ -286,8 +723,130 @@ var MessagesSync = &Z.Cmd{
 	},
 }
 
+// statsDays is how many days of daily message counts the stats command shows
+const statsDays = 30
+
+var MessagesImport = &Z.Cmd{
+	Name:    "import",
+	Summary: "Import a chat exported from WhatsApp",
+	Usage:   "<file> [--me NAME] [--title TITLE] [--date-order dmy|mdy]",
+	MinArgs: 1,
+	Description: `
+Import a chat exported with WhatsApp's "Export chat", for history from
+before Beeper. FILE is the exported _chat.txt ("WhatsApp Chat with
+NAME.txt" on Android) or the .zip it came in. The conversation is named
+after the file unless --title is given.
+
+Pass --me with your name as it appears in the export so your messages are
+marked as sent; in a one-to-one chat named after the other person, you're
+recognized without it. Dates are read day or month first depending on
+which the export's dates allow, favoring month first for 12-hour times;
+--date-order settles it when every date could be either.
+
+Media left out of the export is imported as attachment placeholders, and
+media files next to a _chat.txt are linked so 'dunbar messages
+attachments --download' can copy them. Importing a chat again, or a later
+export of it, updates what was imported before instead of duplicating it.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		export := messages.NewWhatsAppExport(args[0])
+		export.SetSelf(flagValue(args, "--me"))
+		export.SetTitle(flagValue(args, "--title"))
+		if err := export.SetDateOrder(flagValue(args, "--date-order")); err != nil {
+			return err
+		}
+
+		cfg := loadConfig()
+		mm, err := getMessageManager(cfg)
+		if err != nil {
+			return err
+		}
+		defer mm.Close()
+
+		conversations, count, err := mm.Import(cmdCtx, export)
+		if err != nil {
+			return fmt.Errorf("failed to import %s: %w", args[0], err)
+		}
+		if len(conversations) == 0 {
+			fmt.Println("Nothing imported: the conversation is ignored. See 'dunbar ignores'.")
+			return nil
+		}
+		for _, conv := range conversations {
+			fmt.Printf("Imported %s into %s (%s)\n", plural(count, "message"), conv.Title, conv.ID)
+		}
+		return nil
+	},
+}
+
+var MessagesStats = &Z.Cmd{
+	Name:    "stats",
+	Summary: "Summarize all synced conversations and messages",
+	Usage:   "[--json]",
+	Call: func(x *Z.Cmd, args ...string) error {
+		cfg := loadConfig()
+		mm, err := getMessageManager(cfg)
+		if err != nil {
+			return err
+		}
+		defer mm.Close()
+
+		stats, err := mm.Stats(statsDays)
+		if err != nil {
+			return fmt.Errorf("failed to compute stats: %w", err)
+		}
+
+		if hasFlag(args, "--json") {
+			data, err := json.MarshalIndent(stats, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal stats: %w", err)
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
+		fmt.Printf("Conversations: %d\n", stats.Conversations)
+		fmt.Printf("Messages:      %d\n", stats.Messages)
+		fmt.Printf("Database size: %s\n", util.HumanBytes(float64(stats.DBSize)))
+
+		if len(stats.Platforms) > 0 {
+			fmt.Println("\nBy platform:")
+			for _, p := range stats.Platforms {
+				fmt.Printf("  %-12s %6d conversations %8d messages\n", p.Platform, p.Conversations, p.Messages)
+			}
+		}
+
+		if stats.Busiest != nil {
+			fmt.Printf("\nBusiest conversation: %s (%s) - %d messages\n",
+				stats.Busiest.Title, stats.Busiest.Platform, stats.Busiest.Messages)
+		}
+
+		// Scale the bars to the busiest day
+		busiestDay := 0
+		for _, day := range stats.Daily {
+			busiestDay = max(busiestDay, day.Messages)
+		}
+		const barWidth = 40
+
+		fmt.Printf("\nMessages per day (last %d days):\n", statsDays)
+		for _, day := range stats.Daily {
+			bar := ""
+			if busiestDay > 0 {
+				bar = strings.Repeat("▇", day.Messages*barWidth/busiestDay)
+			}
+			fmt.Printf("  %s %5d %s\n", day.Date, day.Messages, bar)
+		}
+
+		return nil
+	},
+}
+
 // Helper function to get or create MessageManager
 func getMessageManager(cfg *config.Config) (*messages.MessageManager, error) {
+	return newMessageManager(cfg, os.Stdout)
+}
+
+// newMessageManager creates a MessageManager whose provider prints sync progress to progress
+func newMessageManager(cfg *config.Config, progress io.Writer) (*messages.MessageManager, error) {
 	if err := cfg.EnsureDunbarDir(); err != nil {
 		return nil, fmt.Errorf("failed to create dunbar directory: %w", err)
 	}
@@ -297,43 +856,160 @@ func getMessageManager(cfg *config.Config) (*messages.MessageManager, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Beeper provider: %w", err)
 	}
+	provider.SetProgressOutput(progress)
+	provider.SetConcurrency(cfg.MessagesConcurrency)
+	provider.SetAccountFilter(cfg.MessagesAccounts)
+	provider.SetNetworkFilter(cfg.MessagesNetworks)
 
-	// Initialize provider (loads credentials from file)
-	if err := provider.Initialize(); err != nil {
-		return nil, fmt.Errorf("failed to initialize provider: %w. Run 'dunbar messages init' first", err)
-	}
+	// The provider loads credentials lazily when syncing, so listing and
+	// browsing work offline against the local database
 
 	// Create MessageManager
-	return messages.NewMessageManager(provider, *cfg)
+	mm, err := messages.NewMessageManager(provider, *cfg)
+	if errors.Is(err, messages.ErrCorruptDB) {
+		if !confirmRecreateDB(cfg) {
+			return nil, fmt.Errorf("%w. Messages can be re-synced: delete %s and run 'dunbar messages sync'",
+				err, messages.DBPath(*cfg))
+		}
+		backup, backupErr := messages.BackupCorruptDB(messages.DBPath(*cfg))
+		if backupErr != nil {
+			return nil, backupErr
+		}
+		fmt.Fprintf(os.Stderr, "Moved the corrupted database to %s. Run 'dunbar messages sync' to re-download your messages.\n", backup)
+		mm, err = messages.NewMessageManager(provider, *cfg)
+	}
+	return mm, err
+}
+
+// confirmRecreateDB asks whether to replace a corrupted messages database.
+// Without a terminal to ask on, the answer is no.
+func confirmRecreateDB(cfg *config.Config) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+
+	var recreate bool
+	form := huh.NewForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title("The messages database is corrupted").
+				Description(fmt.Sprintf("Back up %s and start a new one? Messages can be re-synced from the provider.", messages.DBPath(*cfg))).
+				Affirmative("Yes, recreate").
+				Negative("No").
+				Value(&recreate),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return false
+	}
+	return recreate
 }
 
 // getAllConversations gets all conversations from the database
 func getAllConversations(mm *messages.MessageManager) ([]messages.Conversation, error) {
-	return mm.ListAllConversations()
+	return mm.ListAllConversations(messages.ConversationFilter{})
 }
 
 // TUI implementation
 func runMessagesTUI(x *Z.Cmd, args ...string) error {
-	cfg := config.New()
-	mm, err := getMessageManager(cfg)
+	_, err := messagesTUI(false, hasFlag(args, "--reset"), "", "")
+	return err
+}
+
+var MessagesPick = &Z.Cmd{
+	Name:    "pick",
+	Summary: "Choose a conversation in the TUI and print its ID",
+	Usage:   "[--reset]",
+	Description: `
+Open the messages TUI as a picker: enter on a conversation quits and prints
+its ID to standard output, so other tools can capture it, e.g.
+'dunbar messages show "$(dunbar messages pick)"'. The TUI itself is drawn
+on standard error. Quitting without picking exits with an error. It starts
+on the last conversation chosen unless --reset is given.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		convID, err := messagesTUI(true, hasFlag(args, "--reset"), "", "")
+		if err != nil {
+			return err
+		}
+		if convID == "" {
+			return fmt.Errorf("no conversation picked")
+		}
+		fmt.Println(convID)
+		return nil
+	},
+}
+
+// messagesTUI runs the messages TUI. In pick mode, enter on a conversation
+// quits and returns its ID, and the TUI is drawn on stderr so stdout stays
+// free for the result. Unless reset is set, the cursor starts on the
+// conversation the TUI was last left on. If openID is set, that conversation
+// is opened instead, with search applied to it when not empty.
+func messagesTUI(pick, reset bool, openID, search string) (string, error) {
+	cfg := loadConfig()
+	// Sync progress would corrupt the TUI, so it is discarded
+	mm, err := newMessageManager(cfg, io.Discard)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer mm.Close()
 
-	conversations, err := getAllConversations(mm)
-	if err != nil {
-		return fmt.Errorf("failed to list conversations: %w", err)
-	}
+	// A spinner shows while conversations and contacts are read
+	result, err := runLoadingTUI("conversations", pick, func() (tea.Model, error) {
+		conversations, err := getAllConversations(mm)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list conversations: %w", err)
+		}
 
-	m := newMessagesModel(conversations, mm)
-	p := tea.NewProgram(m, tea.WithAltScreen())
+		m := newMessagesModel(conversations, mm)
+		m.contacts = loadLocalContacts(cfg)
+		m.timeFormat = cfg.DisplayTimeFormat()
+		m.pick = pick
+		m.cfg = cfg
+		m.splitRatio = cfg.PaneSplit()
+		m.previewChars = cfg.PreviewChars()
+		m.deleteConfirm = newDeleteConfirm(cfg.DeleteConfirm)
+		m.previewLimit = cfg.PreviewMaxMessages
+		// Fall back to the top if the saved conversation no longer exists
+		if convID := loadTUIState(cfg, reset).ConversationID; convID != "" {
+			m.cursor = max(0, slices.IndexFunc(m.conversations, func(c messages.Conversation) bool { return c.ID == convID }))
+			m.clampViewport()
+			m.loadPreview()
+		}
+		if i := slices.IndexFunc(m.conversations, func(c messages.Conversation) bool { return c.ID == openID }); i >= 0 {
+			m.cursor = i
+			m.clampViewport()
+			m.loadPreview()
+			m.openConversation()
+			if search != "" {
+				m.applySearch(search)
+			}
+		}
+		return m, nil
+	})
+	if err != nil || result == nil {
+		return "", err
+	}
 
-	if _, err := p.Run(); err != nil {
-		return fmt.Errorf("TUI error: %w", err)
+	final := result.(messagesModel)
+	if final.cursor < len(final.conversations) {
+		saveTUIState(cfg, func(s *config.TUIState) { s.ConversationID = final.conversations[final.cursor].ID })
 	}
+	return final.picked, nil
+}
 
-	return nil
+// loadLocalContacts reads contacts from local storage without initializing a provider.
+// Contacts are only used to resolve names, so failures yield an empty list.
+func loadLocalContacts(cfg *config.Config) []contacts.Contact {
+	cm, err := contacts.NewContactManager(nil, *cfg, cfg.DataDir)
+	if err != nil {
+		return nil
+	}
+	contactsList, err := cm.ListContacts()
+	if err != nil {
+		return nil
+	}
+	return contactsList
 }
 
 // Bubble Tea model for messages TUI
@@ -351,6 +1027,68 @@ type messagesModel struct {
 	messagesViewTop  int
 	confirmingDelete bool
 	deleteConvID     string
+	deleteConfirm    deleteConfirm      // How deletes are confirmed (the delete_confirm setting)
+	contacts         []contacts.Contact // Local contacts used to resolve participant names
+	senders          senderResolver     // Resolves sender names for the open conversation
+	syncing          bool               // True while a provider sync runs in the background
+	spinner          spinner.Model
+	status           string             // Result of the last background action
+	messagesHasMore  bool               // True while older messages remain unloaded
+	previewConvID    string             // Conversation whose messages are in previewMessages
+	previewMessages  []messages.Message // Messages shown in the preview pane
+	previewChars     int                // Display width each preview message is cut to
+	previewLimit     int                // Most messages the preview pane shows; zero for as many as fit
+	timeFormat       util.TimeFormat    // How timestamps and date separators are shown
+	searching        bool               // True while typing a search for the open conversation
+	searchInput      textinput.Model
+	searchQuery      string         // Search applied to the open conversation, highlighted in messages
+	searchMatches    []int          // Indexes into messages that match searchQuery
+	searchMatch      int            // Position in searchMatches of the current match
+	pick             bool           // Picker mode: enter chooses the conversation under the cursor and quits
+	picked           string         // Conversation ID chosen in picker mode
+	splitRatio       float64        // Share of the width given to the conversation list, moved with '<' and '>'
+	cfg              *config.Config // Where a moved divider is saved
+	showHelp         bool           // True while the '?' help overlay is open
+}
+
+// The single-conversation view loads messages a page at a time, fetching the
+// next older page once the cursor comes within messagesPrefetch of the end
+const (
+	messagesPageSize = 200
+	messagesPrefetch = 20
+)
+
+// messagesSyncedMsg is sent when a background messages sync finishes
+type messagesSyncedMsg struct {
+	conversations []messages.Conversation
+	err           error
+}
+
+// syncMessagesCmd syncs with the provider in the background. The provider is
+// only initialized here, so the TUI opens instantly from the local database.
+func syncMessagesCmd(mm *messages.MessageManager) tea.Cmd {
+	return func() tea.Msg {
+		if err := mm.Sync(cmdCtx); err != nil {
+			return messagesSyncedMsg{err: err}
+		}
+		conversations, err := mm.ListAllConversations(messages.ConversationFilter{})
+		return messagesSyncedMsg{conversations: conversations, err: err}
+	}
+}
+
+// sortConversations sorts pinned conversations first, in pin order, then
+// the rest by last activity (most recent first)
+func sortConversations(conversations []messages.Conversation) {
+	sort.Slice(conversations, func(i, j int) bool {
+		pi, pj := conversations[i].PinOrder, conversations[j].PinOrder
+		if (pi > 0) != (pj > 0) {
+			return pi > 0
+		}
+		if pi != pj {
+			return pi < pj
+		}
+		return conversations[i].LastActivity.After(conversations[j].LastActivity)
+	})
 }
 
 // DateSeparator represents a date divider in message list
@@ -374,12 +1112,9 @@ func (d displayItem) isSeparator() bool {
 }
 
 func newMessagesModel(conversations []messages.Conversation, mm *messages.MessageManager) messagesModel {
-	// Sort conversations by last activity (most recent first)
-	sort.Slice(conversations, func(i, j int) bool {
-		return conversations[i].LastActivity.After(conversations[j].LastActivity)
-	})
+	sortConversations(conversations)
 
-	return messagesModel{
+	m := messagesModel{
 		conversations:    conversations,
 		cursor:           0,
 		viewportTop:      0,
@@ -389,7 +1124,169 @@ func newMessagesModel(conversations []messages.Conversation, mm *messages.Messag
 		viewMode:         "conversations",
 		confirmingDelete: false,
 		deleteConvID:     "",
+		spinner:          spinner.New(spinner.WithSpinner(spinner.Dot)),
+		searchInput:      newSearchInput(),
+		previewChars:     config.DefaultPreviewMaxChars,
+	}
+	m.loadPreview()
+	return m
+}
+
+// loadPreview loads the messages for the preview pane when the selected
+// conversation changes, so rendering never has to hit the database
+func (m *messagesModel) loadPreview() {
+	if m.cursor >= len(m.conversations) {
+		m.previewConvID = ""
+		m.previewMessages = nil
+		return
+	}
+
+	conv := m.conversations[m.cursor]
+	if conv.ID == m.previewConvID {
+		return
+	}
+
+	// Only a screenful is shown, so the first page is plenty
+	msgs, err := m.mm.GetMessagesForConversationPage(conv.ID, "", messagesPageSize)
+	if err != nil {
+		msgs = nil
+	}
+	m.previewConvID = conv.ID
+	m.previewMessages = msgs
+}
+
+// openConversation shows the messages of the conversation under the cursor,
+// starting with the most recent page; older ones follow as the cursor moves
+func (m *messagesModel) openConversation() {
+	if m.cursor >= len(m.conversations) {
+		return
+	}
+	conv := m.conversations[m.cursor]
+	m.viewMode = "messages"
+	m.selectedConvID = conv.ID
+
+	m.messages = nil
+	m.messagesHasMore = true
+	m.messagesCursor = 0
+	m.messagesViewTop = 0
+	m.loadOlderMessages()
+}
+
+// loadOlderMessages appends the next page of older messages to the open
+// conversation once the cursor nears the end of what has been loaded. Messages
+// are listed newest first, so older pages append and the viewport stays put.
+func (m *messagesModel) loadOlderMessages() {
+	if !m.messagesHasMore || m.messagesCursor < len(m.messages)-messagesPrefetch {
+		return
+	}
+
+	var before string
+	if len(m.messages) > 0 {
+		before = m.messages[len(m.messages)-1].ID
+	}
+	page, err := m.mm.GetMessagesForConversationPage(m.selectedConvID, before, messagesPageSize)
+	if err != nil {
+		m.messagesHasMore = false
+		m.status = fmt.Sprintf("Failed to load older messages: %v", err)
+		return
+	}
+
+	m.messagesHasMore = len(page) == messagesPageSize
+	m.messages = append(m.messages, page...)
+	m.senders = newSenderResolver(m.selectedConversation(), m.messages, m.contacts)
+	m.senders.apply(page)
+}
+
+// newSearchInput creates the text input used to search a conversation
+func newSearchInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = "/"
+	ti.Placeholder = "search this conversation"
+	return ti
+}
+
+// closeConversation leaves the messages view for the conversation list
+func (m *messagesModel) closeConversation() {
+	m.status = ""
+	m.viewMode = "conversations"
+	m.messages = nil
+	m.messagesHasMore = false
+	m.messagesCursor = 0
+	m.messagesViewTop = 0
+	m.searchQuery = ""
+	m.searchMatches = nil
+}
+
+// applySearch finds the messages in the open conversation containing query
+// and moves to the first match at or after the cursor. The rest of the
+// conversation is loaded first so older messages are searched too.
+func (m *messagesModel) applySearch(query string) {
+	m.searchQuery = strings.TrimSpace(query)
+	m.searchMatches = nil
+	m.searchMatch = 0
+	if m.searchQuery == "" {
+		return
+	}
+
+	if m.messagesHasMore {
+		msgs, err := m.mm.GetMessagesForConversation(m.selectedConvID)
+		if err != nil {
+			m.status = fmt.Sprintf("Failed to load messages: %v", err)
+			return
+		}
+		m.messages = msgs
+		m.messagesHasMore = false
+		m.senders = newSenderResolver(m.selectedConversation(), m.messages, m.contacts)
+		m.senders.apply(m.messages)
+	}
+
+	needle := strings.ToLower(m.searchQuery)
+	for i, msg := range m.messages {
+		if strings.Contains(strings.ToLower(msg.Text), needle) {
+			m.searchMatches = append(m.searchMatches, i)
+		}
+	}
+	if len(m.searchMatches) == 0 {
+		return
+	}
+
+	for i, idx := range m.searchMatches {
+		if idx >= m.messagesCursor {
+			m.searchMatch = i
+			break
+		}
 	}
+	m.messagesCursor = m.searchMatches[m.searchMatch]
+	m.clampMessagesViewport()
+}
+
+// copyTranscript copies the whole open conversation to the clipboard as a
+// plain-text transcript, oldest first
+func (m *messagesModel) copyTranscript() {
+	msgs, err := m.mm.GetMessagesForConversation(m.selectedConvID)
+	if err != nil {
+		m.status = fmt.Sprintf("Copy failed: %v", err)
+		return
+	}
+	slices.Reverse(msgs)
+	m.senders.apply(msgs)
+
+	if err := copyToClipboard(renderPlainTranscript(m.selectedConversation(), msgs, m.timeFormat)); err != nil {
+		m.status = fmt.Sprintf("Copy failed: %v", err)
+		return
+	}
+	m.status = fmt.Sprintf("Copied %d messages", len(msgs))
+}
+
+// nextMatch moves the cursor to the next (dir 1) or previous (dir -1) search
+// match, wrapping around at either end
+func (m *messagesModel) nextMatch(dir int) {
+	if len(m.searchMatches) == 0 {
+		return
+	}
+	m.searchMatch = (m.searchMatch + dir + len(m.searchMatches)) % len(m.searchMatches)
+	m.messagesCursor = m.searchMatches[m.searchMatch]
+	m.clampMessagesViewport()
 }
 
 func (m messagesModel) Init() tea.Cmd {
@@ -397,29 +1294,66 @@ func (m messagesModel) Init() tea.Cmd {
 }
 
 func (m messagesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m, cmd := m.update(msg)
+	m.loadPreview()
+	if m.viewMode == "messages" {
+		m.loadOlderMessages()
+	}
+	return m, cmd
+}
+
+func (m messagesModel) update(msg tea.Msg) (messagesModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		m.height = msg.Height - 3
+		m.height = max(1, msg.Height-3)
 		m.width = msg.Width
+		m.clampViewport()
+		m.clampMessagesViewport()
+
+	case spinner.TickMsg:
+		if !m.syncing {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case messagesSyncedMsg:
+		m.syncing = false
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Sync failed: %v", msg.err)
+			return m, nil
+		}
+		sortConversations(msg.conversations)
+		m.conversations = msg.conversations
+		m.previewConvID = "" // Messages may have changed
+		m.clampViewport()
+		m.status = fmt.Sprintf("Synced %d conversations", len(m.conversations))
+		return m, nil
 
 	case tea.KeyMsg:
 		// Handle delete confirmation
-		if m.confirmingDelete {
+		if m.confirmingDelete && m.deleteConfirm.typed() {
 			switch msg.String() {
-			case "y", "Y":
-				// For now, we don't actually delete from database
-				// Just remove from local list
-				for i, c := range m.conversations {
-					if c.ID == m.deleteConvID {
-						m.conversations = append(m.conversations[:i], m.conversations[i+1:]...)
-						break
-					}
-				}
-				if m.cursor >= len(m.conversations) && len(m.conversations) > 0 {
-					m.cursor = len(m.conversations) - 1
+			case "enter":
+				if m.deleteConfirm.matches(m.deleteConversation().Title) {
+					m.deletePending()
 				}
+				return m, nil
+			case "esc":
 				m.confirmingDelete = false
 				m.deleteConvID = ""
+				m.deleteConfirm.input.Blur()
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.deleteConfirm.input, cmd = m.deleteConfirm.input.Update(msg)
+			return m, cmd
+		}
+		if m.confirmingDelete {
+			switch msg.String() {
+			case "y", "Y":
+				m.deletePending()
 				return m, nil
 
 			case "n", "N", "esc":
@@ -430,17 +1364,72 @@ func (m messagesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		// Handle typing a search in the open conversation
+		if m.searching {
+			switch msg.String() {
+			case "enter":
+				m.searching = false
+				m.searchInput.Blur()
+				m.applySearch(m.searchInput.Value())
+				return m, nil
+			case "esc":
+				m.searching = false
+				m.searchInput.Blur()
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.searchInput, cmd = m.searchInput.Update(msg)
+			return m, cmd
+		}
+
+		// While the help overlay is open, keys only close it
+		if m.showHelp {
+			switch msg.String() {
+			case "?", "esc", "q":
+				m.showHelp = false
+			case "ctrl+c":
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+
+		if msg.String() == "?" {
+			m.showHelp = true
+			return m, nil
+		}
+
 		// Mode-specific key handling
 		if m.viewMode == "messages" {
 			switch msg.String() {
-			case "q", "esc":
-				// Go back to conversations view
-				m.viewMode = "conversations"
-				m.messages = nil
-				m.messagesCursor = 0
-				m.messagesViewTop = 0
+			case "esc":
+				// Clear an active search before leaving the conversation
+				if m.searchQuery != "" {
+					m.applySearch("")
+					return m, nil
+				}
+				m.closeConversation()
 				return m, nil
 
+			case "q":
+				m.closeConversation()
+				return m, nil
+
+			case "/":
+				m.status = ""
+				m.searching = true
+				m.searchInput.SetValue(m.searchQuery)
+				m.searchInput.CursorEnd()
+				return m, m.searchInput.Focus()
+
+			case "Y":
+				m.copyTranscript()
+
+			case "n":
+				m.nextMatch(1)
+
+			case "N":
+				m.nextMatch(-1)
+
 			case "up", "k":
 				if m.messagesCursor > 0 {
 					m.messagesCursor--
@@ -453,8 +1442,8 @@ func (m messagesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.messagesCursor < len(m.messages)-1 {
 					m.messagesCursor++
 					// Calculate exactly how many messages fit in viewport
-					availableHeight := max(1, m.height-4)
-					visibleMessages := calculateVisibleMessageCount(m.messages, m.messagesViewTop, m.width-4, availableHeight)
+					availableHeight := m.messagesAvailableHeight()
+					visibleMessages := calculateVisibleMessageCount(m.messages, m.messagesViewTop, m.width-4, availableHeight, m.timeFormat)
 
 					if m.messagesCursor >= m.messagesViewTop+visibleMessages {
 						m.messagesViewTop++
@@ -466,17 +1455,8 @@ func (m messagesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.messagesViewTop = 0
 
 			case "G", "end":
-				m.messagesCursor = len(m.messages) - 1
-				// Calculate exact visible messages and position viewport at the end
-				availableHeight := max(1, m.height-4)
-				// Try different starting positions to find where the last message is visible
-				for startIdx := len(m.messages) - 1; startIdx >= 0; startIdx-- {
-					visibleCount := calculateVisibleMessageCount(m.messages, startIdx, m.width-4, availableHeight)
-					if startIdx+visibleCount >= len(m.messages) {
-						m.messagesViewTop = startIdx
-						break
-					}
-				}
+				m.messagesCursor = max(0, len(m.messages)-1)
+				m.messagesViewTop = m.lastMessagesViewTop()
 			}
 		} else {
 			// Conversations view
@@ -484,30 +1464,65 @@ func (m messagesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "q", "ctrl+c":
 				return m, tea.Quit
 
+			case "s":
+				// Sync with the provider in the background
+				if !m.syncing {
+					m.syncing = true
+					m.status = ""
+					return m, tea.Batch(m.spinner.Tick, syncMessagesCmd(m.mm))
+				}
+
+			case "<", ">":
+				// Move the divider between the panes
+				delta := splitStep
+				if msg.String() == "<" {
+					delta = -splitStep
+				}
+				m.splitRatio, m.status = nudgeSplit(m.cfg, m.splitRatio, delta)
+
+			case "p":
+				// Pin or unpin the selected conversation
+				if m.cursor < len(m.conversations) {
+					id := m.conversations[m.cursor].ID
+					m.setPinned(togglePin(m.pinnedConversationIDs(), id), id)
+				}
+
+			case "J", "K":
+				// Move the selected pinned conversation down/up within the pinned block
+				if m.cursor < len(m.conversations) {
+					delta := 1
+					if msg.String() == "K" {
+						delta = -1
+					}
+					id := m.conversations[m.cursor].ID
+					if pinned, ok := movePin(m.pinnedConversationIDs(), id, delta); ok {
+						m.setPinned(pinned, id)
+					}
+				}
+
 			case "d":
 				if len(m.conversations) > 0 && m.cursor < len(m.conversations) {
-					m.confirmingDelete = true
 					m.deleteConvID = m.conversations[m.cursor].ID
+					if m.deleteConfirm.skip() {
+						m.deletePending()
+						return m, nil
+					}
+					m.confirmingDelete = true
+					if m.deleteConfirm.typed() {
+						m.deleteConfirm.input.Reset()
+						return m, m.deleteConfirm.input.Focus()
+					}
 				}
 
 			case "enter":
-				// View messages for selected conversation
-				if m.cursor < len(m.conversations) {
-					conv := m.conversations[m.cursor]
-					m.viewMode = "messages"
-					m.selectedConvID = conv.ID
-
-					// Load messages for this conversation
-					msgs, err := m.mm.GetMessagesForConversation(conv.ID)
-					if err == nil {
-						m.messages = msgs
-					} else {
-						m.messages = []messages.Message{}
-					}
-					m.messagesCursor = 0
-					m.messagesViewTop = 0
+				if m.pick && m.cursor < len(m.conversations) {
+					m.picked = m.conversations[m.cursor].ID
+					return m, tea.Quit
 				}
 
+				// View messages for selected conversation
+				m.openConversation()
+
 			case "up", "k":
 				if m.cursor > 0 {
 					m.cursor--
@@ -547,23 +1562,25 @@ func (m messagesModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m messagesModel) View() string {
+	if m.showHelp {
+		return renderKeyHelp("Messages keys", messagesKeyHelp, m.width, m.height+3)
+	}
+
 	if m.viewMode == "messages" {
 		return m.renderMessagesView()
 	}
 
 	if len(m.conversations) == 0 {
-		return "No conversations found. Run 'dunbar messages sync' to sync your messages.\n\nPress 'q' to quit."
+		view := "No conversations found. Press 's' to sync your messages.\n\nPress 'q' to quit."
+		if status := syncStatus(m.syncing, m.spinner, m.status); status != "" {
+			view += "\n\n" + status
+		}
+		return view
 	}
 
 	// Show delete confirmation dialog
 	if m.confirmingDelete {
-		var conv messages.Conversation
-		for _, c := range m.conversations {
-			if c.ID == m.deleteConvID {
-				conv = c
-				break
-			}
-		}
+		conv := m.deleteConversation()
 
 		titleStyle := lipgloss.NewStyle().
 			Bold(true).
@@ -605,7 +1622,11 @@ func (m messagesModel) View() string {
 		dialogContent.WriteString("\n\n")
 		dialogContent.WriteString(buttonStyle.Render("This action cannot be undone."))
 		dialogContent.WriteString("\n\n\n")
-		dialogContent.WriteString(yesButtonStyle.Render("Y") + "  " + noButtonStyle.Render("N"))
+		if m.deleteConfirm.typed() {
+			dialogContent.WriteString(m.deleteConfirm.prompt(conv.Title, ""))
+		} else {
+			dialogContent.WriteString(yesButtonStyle.Render("Y") + "  " + noButtonStyle.Render("N"))
+		}
 
 		dialog := boxStyle.Render(dialogContent.String())
 
@@ -617,8 +1638,36 @@ func (m messagesModel) View() string {
 	return m.renderConversationsView()
 }
 
+// deleteConversation returns the conversation the delete confirmation is for
+func (m messagesModel) deleteConversation() messages.Conversation {
+	for _, c := range m.conversations {
+		if c.ID == m.deleteConvID {
+			return c
+		}
+	}
+	return messages.Conversation{}
+}
+
+// deletePending deletes the conversation the delete confirmation is for.
+// For now, we don't actually delete from database, just remove it from the
+// local list.
+func (m *messagesModel) deletePending() {
+	for i, c := range m.conversations {
+		if c.ID == m.deleteConvID {
+			m.conversations = append(m.conversations[:i], m.conversations[i+1:]...)
+			break
+		}
+	}
+	if m.cursor >= len(m.conversations) && len(m.conversations) > 0 {
+		m.cursor = len(m.conversations) - 1
+	}
+	m.confirmingDelete = false
+	m.deleteConvID = ""
+	m.deleteConfirm.input.Blur()
+}
+
 func (m messagesModel) renderConversationsView() string {
-	leftWidth := max(40, m.width*2/5)
+	leftWidth := leftPaneWidth(m.width, m.splitRatio, 40)
 
 	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
 	normalStyle := lipgloss.NewStyle()
@@ -646,8 +1695,11 @@ func (m messagesModel) renderConversationsView() string {
 		if conv.UnreadCount > 0 {
 			label += fmt.Sprintf(" (%d)", conv.UnreadCount)
 		}
+		if conv.PinOrder > 0 {
+			label = "📌 " + label
+		}
 
-		line := fmt.Sprintf(" %s", truncate(label, leftWidth-2))
+		line := fmt.Sprintf(" %s", util.Truncate(label, leftWidth-2))
 		leftPane.WriteString(style.Render(line))
 		leftPane.WriteString("\n")
 	}
@@ -672,8 +1724,17 @@ func (m messagesModel) renderConversationsView() string {
 
 		// Title with platform and time info
 		platformInfo := fmt.Sprintf("[%s]", conv.Platform)
+		count := util.HumanCount(conv.MessageCount) + " messages"
+		if conv.MessageCount == 1 {
+			count = "1 message"
+		}
+		platformInfo += " (" + count
 		if conv.UnreadCount > 0 {
-			platformInfo += fmt.Sprintf(" (%d unread)", conv.UnreadCount)
+			platformInfo += fmt.Sprintf(", %d unread", conv.UnreadCount)
+		}
+		platformInfo += ")"
+		if conv.OriginalTitle != "" {
+			platformInfo += " • originally " + conv.OriginalTitle
 		}
 		rightPane.WriteString(titleStyle.Render(conv.Title))
 		rightPane.WriteString("\n")
@@ -682,30 +1743,34 @@ func (m messagesModel) renderConversationsView() string {
 		rightPane.WriteString(divider)
 		rightPane.WriteString("\n")
 
-		// Load and display conversation messages
-		convMessages, err := m.mm.GetMessagesForConversation(conv.ID)
-		if err != nil || len(convMessages) == 0 {
+		// Display the conversation's messages, loaded when it was selected
+		convMessages := m.previewMessages
+		if m.previewLimit > 0 && len(convMessages) > m.previewLimit {
+			convMessages = convMessages[:m.previewLimit]
+		}
+		if len(convMessages) == 0 {
 			rightPane.WriteString(fieldLabelStyle.Render("No messages found"))
 			rightPane.WriteString("\n")
 		} else {
+			// Truncate long messages first, so the fit below counts the
+			// lines they'll actually take
+			preview := make([]messages.Message, len(convMessages))
+			for i, msg := range convMessages {
+				msg.Text = util.Truncate(msg.Text, m.previewChars)
+				preview[i] = msg
+			}
+
 			// Calculate how many messages actually fit in the preview pane
 			// Account for: title (1) + platform info (1) + divider (1) = 3 lines used
-			rightPaneWidth := m.width - leftWidth - 4
+			rightPaneWidth := m.width - leftWidth - paneSeparatorWidth - 1
 			availableHeight := max(1, m.height-5) // Conservative estimate for preview
-			maxMessages := calculateVisibleMessageCount(convMessages, 0, rightPaneWidth, availableHeight)
-			maxMessages = min(maxMessages, len(convMessages))
+			maxMessages := calculateVisibleMessageCount(preview, 0, rightPaneWidth, availableHeight, m.timeFormat)
+			maxMessages = min(maxMessages, len(preview))
 
 			var prevMsg *messages.Message
 			for i := 0; i < maxMessages; i++ {
-				msg := convMessages[i]
-
-				// Truncate very long messages in preview
-				if len(msg.Text) > 200 {
-					msg.Text = msg.Text[:197] + "..."
-				}
-
-				rightPane.WriteString(formatMessage(msg, rightPaneWidth, prevMsg))
-				prevMsg = &convMessages[i]
+				rightPane.WriteString(formatMessage(preview[i], rightPaneWidth, prevMsg, m.timeFormat, ""))
+				prevMsg = &preview[i]
 			}
 		}
 	}
@@ -735,121 +1800,347 @@ func (m messagesModel) renderConversationsView() string {
 
 	// Footer
 	combined.WriteString("\n")
-	footer := "j/k: down/up • g/G: top/bottom • enter: fullscreen • d: delete • q: quit"
+	footer := "j/k: down/up • g/G: top/bottom • enter: fullscreen • </>: resize • s: sync • p: pin • J/K: move pin • d: delete • ?: help • q: quit"
+	if m.pick {
+		footer = "j/k: down/up • g/G: top/bottom • enter: pick • </>: resize • s: sync • ?: help • q: quit"
+	}
 	combined.WriteString(footerStyle.Render(footer))
+	if status := syncStatus(m.syncing, m.spinner, m.status); status != "" {
+		combined.WriteString(footerStyle.Render("  " + status))
+	}
 
 	return combined.String()
 }
 
 func (m messagesModel) renderMessagesView() string {
 	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+	participantsStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
 	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
 
 	var sb strings.Builder
 
 	// Header
-	var convTitle string
+	conv := m.selectedConversation()
+	sb.WriteString(headerStyle.Render(conv.Title))
+	sb.WriteString("\n")
+	if header := m.participantsHeader(); header != "" {
+		sb.WriteString(participantsStyle.Render(header))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+
+	if len(m.messages) == 0 {
+		sb.WriteString("No messages found\n")
+	} else {
+		// Lay out the viewport once, then render it with selection and
+		// search highlighting
+		items := layoutMessages(insertDateSeparators(m.messages, m.timeFormat), m.messagesViewTop, m.width-4, m.messagesAvailableHeight(), m.timeFormat)
+
+		messageIndex := m.messagesViewTop
+		var prevMsg *messages.Message
+		for _, item := range items {
+			if item.isSeparator() {
+				sb.WriteString(renderDateSeparator(*item.dateSeparator, m.width-4))
+				prevMsg = nil // Reset grouping after date separator
+				continue
+			}
+
+			isSelected := messageIndex == m.messagesCursor
+			sb.WriteString(formatMessage(*item.message, m.width-4, prevMsg, m.timeFormat, m.searchQuery, isSelected))
+			prevMsg = item.message
+			messageIndex++
+		}
+	}
+
+	// Footer
+	sb.WriteString("\n")
+	switch {
+	case m.searching:
+		sb.WriteString(m.searchInput.View())
+	case m.searchQuery != "":
+		position := "no matches"
+		if len(m.searchMatches) > 0 {
+			position = fmt.Sprintf("match %d of %d", m.searchMatch+1, len(m.searchMatches))
+		}
+		sb.WriteString(footerStyle.Render(fmt.Sprintf("/%s: %s • n/N: next/previous • esc: clear search", m.searchQuery, position)))
+	default:
+		footer := "j/k: down/up • g/G: top/bottom • /: search • Y: copy transcript • ?: help • esc/q: back to conversations"
+		if m.status != "" {
+			footer += "  " + m.status
+		}
+		sb.WriteString(footerStyle.Render(footer))
+	}
+
+	return sb.String()
+}
+
+// selectedConversation returns the conversation open in the messages view
+func (m messagesModel) selectedConversation() messages.Conversation {
 	for _, c := range m.conversations {
 		if c.ID == m.selectedConvID {
-			convTitle = c.Title
-			break
+			return c
 		}
 	}
-	sb.WriteString(headerStyle.Render(convTitle))
-	sb.WriteString("\n\n")
+	return messages.Conversation{}
+}
 
-	if len(m.messages) == 0 {
-		sb.WriteString("No messages found\n")
+// participantsHeader lists the other members of an open group conversation,
+// or returns "" for direct messages
+func (m messagesModel) participantsHeader() string {
+	conv := m.selectedConversation()
+	if conv.Type != "group" || len(conv.Participants) == 0 {
+		return ""
+	}
+
+	var names []string
+	for _, p := range conv.Participants {
+		if p.IsSelf {
+			continue
+		}
+		names = append(names, m.senders.Name(p.UID, p.UID))
+	}
+
+	header := "👥 " + strings.Join(names, ", ")
+	if more := conv.ParticipantCount - len(conv.Participants); more > 0 {
+		header += fmt.Sprintf(" (+%d more)", more)
+	}
+	return util.Truncate(header, max(1, m.width-4))
+}
+
+// pinnedConversationIDs returns the IDs of the pinned conversations in pin
+// order, which is how they're sorted at the top of the list
+func (m messagesModel) pinnedConversationIDs() []string {
+	var ids []string
+	for _, c := range m.conversations {
+		if c.PinOrder > 0 {
+			ids = append(ids, c.ID)
+		}
+	}
+	return ids
+}
+
+// setPinned saves the pinned conversations and their order, then re-sorts
+// the list keeping the cursor on the conversation with the given ID
+func (m *messagesModel) setPinned(pinned []string, id string) {
+	if err := m.mm.SetPinnedConversations(pinned); err != nil {
+		m.status = fmt.Sprintf("Pin failed: %v", err)
+		return
+	}
+
+	for i := range m.conversations {
+		m.conversations[i].PinOrder = slices.Index(pinned, m.conversations[i].ID) + 1
+	}
+	sortConversations(m.conversations)
+	m.cursor = slices.IndexFunc(m.conversations, func(c messages.Conversation) bool { return c.ID == id })
+	m.clampViewport()
+
+	if slices.Contains(pinned, id) {
+		m.status = fmt.Sprintf("Pinned %s (J/K to reorder)", m.conversations[m.cursor].Title)
 	} else {
-		// Insert date separators into message list
-		displayItems := insertDateSeparators(m.messages)
+		m.status = fmt.Sprintf("Unpinned %s", m.conversations[m.cursor].Title)
+	}
+}
 
-		// Reserve space for: header (2 lines) + footer (2 lines) = 4 lines
-		availableHeight := m.height - 4
-		linesUsed := 0
+// clampViewport keeps the conversation cursor in range and on screen, e.g.
+// after the terminal shrinks or the list changes
+func (m *messagesModel) clampViewport() {
+	m.cursor = max(0, min(m.cursor, len(m.conversations)-1))
+	m.viewportTop = max(0, min(m.viewportTop, len(m.conversations)-m.height))
+	if m.cursor < m.viewportTop {
+		m.viewportTop = m.cursor
+	} else if m.cursor >= m.viewportTop+m.height {
+		m.viewportTop = m.cursor - m.height + 1
+	}
+}
 
-		// Track message index separately from display item index
-		messageIndex := 0
-		var prevMsg *messages.Message
-		inViewport := false
-
-		// Render display items until we run out of space
-		for i, item := range displayItems {
-			if item.isMessage() {
-				// Check if this message should be rendered
-				if messageIndex < m.messagesViewTop {
-					messageIndex++
-					continue
-				}
+// clampMessagesViewport keeps the message cursor in range and on screen in
+// the single-conversation view, without scrolling past the last message
+func (m *messagesModel) clampMessagesViewport() {
+	m.messagesCursor = max(0, min(m.messagesCursor, len(m.messages)-1))
+	m.messagesViewTop = max(0, min(m.messagesViewTop, min(m.messagesCursor, m.lastMessagesViewTop())))
 
-				// We're now in the viewport
-				inViewport = true
+	availableHeight := m.messagesAvailableHeight()
+	for m.messagesViewTop < m.messagesCursor &&
+		m.messagesCursor >= m.messagesViewTop+calculateVisibleMessageCount(m.messages, m.messagesViewTop, m.width-4, availableHeight, m.timeFormat) {
+		m.messagesViewTop++
+	}
+}
 
-				// Render message
-				isSelected := messageIndex == m.messagesCursor
-				rendered := formatMessage(*item.message, m.width-4, prevMsg, isSelected)
+// lastMessagesViewTop returns the first message shown when the view is
+// scrolled to the end, i.e. the earliest start that still shows the last message
+func (m messagesModel) lastMessagesViewTop() int {
+	availableHeight := m.messagesAvailableHeight()
+	for startIdx := len(m.messages) - 1; startIdx >= 0; startIdx-- {
+		visibleCount := calculateVisibleMessageCount(m.messages, startIdx, m.width-4, availableHeight, m.timeFormat)
+		if startIdx+visibleCount < len(m.messages) {
+			return startIdx + 1
+		}
+	}
+	return 0
+}
 
-				lineCount := strings.Count(rendered, "\n")
-				if linesUsed+lineCount > availableHeight {
-					break
-				}
+// messagesAvailableHeight returns the number of lines available for messages
+// in the single-conversation view, after the header and footer
+func (m messagesModel) messagesAvailableHeight() int {
+	headerLines := 2 // title + blank line
+	if m.participantsHeader() != "" {
+		headerLines++
+	}
+	return max(1, m.height-headerLines-2)
+}
 
-				sb.WriteString(rendered)
-				linesUsed += lineCount
-				prevMsg = item.message
-				messageIndex++
-
-			} else if item.isSeparator() {
-				// Only render date separator if we're in viewport OR if the next message will be in viewport
-				// Look ahead to see if any messages from this date will be visible
-				nextMessageInViewport := false
-				tempMessageIndex := messageIndex
-
-				for j := i + 1; j < len(displayItems); j++ {
-					if displayItems[j].isMessage() {
-						if tempMessageIndex >= m.messagesViewTop {
-							nextMessageInViewport = true
-							break
-						}
-						tempMessageIndex++
-						// Stop looking ahead after checking a few messages
-						if tempMessageIndex > m.messagesViewTop+2 {
-							break
-						}
-					} else {
-						// Hit another separator, stop looking
-						break
-					}
-				}
+// senderResolver maps sender UIDs in a conversation to matching local
+// contacts so messages show friendly names instead of raw handles
+type senderResolver struct {
+	contacts map[string]*contacts.Contact // Sender UID -> matching contact
+	names    map[string]string            // Participant UID -> platform display name
+}
 
-				// Only render separator if we're already in viewport or next message will be
-				if inViewport || nextMessageInViewport {
-					rendered := renderDateSeparator(*item.dateSeparator, m.width-4)
-					lineCount := strings.Count(rendered, "\n") + 1
+// newSenderResolver matches the conversation's participants, and any other
+// senders whose name is a phone number or email, against local contacts
+func newSenderResolver(conv messages.Conversation, msgs []messages.Message, contactsList []contacts.Contact) senderResolver {
+	r := senderResolver{
+		contacts: make(map[string]*contacts.Contact),
+		names:    make(map[string]string, len(conv.Participants)),
+	}
 
-					if linesUsed+lineCount > availableHeight {
-						break
-					}
+	for _, p := range conv.Participants {
+		r.names[p.UID] = p.Name
+		if contact := contacts.FindByPhoneOrEmail(contactsList, p.PhoneNumber, p.Email); contact != nil {
+			r.contacts[p.UID] = contact
+		}
+	}
 
-					sb.WriteString(rendered)
-					linesUsed += lineCount
-					prevMsg = nil // Reset grouping after date separator
-				}
-			}
+	// Senders missing from the participant list (e.g. in large groups) may
+	// only be known by a phone number or email handle
+	for _, msg := range msgs {
+		if _, ok := r.contacts[msg.SenderUID]; ok {
+			continue
+		}
+		phone, email := handleToPhoneOrEmail(msg.SenderName)
+		if phone == "" && email == "" {
+			continue
+		}
+		if contact := contacts.FindByPhoneOrEmail(contactsList, phone, email); contact != nil {
+			r.contacts[msg.SenderUID] = contact
 		}
 	}
 
-	// Footer
-	sb.WriteString("\n")
-	footer := "j/k: down/up • g/G: top/bottom • esc/q: back to conversations"
-	sb.WriteString(footerStyle.Render(footer))
+	return r
+}
 
-	return sb.String()
+// handleToPhoneOrEmail interprets a raw sender handle as a phone number or email
+func handleToPhoneOrEmail(handle string) (phone, email string) {
+	handle = strings.TrimSpace(handle)
+	if strings.Contains(handle, "@") {
+		return "", handle
+	}
+	// Only treat handles made of phone characters as numbers
+	if strings.Trim(handle, "+0123456789 -().") == "" && len(contacts.NormalizePhone(handle)) >= 7 {
+		return handle, ""
+	}
+	return "", ""
+}
+
+// Name returns the display name for a sender UID: the matching contact's
+// FullName, else the platform display name, else fallback
+func (r senderResolver) Name(uid, fallback string) string {
+	if contact, ok := r.contacts[uid]; ok && contact.FullName != "" {
+		return contact.FullName
+	}
+	if name := r.names[uid]; name != "" {
+		return name
+	}
+	return fallback
+}
+
+// apply replaces each message's sender name with its resolved display name
+func (r senderResolver) apply(msgs []messages.Message) {
+	for i := range msgs {
+		msgs[i].SenderName = r.Name(msgs[i].SenderUID, msgs[i].SenderName)
+	}
+}
+
+// senderPalette holds the colors assigned to senders in group conversations
+var senderPalette = []lipgloss.Color{"117", "114", "215", "211", "177", "80", "221", "147"}
+
+// senderColor returns a stable color for a sender by hashing their UID
+func senderColor(uid string) lipgloss.Color {
+	h := fnv.New32a()
+	h.Write([]byte(uid))
+	return senderPalette[h.Sum32()%uint32(len(senderPalette))]
+}
+
+// deliveryMark returns the indicator shown after the time of a sent message
+// for its delivery status, or "" if the status is unknown
+func deliveryMark(status string) string {
+	switch status {
+	case messages.StatusSent:
+		return "✓"
+	case messages.StatusDelivered, messages.StatusRead:
+		return "✓✓"
+	case messages.StatusFailed:
+		return "! not sent"
+	}
+	return ""
+}
+
+// attachmentIndicators describes a message's attachments for display, e.g.
+// ["📷 2 Images", "🎤 Voice (0:12)"]. Stickers, GIFs, and voice notes get
+// their own indicators rather than the image, video, or audio they're sent as.
+func attachmentIndicators(attachments []messages.Attachment) []string {
+	var voiceNotes []string
+	counts := make(map[string]int)
+	for _, att := range attachments {
+		switch {
+		case att.IsVoiceNote:
+			voice := "🎤 Voice"
+			if att.Duration > 0 {
+				voice += " (" + util.HumanDuration(time.Duration(att.Duration*float64(time.Second))) + ")"
+			}
+			voiceNotes = append(voiceNotes, voice)
+		case att.IsSticker:
+			counts["sticker"]++
+		case att.IsGif:
+			counts["gif"]++
+		case att.Type == "img", att.Type == "video", att.Type == "audio":
+			counts[att.Type]++
+		default:
+			counts["file"]++
+		}
+	}
+
+	// Walk the kinds in a fixed order so the indicators don't change between renders
+	kinds := []struct {
+		key, one, many string
+	}{
+		{"img", "📷 Image", "📷 %d Images"},
+		{"video", "🎥 Video", "🎥 %d Videos"},
+		{"gif", "GIF", "%d GIFs"},
+		{"sticker", "🎨 Sticker", "🎨 %d Stickers"},
+		{"audio", "🎵 Audio", "🎵 %d Audio"},
+		{"file", "📎 File", "📎 %d Files"},
+	}
+	var indicators []string
+	for _, kind := range kinds {
+		if kind.key == "file" {
+			// Each voice note is listed with its length, before any files
+			indicators = append(indicators, voiceNotes...)
+		}
+		switch count := counts[kind.key]; count {
+		case 0:
+		case 1:
+			indicators = append(indicators, kind.one)
+		default:
+			indicators = append(indicators, fmt.Sprintf(kind.many, count))
+		}
+	}
+	return indicators
 }
 
 // formatMessage formats a single message with consistent styling
 // Now supports message grouping and right-alignment for sent messages
-func formatMessage(msg messages.Message, width int, prevMsg *messages.Message, isSelected ...bool) string {
+func formatMessage(msg messages.Message, width int, prevMsg *messages.Message, tf util.TimeFormat, highlight string, isSelected ...bool) string {
 	var sb strings.Builder
 
 	selected := false
@@ -860,10 +2151,13 @@ func formatMessage(msg messages.Message, width int, prevMsg *messages.Message, i
 	// Updated color scheme for better readability
 	receivedTextStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("255"))
 	sentTextStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("252")) // Slightly dimmer white
-	senderStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("117")).Bold(true) // Light blue
+	senderStyle := lipgloss.NewStyle().Foreground(senderColor(msg.SenderUID)).Bold(true) // Stable per-sender color
 	myMessageSenderStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("141")).Bold(true) // Light purple
 	timeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("243")) // Medium gray (improved from 237)
 	separatorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240")) // Subtle gray for middot
+	quoteStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("243")).Italic(true) // Dim gray for reply snippets
+	reactionStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("245")) // Dim gray for reactions
+	highlightStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("16")).Background(lipgloss.Color("220")) // Search matches
 
 	// Apply selection background
 	selectionBg := lipgloss.Color("235") // Subtle dark gray
@@ -874,6 +2168,8 @@ func formatMessage(msg messages.Message, width int, prevMsg *messages.Message, i
 		myMessageSenderStyle = myMessageSenderStyle.Background(selectionBg)
 		timeStyle = timeStyle.Background(selectionBg)
 		separatorStyle = separatorStyle.Background(selectionBg)
+		quoteStyle = quoteStyle.Background(selectionBg)
+		reactionStyle = reactionStyle.Background(selectionBg)
 	}
 
 	// Determine if message should group with previous
@@ -886,7 +2182,7 @@ func formatMessage(msg messages.Message, width int, prevMsg *messages.Message, i
 
 	// Format sender/timestamp line (skip if grouping with previous message)
 	if !shouldGroup {
-		timeStr := formatTime(msg.Timestamp)
+		timeStr := formatTime(msg.Timestamp, tf)
 
 		if msg.IsSent {
 			// Right-aligned: "You · 3:04 PM"
@@ -894,6 +2190,20 @@ func formatMessage(msg messages.Message, width int, prevMsg *messages.Message, i
 			sepPart := separatorStyle.Render(" · ")
 			timePart := timeStyle.Render(timeStr)
 
+			// Delivery status follows the time, e.g. "You · 3:04 PM ✓✓"
+			mark := deliveryMark(msg.Status)
+			if mark != "" {
+				markStyle := timeStyle
+				switch msg.Status {
+				case messages.StatusRead:
+					markStyle = markStyle.Foreground(lipgloss.Color("39")) // Blue, as in most messaging apps
+				case messages.StatusFailed:
+					markStyle = markStyle.Foreground(lipgloss.Color("196")) // Red
+				}
+				timePart += timeStyle.Render(" ") + markStyle.Render(mark)
+				timeStr += " " + mark
+			}
+
 			// Calculate combined width for alignment
 			combinedText := "You · " + timeStr
 			combinedWidth := calculateDisplayWidth(combinedText)
@@ -919,54 +2229,34 @@ func formatMessage(msg messages.Message, width int, prevMsg *messages.Message, i
 	}
 
 	// Prepare message text with attachments
-	msgText := msg.Text
-
-	// Add attachment indicators
-	if len(msg.Attachments) > 0 {
-		var attachmentIndicators []string
-		attachmentCounts := make(map[string]int)
-
-		for _, att := range msg.Attachments {
-			attachmentCounts[att.Type]++
-		}
+	msgText := cleanMessageText(msg.Text)
 
-		for attType, count := range attachmentCounts {
-			var indicator string
-			switch attType {
-			case "img":
-				if count == 1 {
-					indicator = "📷 Image"
-				} else {
-					indicator = fmt.Sprintf("📷 %d Images", count)
-				}
-			case "video":
-				if count == 1 {
-					indicator = "🎥 Video"
-				} else {
-					indicator = fmt.Sprintf("🎥 %d Videos", count)
-				}
-			case "audio":
-				if count == 1 {
-					indicator = "🎵 Audio"
-				} else {
-					indicator = fmt.Sprintf("🎵 %d Audio", count)
-				}
-			default:
-				if count == 1 {
-					indicator = "📎 File"
-				} else {
-					indicator = fmt.Sprintf("📎 %d Files", count)
-				}
-			}
-			attachmentIndicators = append(attachmentIndicators, indicator)
-		}
+	// Tombstone deleted messages; add attachment indicators otherwise
+	if msg.IsDeleted {
+		msgText = "(message deleted)"
+	} else if len(msg.Attachments) > 0 {
+		indicators := strings.Join(attachmentIndicators(msg.Attachments), ", ")
 
 		// Add to message text
 		if msgText != "" {
-			msgText = fmt.Sprintf("[%s] %s", strings.Join(attachmentIndicators, ", "), msgText)
+			msgText = fmt.Sprintf("[%s] %s", indicators, msgText)
 		} else {
-			msgText = fmt.Sprintf("[%s]", strings.Join(attachmentIndicators, ", "))
+			msgText = fmt.Sprintf("[%s]", indicators)
+		}
+	}
+	if msgText == "" {
+		msgText = "(no text)"
+	}
+
+	// Quoted snippet of the message being replied to
+	if msg.ReplyToID != "" {
+		snippet := msg.ReplyToText
+		if snippet == "" {
+			snippet = "original message"
 		}
+		quote := "┃ " + util.Truncate(strings.Join(strings.Fields(snippet), " "), max(1, width-8))
+		sb.WriteString(alignMessageLine(quote, width, msg.IsSent, quoteStyle))
+		sb.WriteString("\n")
 	}
 
 	// Wrap and render message text with proper alignment
@@ -974,7 +2264,9 @@ func formatMessage(msg messages.Message, width int, prevMsg *messages.Message, i
 
 	for _, line := range wrappedLines {
 		var textStyle lipgloss.Style
-		if msg.IsSent {
+		if msg.IsDeleted {
+			textStyle = quoteStyle
+		} else if msg.IsSent {
 			textStyle = sentTextStyle
 		} else {
 			textStyle = receivedTextStyle
@@ -989,19 +2281,109 @@ func formatMessage(msg messages.Message, width int, prevMsg *messages.Message, i
 				padding = 0
 			}
 
-			paddedLine := strings.Repeat(" ", padding) + strings.Repeat(" ", indent) + line
-			sb.WriteString(textStyle.Render(paddedLine))
+			sb.WriteString(textStyle.Render(strings.Repeat(" ", padding+indent)))
+			sb.WriteString(renderHighlighted(line, highlight, textStyle, highlightStyle))
 		} else {
 			// Left-align received messages
 			indent := 2 // Default indent
-			sb.WriteString(textStyle.Render(strings.Repeat(" ", indent) + line))
+			sb.WriteString(textStyle.Render(strings.Repeat(" ", indent)))
+			sb.WriteString(renderHighlighted(line, highlight, textStyle, highlightStyle))
 		}
 		sb.WriteString("\n")
 	}
 
+	// Reactions as a dim suffix line
+	if len(msg.Reactions) > 0 {
+		sb.WriteString(alignMessageLine(formatReactions(msg.Reactions), width, msg.IsSent, reactionStyle))
+		sb.WriteString("\n")
+	}
+
 	return sb.String()
 }
 
+// renderHighlighted renders a line in style, with case-insensitive matches of
+// term in highlightStyle
+func renderHighlighted(line, term string, style, highlightStyle lipgloss.Style) string {
+	lower := strings.ToLower(line)
+	needle := strings.ToLower(term)
+	// Lowercasing can change byte lengths for some scripts; skip highlighting then
+	if needle == "" || len(lower) != len(line) {
+		return style.Render(line)
+	}
+
+	var sb strings.Builder
+	for {
+		i := strings.Index(lower, needle)
+		if i < 0 {
+			break
+		}
+		if i > 0 {
+			sb.WriteString(style.Render(line[:i]))
+		}
+		sb.WriteString(highlightStyle.Render(line[i : i+len(needle)]))
+		line, lower = line[i+len(needle):], lower[i+len(needle):]
+	}
+	if line != "" {
+		sb.WriteString(style.Render(line))
+	}
+	return sb.String()
+}
+
+// alignMessageLine indents a line of a message body, right-aligning it for sent messages
+func alignMessageLine(line string, width int, isSent bool, style lipgloss.Style) string {
+	indent := 2
+	if !isSent {
+		return style.Render(strings.Repeat(" ", indent) + line)
+	}
+
+	padding := width - calculateDisplayWidth(line) - indent - 2
+	if padding < 0 {
+		padding = 0
+	}
+	return style.Render(strings.Repeat(" ", padding+indent) + line)
+}
+
+// formatReactions summarizes reactions as "👍 2  ❤️ 1", in order of first appearance
+func formatReactions(reactions []messages.Reaction) string {
+	var keys []string
+	counts := make(map[string]int)
+	for _, r := range reactions {
+		if counts[r.Key] == 0 {
+			keys = append(keys, r.Key)
+		}
+		counts[r.Key]++
+	}
+
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		if counts[key] == 1 {
+			parts[i] = key
+		} else {
+			parts[i] = fmt.Sprintf("%s %d", key, counts[key])
+		}
+	}
+	return strings.Join(parts, "  ")
+}
+
+// cleanMessageText strips control characters from message text and returns
+// "" if nothing visible is left, e.g. for whitespace or zero-width only text
+func cleanMessageText(text string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && !unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, text)
+
+	visible := strings.ContainsFunc(cleaned, func(r rune) bool {
+		return !unicode.IsSpace(r) && !unicode.Is(unicode.Cf, r)
+	})
+	if !visible {
+		return ""
+	}
+	return strings.TrimSpace(cleaned)
+}
+
 // wrapText wraps text to fit within a specified width
 func wrapText(text string, width int) []string {
 	if width <= 0 {
@@ -1033,87 +2415,76 @@ func wrapText(text string, width int) []string {
 
 // calculateVisibleMessageCount calculates how many messages can fit in the viewport
 // starting from startIndex, accounting for actual message heights
-func calculateVisibleMessageCount(msgs []messages.Message, startIndex int, width int, availableHeight int) int {
+func calculateVisibleMessageCount(msgs []messages.Message, startIndex int, width int, availableHeight int, tf util.TimeFormat) int {
 	if len(msgs) == 0 || startIndex >= len(msgs) {
 		return 0
 	}
 
-	displayItems := insertDateSeparators(msgs)
-	linesUsed := 0
 	messageCount := 0
+	for _, item := range layoutMessages(insertDateSeparators(msgs, tf), startIndex, width, availableHeight, tf) {
+		if item.isMessage() {
+			messageCount++
+		}
+	}
+
+	return max(1, messageCount)
+}
+
+// layoutMessages returns the display items that fit in availableHeight lines
+// when the view starts at message startIndex. A date separator is shown only
+// with the message it introduces, so the first message of a day at the top
+// keeps its separator and the view never ends on a dangling one.
+func layoutMessages(items []displayItem, startIndex int, width int, availableHeight int, tf util.TimeFormat) []displayItem {
+	var visible []displayItem
+	linesUsed := 0
 	messageIndex := 0
 	var prevMsg *messages.Message
 
-	for _, item := range displayItems {
-		if item.isMessage() {
-			// Skip messages before startIndex
-			if messageIndex < startIndex {
-				messageIndex++
+	for i := 0; i < len(items); i++ {
+		item := items[i]
+
+		if item.isSeparator() {
+			// A separator precedes message messageIndex
+			if messageIndex < startIndex || i+1 >= len(items) || !items[i+1].isMessage() {
 				continue
 			}
-
-			// Calculate how many lines this message will take
-			rendered := formatMessage(*item.message, width, prevMsg, false)
-			lineCount := strings.Count(rendered, "\n")
-
-			// Check if adding this message would exceed available height
+			next := items[i+1].message
+			lineCount := strings.Count(renderDateSeparator(*item.dateSeparator, width), "\n") +
+				strings.Count(formatMessage(*next, width, nil, tf, ""), "\n")
 			if linesUsed+lineCount > availableHeight {
 				break
 			}
 
+			visible = append(visible, item, items[i+1])
 			linesUsed += lineCount
-			messageCount++
-			prevMsg = item.message
+			prevMsg = next
 			messageIndex++
+			i++ // The message was laid out with its separator
+			continue
+		}
 
-		} else if item.isSeparator() && messageIndex >= startIndex {
-			// Account for date separator lines too
-			rendered := renderDateSeparator(*item.dateSeparator, width)
-			lineCount := strings.Count(rendered, "\n") + 1
-
-			if linesUsed+lineCount > availableHeight {
-				break
-			}
+		// Skip messages before startIndex
+		if messageIndex < startIndex {
+			messageIndex++
+			continue
+		}
 
-			linesUsed += lineCount
-			prevMsg = nil
+		lineCount := strings.Count(formatMessage(*item.message, width, prevMsg, tf, ""), "\n")
+		if linesUsed+lineCount > availableHeight {
+			break
 		}
+
+		visible = append(visible, item)
+		linesUsed += lineCount
+		prevMsg = item.message
+		messageIndex++
 	}
 
-	return max(1, messageCount)
+	return visible
 }
 
 // Helper functions for conversation list
 
-// formatTimeAgo formats a time as a relative string (e.g., "2m ago", "3h ago", "yesterday")
-func formatTimeAgo(t time.Time) string {
-	now := time.Now()
-	diff := now.Sub(t)
-
-	if diff < time.Minute {
-		return "now"
-	} else if diff < time.Hour {
-		mins := int(diff.Minutes())
-		return fmt.Sprintf("%dm ago", mins)
-	} else if diff < 24*time.Hour {
-		hours := int(diff.Hours())
-		return fmt.Sprintf("%dh ago", hours)
-	} else if diff < 48*time.Hour {
-		return "yesterday"
-	} else if diff < 7*24*time.Hour {
-		days := int(diff.Hours() / 24)
-		return fmt.Sprintf("%dd ago", days)
-	} else if diff < 30*24*time.Hour {
-		weeks := int(diff.Hours() / 24 / 7)
-		if weeks == 1 {
-			return "1w ago"
-		}
-		return fmt.Sprintf("%dw ago", weeks)
-	} else {
-		return t.Format("Jan 2")
-	}
-}
-
 // getPlatformIcon returns a text prefix for the given platform
 func getPlatformIcon(platform string) string {
 	platform = strings.ToLower(platform)
@@ -1153,31 +2524,33 @@ func sameDay(t1, t2 time.Time) bool {
 }
 
 // formatTime formats a timestamp based on recency
-func formatTime(t time.Time) string {
-	now := time.Now()
+func formatTime(t time.Time, tf util.TimeFormat) string {
+	t = tf.In(t)
+	now := tf.Now()
 
 	// Today: show time only
 	if sameDay(t, now) {
-		return t.Format("3:04 PM")
+		return t.Format(tf.Clock())
 	}
 
 	// This week: show day + time
 	if now.Sub(t) < 7*24*time.Hour && now.Sub(t) >= 0 {
-		return t.Format("Mon 3:04 PM")
+		return t.Format("Mon " + tf.Clock())
 	}
 
 	// This year: show date without year
 	if t.Year() == now.Year() {
-		return t.Format("Jan 2")
+		return t.Format(tf.MonthDay())
 	}
 
 	// Older: show full date
-	return t.Format("Jan 2, 2006")
+	return t.Format(tf.MonthDayYear())
 }
 
 // formatDateSeparator formats a date for use in separator
-func formatDateSeparator(t time.Time) string {
-	now := time.Now()
+func formatDateSeparator(t time.Time, tf util.TimeFormat) string {
+	t = tf.In(t)
+	now := tf.Now()
 
 	// Today
 	if sameDay(t, now) {
@@ -1201,11 +2574,11 @@ func formatDateSeparator(t time.Time) string {
 
 	// This year (not this week) - include day of week
 	if t.Year() == now.Year() {
-		return t.Format("Mon, Jan 2")
+		return t.Format("Mon, " + tf.MonthDay())
 	}
 
 	// Older years - include day of week and year
-	return t.Format("Mon, Jan 2, 2006")
+	return t.Format("Mon, " + tf.MonthDayYear())
 }
 
 // shouldGroupWithPrevious determines if a message should group with the previous one
@@ -1219,6 +2592,11 @@ func shouldGroupWithPrevious(msg messages.Message, prevMsg *messages.Message) bo
 		return false
 	}
 
+	// Different conversation (in a unified timeline) = don't group
+	if msg.ConversationUID != prevMsg.ConversationUID {
+		return false
+	}
+
 	// Different day = don't group (date separator will appear)
 	if !sameDay(msg.Timestamp, prevMsg.Timestamp) {
 		return false
@@ -1261,7 +2639,7 @@ func isEmoji(r rune) bool {
 }
 
 // insertDateSeparators inserts date separators between messages from different days
-func insertDateSeparators(msgs []messages.Message) []displayItem {
+func insertDateSeparators(msgs []messages.Message, tf util.TimeFormat) []displayItem {
 	if len(msgs) == 0 {
 		return []displayItem{}
 	}
@@ -1270,14 +2648,14 @@ func insertDateSeparators(msgs []messages.Message) []displayItem {
 	var lastDate time.Time
 
 	for i := range msgs {
-		msgDate := msgs[i].Timestamp
+		msgDate := tf.In(msgs[i].Timestamp)
 
 		// Check if we need a date separator
 		if i == 0 || !sameDay(msgDate, lastDate) {
 			// Add date separator
 			items = append(items, displayItem{
 				dateSeparator: &DateSeparator{
-					Text: formatDateSeparator(msgDate),
+					Text: formatDateSeparator(msgDate, tf),
 					Date: msgDate,
 				},
 			})
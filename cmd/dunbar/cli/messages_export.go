@@ -0,0 +1,460 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/dunbar/pkg/messages"
+	"github.com/arjungandhi/dunbar/pkg/util"
+	Z "github.com/rwxrob/bonzai/z"
+)
+
+// exportPageSize is how many messages export-all reads from the database at a time
+const exportPageSize = 1000
+
+// Transcript formats written by export and export-all
+const (
+	exportFormatMarkdown = "md"
+	exportFormatText     = "txt"
+	exportFormatJSON     = "json"
+	exportFormatJSONL    = "jsonl" // One Message object per line, streamed from the database
+)
+
+var MessagesExport = &Z.Cmd{
+	Name:    "export",
+	Summary: "Write one conversation as a transcript or JSON Lines",
+	Usage:   "<conversation-id> [--format md|txt|json|jsonl] [--since YYYY-MM-DD] [--output FILE]",
+	MinArgs: 1,
+	Description: `
+Write a conversation to standard output, or to FILE with --output, oldest
+message first. The format is Markdown by default; txt is plain text and
+json one object holding the conversation and its messages.
+
+jsonl writes one message per line as a JSON object, for jq, pandas, and
+other data tools. Its fields are those of the Message type in
+pkg/messages, e.g.
+
+  {"id":"...","timestamp":"2024-05-01T09:30:00Z","sender_name":"Ada",
+   "text":"hi","is_sent":false,"attachments":null,...}
+
+Sender names are resolved from your contacts as in the TUI. Messages are
+streamed from the database, so even very long conversations export in
+constant memory. --since only exports messages from that date on.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		convID := args[0]
+		format, err := exportFormat(args)
+		if err != nil {
+			return err
+		}
+		since, err := exportSince(args)
+		if err != nil {
+			return err
+		}
+
+		cfg := loadConfig()
+		mm, err := getMessageManager(cfg)
+		if err != nil {
+			return err
+		}
+		defer mm.Close()
+
+		conv, err := mm.GetConversation(convID)
+		if err != nil {
+			return fmt.Errorf("failed to load conversation: %w", err)
+		}
+		if conv == nil {
+			return fmt.Errorf("conversation not found: %s", convID)
+		}
+
+		out := io.Writer(os.Stdout)
+		path := flagValue(args, "--output")
+		if path != "" {
+			file, err := os.Create(path)
+			if err != nil {
+				return fmt.Errorf("failed to create %s: %w", path, err)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		var count int
+		localContacts := loadLocalContacts(cfg)
+		if format == exportFormatJSONL {
+			entry, err := writeMessagesJSONL(out, mm, *conv, since, newSenderResolver(*conv, nil, localContacts))
+			if err != nil {
+				return err
+			}
+			count = entry.Messages
+		} else {
+			msgs, err := loadMessagesSince(mm, conv.ID, since)
+			if err != nil {
+				return fmt.Errorf("failed to load messages: %w", err)
+			}
+			newSenderResolver(*conv, msgs, localContacts).apply(msgs)
+			data, err := renderExport(*conv, msgs, format, cfg.DisplayTimeFormat())
+			if err != nil {
+				return err
+			}
+			if _, err := out.Write(data); err != nil {
+				return fmt.Errorf("failed to write export: %w", err)
+			}
+			count = len(msgs)
+		}
+
+		if path != "" {
+			fmt.Fprintf(os.Stderr, "Exported %s to %s\n", plural(count, "message"), path)
+		}
+		return nil
+	},
+}
+
+// exportFormat reads --format, defaulting to Markdown
+func exportFormat(args []string) (string, error) {
+	format := flagValue(args, "--format")
+	if format == "" {
+		format = exportFormatMarkdown
+	}
+	switch format {
+	case exportFormatMarkdown, exportFormatText, exportFormatJSON, exportFormatJSONL:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown format %q (expected md, txt, json, or jsonl)", format)
+	}
+}
+
+// exportSince reads --since as a local date; zero if it isn't given
+func exportSince(args []string) (time.Time, error) {
+	value := flagValue(args, "--since")
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since date %q (expected YYYY-MM-DD)", value)
+	}
+	return t, nil
+}
+
+// renderExport renders messages, oldest first, in one of the buffered
+// formats: md, txt, or json
+func renderExport(conv messages.Conversation, msgs []messages.Message, format string, tf util.TimeFormat) ([]byte, error) {
+	switch format {
+	case exportFormatJSON:
+		data, err := json.MarshalIndent(struct {
+			Conversation messages.Conversation `json:"conversation"`
+			Messages     []messages.Message    `json:"messages"`
+		}{conv, msgs}, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s: %w", conv.Title, err)
+		}
+		return append(data, '\n'), nil
+	case exportFormatText:
+		return []byte(renderPlainTranscript(conv, msgs, tf)), nil
+	default:
+		return []byte(renderMarkdownTranscript(conv, msgs, tf)), nil
+	}
+}
+
+// writeMessagesJSONL streams a conversation's messages from since onwards
+// to w as JSON Lines, oldest first, and describes what was written
+func writeMessagesJSONL(w io.Writer, mm *messages.MessageManager, conv messages.Conversation, since time.Time, senders senderResolver) (exportManifestEntry, error) {
+	entry := exportManifestEntry{ID: conv.ID, Title: conv.Title, Platform: conv.Platform}
+	buf := bufio.NewWriter(w)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+
+	err := mm.EachMessage(conv.ID, since, func(msg messages.Message) error {
+		msg.SenderName = senders.Name(msg.SenderUID, msg.SenderName)
+		if err := enc.Encode(msg); err != nil {
+			return fmt.Errorf("failed to write message %s: %w", msg.ID, err)
+		}
+		if entry.Messages == 0 {
+			entry.First = msg.Timestamp
+		}
+		entry.Last = msg.Timestamp
+		entry.Messages++
+		return nil
+	})
+	if err != nil {
+		return entry, err
+	}
+	if err := buf.Flush(); err != nil {
+		return entry, fmt.Errorf("failed to write export: %w", err)
+	}
+	return entry, nil
+}
+
+var MessagesExportAll = &Z.Cmd{
+	Name:    "export-all",
+	Summary: "Write every conversation to its own transcript file",
+	Usage:   "<dir> [--format md|txt|json|jsonl] [--since YYYY-MM-DD]",
+	MinArgs: 1,
+	Description: `
+Archive every conversation into <dir>, one transcript file per
+conversation (Markdown by default), plus a manifest.json index listing each
+conversation's file and message count. Files are named after the
+conversation title and ID, so conversations with the same title don't
+collide. The formats are those of 'dunbar messages export'; jsonl files
+are streamed straight from the database.
+
+--since only exports messages from that date on; conversations with no
+messages since then are skipped. Conversations are read and written one at
+a time, so large archives don't have to fit in memory.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		dir := args[0]
+		format, err := exportFormat(args)
+		if err != nil {
+			return err
+		}
+		since, err := exportSince(args)
+		if err != nil {
+			return err
+		}
+
+		cfg := loadConfig()
+		mm, err := getMessageManager(cfg)
+		if err != nil {
+			return err
+		}
+		defer mm.Close()
+
+		conversations, err := mm.ListAllConversations(messages.ConversationFilter{})
+		if err != nil {
+			return fmt.Errorf("failed to list conversations: %w", err)
+		}
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+
+		localContacts := loadLocalContacts(cfg)
+		tf := cfg.DisplayTimeFormat()
+		manifest := []exportManifestEntry{}
+
+		for _, conv := range conversations {
+			name := exportFileName(conv) + "." + format
+			if format == exportFormatJSONL {
+				entry, err := exportJSONLFile(filepath.Join(dir, name), mm, conv, since, newSenderResolver(conv, nil, localContacts))
+				if err != nil {
+					return err
+				}
+				if entry.Messages > 0 {
+					entry.File = name
+					manifest = append(manifest, entry)
+				}
+				continue
+			}
+
+			msgs, err := loadMessagesSince(mm, conv.ID, since)
+			if err != nil {
+				return fmt.Errorf("failed to load messages for %s: %w", conv.Title, err)
+			}
+			if len(msgs) == 0 {
+				continue
+			}
+			newSenderResolver(conv, msgs, localContacts).apply(msgs)
+
+			data, err := renderExport(conv, msgs, format, tf)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", name, err)
+			}
+
+			manifest = append(manifest, exportManifestEntry{
+				ID:       conv.ID,
+				Title:    conv.Title,
+				Platform: conv.Platform,
+				File:     name,
+				Messages: len(msgs),
+				First:    msgs[0].Timestamp,
+				Last:     msgs[len(msgs)-1].Timestamp,
+			})
+		}
+
+		data, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal manifest: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "manifest.json"), append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write manifest: %w", err)
+		}
+
+		fmt.Printf("Exported %d conversations to %s\n", len(manifest), dir)
+		return nil
+	},
+}
+
+// exportJSONLFile streams a conversation to a JSON Lines file at path,
+// removing the file again if there were no messages to export
+func exportJSONLFile(path string, mm *messages.MessageManager, conv messages.Conversation, since time.Time, senders senderResolver) (exportManifestEntry, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return exportManifestEntry{}, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	entry, err := writeMessagesJSONL(file, mm, conv, since, senders)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write %s: %w", path, closeErr)
+	}
+	if err != nil {
+		return entry, fmt.Errorf("failed to export %s: %w", conv.Title, err)
+	}
+	if entry.Messages == 0 {
+		os.Remove(path)
+	}
+	return entry, nil
+}
+
+// exportManifestEntry describes one exported conversation in manifest.json
+type exportManifestEntry struct {
+	ID       string    `json:"id"`
+	Title    string    `json:"title"`
+	Platform string    `json:"platform"`
+	File     string    `json:"file"`     // Transcript file name within the export directory
+	Messages int       `json:"messages"` // Number of messages exported
+	First    time.Time `json:"first"`    // Timestamp of the oldest exported message
+	Last     time.Time `json:"last"`     // Timestamp of the newest exported message
+}
+
+// loadMessagesSince reads a conversation's messages from since onwards (all
+// of them if since is zero), oldest first. Pages are read directly so
+// exported conversations aren't kept in the message cache.
+func loadMessagesSince(mm *messages.MessageManager, convID string, since time.Time) ([]messages.Message, error) {
+	var msgs []messages.Message
+	before := ""
+	for {
+		page, err := mm.GetMessagesForConversationPage(convID, before, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range page {
+			if msg.Timestamp.Before(since) {
+				slices.Reverse(msgs)
+				return msgs, nil
+			}
+			msgs = append(msgs, msg)
+		}
+		if len(page) < exportPageSize {
+			break
+		}
+		before = page[len(page)-1].ID
+	}
+	slices.Reverse(msgs)
+	return msgs, nil
+}
+
+// exportFileName builds a file name from a conversation's title and ID,
+// without characters that are awkward in file names
+func exportFileName(conv messages.Conversation) string {
+	clean := func(s string, maxLen int) string {
+		var sb strings.Builder
+		for _, r := range strings.ToLower(s) {
+			switch {
+			case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+				sb.WriteRune(r)
+			case r == '-', r == '_', r == ' ', r == '.':
+				sb.WriteRune('-')
+			}
+		}
+		name := strings.Trim(sb.String(), "-")
+		for strings.Contains(name, "--") {
+			name = strings.ReplaceAll(name, "--", "-")
+		}
+		if len(name) > maxLen {
+			name = strings.TrimRight(name[:maxLen], "-")
+		}
+		return name
+	}
+
+	title := clean(conv.Title, 60)
+	if title == "" {
+		title = "conversation"
+	}
+	return title + "-" + clean(conv.ID, 40)
+}
+
+// exportMessageText flattens a message to plain text for a transcript
+func exportMessageText(msg messages.Message) string {
+	if msg.IsDeleted {
+		return "(message deleted)"
+	}
+
+	text := cleanMessageText(msg.Text)
+	var names []string
+	for _, att := range msg.Attachments {
+		name := att.FileName
+		if name == "" {
+			name = att.Type
+		}
+		names = append(names, name)
+	}
+	if len(names) > 0 {
+		attachments := "[attachments: " + strings.Join(names, ", ") + "]"
+		if text == "" {
+			return attachments
+		}
+		return attachments + " " + text
+	}
+	if text == "" {
+		return "(no text)"
+	}
+	return text
+}
+
+// exportSender names a message's sender in a transcript
+func exportSender(msg messages.Message) string {
+	if msg.IsSent {
+		return "You"
+	}
+	if msg.SenderName == "" {
+		return msg.SenderUID
+	}
+	return msg.SenderName
+}
+
+// exportTitle names a conversation in a transcript, falling back to its ID
+func exportTitle(conv messages.Conversation) string {
+	if conv.Title == "" {
+		return conv.ID
+	}
+	return conv.Title
+}
+
+// renderPlainTranscript renders messages, oldest first, as plain text with
+// one timestamped line per message
+func renderPlainTranscript(conv messages.Conversation, msgs []messages.Message, tf util.TimeFormat) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s (%s)\n\n", exportTitle(conv), conv.Platform)
+	for _, msg := range msgs {
+		fmt.Fprintf(&sb, "[%s] %s: %s\n", tf.In(msg.Timestamp).Format("2006-01-02 15:04"), exportSender(msg), exportMessageText(msg))
+	}
+	return sb.String()
+}
+
+// renderMarkdownTranscript renders messages, oldest first, as Markdown with a
+// heading for each day
+func renderMarkdownTranscript(conv messages.Conversation, msgs []messages.Message, tf util.TimeFormat) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n_%s · %d messages_\n", exportTitle(conv), conv.Platform, len(msgs))
+
+	day := ""
+	for _, msg := range msgs {
+		t := tf.In(msg.Timestamp)
+		if d := t.Format("2006-01-02"); d != day {
+			day = d
+			fmt.Fprintf(&sb, "\n## %s\n\n", t.Format("Monday, January 2, 2006"))
+		}
+		fmt.Fprintf(&sb, "- %s **%s:** %s\n", t.Format("15:04"), exportSender(msg), strings.ReplaceAll(exportMessageText(msg), "\n", "\n  "))
+	}
+	return sb.String()
+}
@@ -0,0 +1,205 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/arjungandhi/dunbar/pkg/messages"
+	"github.com/arjungandhi/dunbar/pkg/util"
+)
+
+func TestMessagesResizeKeepsCursorsOnScreen(t *testing.T) {
+	conversations := make([]messages.Conversation, 40)
+	for i := range conversations {
+		conversations[i] = messages.Conversation{ID: fmt.Sprint(i), Title: fmt.Sprintf("Chat %02d", i)}
+	}
+	start := time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)
+	msgs := make([]messages.Message, 30)
+	for i := range msgs {
+		// Newest first, a few hours apart so some days get separators, with
+		// some long enough to wrap
+		msgs[i] = messages.Message{
+			ID:        fmt.Sprint(i),
+			Timestamp: start.Add(-time.Duration(i) * 5 * time.Hour),
+			Text:      strings.Repeat("word ", 1+i%4*10),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		viewMode string
+		cursor   int
+		sizes    [][2]int // Width, height of each resize, in order
+	}{
+		{"conversations shrink at bottom", "conversations", 39, [][2]int{{100, 50}, {100, 8}, {100, 4}}},
+		{"conversations shrink then grow", "conversations", 20, [][2]int{{60, 6}, {200, 80}, {80, 10}}},
+		{"messages shrink at oldest", "messages", 29, [][2]int{{100, 50}, {100, 12}, {40, 8}}},
+		{"messages shrink then grow", "messages", 15, [][2]int{{50, 10}, {200, 100}, {30, 6}}},
+		{"messages tiny terminal", "messages", 5, [][2]int{{20, 4}, {20, 3}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := messagesModel{conversations: conversations, viewMode: tt.viewMode, height: 25, width: 80}
+			// The preview is already loaded and there are no older messages,
+			// so nothing needs a database
+			m.previewConvID = conversations[0].ID
+			if tt.viewMode == "messages" {
+				m.messages = msgs
+				m.messagesCursor = tt.cursor
+			} else {
+				m.cursor = tt.cursor
+				m.previewConvID = conversations[tt.cursor].ID
+			}
+
+			for _, size := range tt.sizes {
+				updated, _ := m.Update(tea.WindowSizeMsg{Width: size[0], Height: size[1]})
+				m = updated.(messagesModel)
+				at := fmt.Sprintf("after %dx%d", size[0], size[1])
+
+				if m.viewportTop < 0 || m.cursor < m.viewportTop || m.cursor >= m.viewportTop+m.height {
+					t.Errorf("%s: conversation cursor %d off screen (top %d, height %d)", at, m.cursor, m.viewportTop, m.height)
+				}
+				if tt.viewMode != "messages" {
+					continue
+				}
+				if m.messagesCursor != tt.cursor {
+					t.Errorf("%s: message cursor moved from %d to %d", at, tt.cursor, m.messagesCursor)
+				}
+				visible := calculateVisibleMessageCount(m.messages, m.messagesViewTop, m.width-4, m.messagesAvailableHeight(), m.timeFormat)
+				if m.messagesViewTop < 0 || m.messagesCursor < m.messagesViewTop ||
+					(m.messagesCursor >= m.messagesViewTop+visible && m.messagesViewTop != m.messagesCursor) {
+					t.Errorf("%s: message cursor %d off screen (top %d, %d visible)", at, m.messagesCursor, m.messagesViewTop, visible)
+				}
+				if last := m.lastMessagesViewTop(); m.messagesViewTop > last {
+					t.Errorf("%s: scrolled to %d, past the end at %d", at, m.messagesViewTop, last)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatMessageAttachmentIndicators(t *testing.T) {
+	img := messages.Attachment{Type: "img"}
+	video := messages.Attachment{Type: "video"}
+	file := messages.Attachment{Type: "unknown"}
+
+	tests := []struct {
+		name        string
+		text        string
+		attachments []messages.Attachment
+		want        string
+	}{
+		{"text only", "see you soon", nil, "see you soon"},
+		{"media only", "", []messages.Attachment{video, img, file, img}, "[📷 2 Images, 🎥 Video, 📎 File]"},
+		{"mixed", "look at this", []messages.Attachment{file, video, img}, "[📷 Image, 🎥 Video, 📎 File] look at this"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := messages.Message{
+				SenderName:  "Ada",
+				Timestamp:   time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC),
+				Text:        tt.text,
+				Attachments: tt.attachments,
+			}
+			got := formatMessage(msg, 80, nil, util.TimeFormat{}, "")
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("formatMessage() = %q, want it to contain %q", got, tt.want)
+			}
+			if tt.attachments == nil && strings.Contains(got, "[") {
+				t.Errorf("formatMessage() = %q, want no attachment indicator", got)
+			}
+			// The same message always renders the same way
+			for range 20 {
+				if again := formatMessage(msg, 80, nil, util.TimeFormat{}, ""); again != got {
+					t.Fatalf("formatMessage() rendered %q, then %q", got, again)
+				}
+			}
+		})
+	}
+}
+
+func TestLayoutMessagesDateSeparators(t *testing.T) {
+	day := time.Date(2024, time.March, 1, 9, 0, 0, 0, time.UTC)
+	msgs := []messages.Message{
+		{ID: "m0", SenderName: "Ada", Timestamp: day, Text: "morning"},
+		{ID: "m1", SenderName: "Grace", Timestamp: day.Add(time.Hour), Text: "hello"},
+		{ID: "m2", SenderName: "Ada", Timestamp: day.Add(24 * time.Hour), Text: "next day"},
+	}
+	const width = 76
+	tf := util.TimeFormat{}
+	items := insertDateSeparators(msgs, tf)
+	lines := func(s string) int { return strings.Count(s, "\n") }
+	sepLines := lines(renderDateSeparator(*items[0].dateSeparator, width))
+	firstDay := sepLines + lines(formatMessage(msgs[0], width, nil, tf, "")) +
+		lines(formatMessage(msgs[1], width, &msgs[0], tf, ""))
+
+	tests := []struct {
+		name   string
+		msgs   []messages.Message
+		start  int
+		height int
+		want   []string // Message IDs, with "--" for a date separator
+	}{
+		{"empty", nil, 0, 20, nil},
+		{"single message", msgs[:1], 0, 20, []string{"--", "m0"}},
+		{"all fit", msgs, 0, 20, []string{"--", "m0", "m1", "--", "m2"}},
+		{"first of day at top", msgs, 2, 20, []string{"--", "m2"}},
+		{"mid-day at top", msgs, 1, 20, []string{"m1", "--", "m2"}},
+		{"no trailing separator", msgs, 0, firstDay + sepLines, []string{"--", "m0", "m1"}},
+		{"too short for separator and message", msgs, 0, sepLines, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, item := range layoutMessages(insertDateSeparators(tt.msgs, tf), tt.start, width, tt.height, tf) {
+				if item.isSeparator() {
+					got = append(got, "--")
+				} else {
+					got = append(got, item.message.ID)
+				}
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("layoutMessages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAttachmentIndicatorsFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		attachments []messages.Attachment
+		want        []string
+	}{
+		{"sticker", []messages.Attachment{{Type: "img", IsSticker: true}}, []string{"🎨 Sticker"}},
+		{"stickers", []messages.Attachment{{Type: "img", IsSticker: true}, {Type: "img", IsSticker: true}}, []string{"🎨 2 Stickers"}},
+		{"gif", []messages.Attachment{{Type: "video", IsGif: true}}, []string{"GIF"}},
+		{"gifs", []messages.Attachment{{Type: "video", IsGif: true}, {Type: "img", IsGif: true}}, []string{"2 GIFs"}},
+		{"voice note", []messages.Attachment{{Type: "audio", IsVoiceNote: true, Duration: 12}}, []string{"🎤 Voice (0:12)"}},
+		{"voice note without duration", []messages.Attachment{{Type: "audio", IsVoiceNote: true}}, []string{"🎤 Voice"}},
+		{"voice notes", []messages.Attachment{{Type: "audio", IsVoiceNote: true, Duration: 75}, {Type: "audio", IsVoiceNote: true, Duration: 3.6}},
+			[]string{"🎤 Voice (1:15)", "🎤 Voice (0:04)"}},
+		{"animated sticker", []messages.Attachment{{Type: "video", IsGif: true, IsSticker: true}}, []string{"🎨 Sticker"}},
+		{"voice note wins", []messages.Attachment{{Type: "audio", IsVoiceNote: true, IsGif: true, IsSticker: true, Duration: 1}}, []string{"🎤 Voice (0:01)"}},
+		{"plain audio", []messages.Attachment{{Type: "audio", Duration: 12}}, []string{"🎵 Audio"}},
+		{"mixed", []messages.Attachment{
+			{Type: "unknown"},
+			{Type: "audio", IsVoiceNote: true, Duration: 12},
+			{Type: "img", IsSticker: true},
+			{Type: "video", IsGif: true},
+			{Type: "img"},
+		}, []string{"📷 Image", "GIF", "🎨 Sticker", "🎤 Voice (0:12)", "📎 File"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := attachmentIndicators(tt.attachments)
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("attachmentIndicators() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+)
+
+// noteEditedMsg is sent when the external editor opened on a contact's
+// notes exits
+type noteEditedMsg struct {
+	uid  string
+	path string // Temporary file holding the edited notes
+	err  error
+}
+
+// noteEditor returns the user's editor command, from $VISUAL or $EDITOR,
+// split into the program and its arguments (e.g. "code --wait")
+func noteEditor() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return nil
+}
+
+// startNoteEdit edits the notes of the contact under the cursor in the
+// user's editor, suspending the TUI while it runs. Without $VISUAL or
+// $EDITOR, a text area opens in the TUI instead.
+func (m contactsModel) startNoteEdit() (tea.Model, tea.Cmd) {
+	contact := m.contacts[m.cursor]
+
+	editor := noteEditor()
+	if editor == nil {
+		return m.openNoteForm(contact.UID, contact.Notes, contact.DisplayName())
+	}
+
+	file, err := os.CreateTemp("", "dunbar-notes-*.txt")
+	if err != nil {
+		m.status = fmt.Sprintf("Failed to edit notes: %v", err)
+		return m, nil
+	}
+	path := file.Name()
+	_, err = file.WriteString(contact.Notes)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		m.status = fmt.Sprintf("Failed to edit notes: %v", err)
+		return m, nil
+	}
+
+	cmd := exec.Command(editor[0], append(editor[1:], path)...)
+	return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return noteEditedMsg{uid: contact.UID, path: path, err: err}
+	})
+}
+
+// finishNoteEdit saves the notes written in the external editor
+func (m contactsModel) finishNoteEdit(msg noteEditedMsg) (tea.Model, tea.Cmd) {
+	defer os.Remove(msg.path)
+	if msg.err != nil {
+		m.status = fmt.Sprintf("Editor failed; notes not saved: %v", msg.err)
+		return m, nil
+	}
+	data, err := os.ReadFile(msg.path)
+	if err != nil {
+		m.status = fmt.Sprintf("Failed to read the edited notes: %v", err)
+		return m, nil
+	}
+	return m.saveNotes(msg.uid, string(data))
+}
+
+// openNoteForm opens a text area in the TUI for editing a contact's notes
+func (m contactsModel) openNoteForm(uid, notes, name string) (tea.Model, tea.Cmd) {
+	// esc cancels as well as ctrl+c
+	keymap := huh.NewDefaultKeyMap()
+	keymap.Quit = key.NewBinding(key.WithKeys("esc", "ctrl+c"))
+
+	m.noteUID = uid
+	m.noteValue = &notes
+	m.noteForm = huh.NewForm(
+		huh.NewGroup(
+			huh.NewText().
+				Title("Notes").
+				Description(name + " (set $EDITOR to use your own editor)").
+				Lines(10).
+				Value(m.noteValue),
+		),
+	).WithKeyMap(keymap).WithShowHelp(false).WithWidth(max(20, min(80, m.width-8)))
+	return m, m.noteForm.Init()
+}
+
+// updateNoteForm passes a message to the open notes text area and saves the
+// notes once it's submitted
+func (m contactsModel) updateNoteForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	form, cmd := m.noteForm.Update(msg)
+	m.noteForm = form.(*huh.Form)
+
+	switch m.noteForm.State {
+	case huh.StateAborted:
+		m.noteForm = nil
+		return m, nil
+	case huh.StateCompleted:
+		m.noteForm = nil
+		return m.saveNotes(m.noteUID, *m.noteValue)
+	}
+	return m, cmd
+}
+
+// saveNotes writes a contact's edited notes, pushing them to the provider
+func (m contactsModel) saveNotes(uid, notes string) (tea.Model, tea.Cmd) {
+	notes = strings.TrimRight(notes, " \t\r\n")
+	contact := m.contactByUID(uid)
+	if notes == contact.Notes {
+		return m, m.setTransientStatus("Notes unchanged")
+	}
+
+	contact.Notes = notes
+	if err := m.cm.WriteContact(cmdCtx, contact); err != nil {
+		m.status = fmt.Sprintf("Saving notes failed: %v", explainContactsError(err))
+		return m, nil
+	}
+	if saved, err := m.cm.GetContact(uid); err == nil && saved != nil {
+		contact = *saved
+	}
+	for i, c := range m.all {
+		if c.UID == uid {
+			m.all[i] = contact
+			break
+		}
+	}
+	m.showContacts()
+	return m, m.setTransientStatus(fmt.Sprintf("Saved notes for %s", contact.DisplayName()))
+}
@@ -0,0 +1,26 @@
+package cli
+
+import "slices"
+
+// togglePin pins id at the end of the pinned block, or unpins it if it's
+// already pinned. pinned is the pinned IDs in order; a new slice is returned.
+func togglePin(pinned []string, id string) []string {
+	if i := slices.Index(pinned, id); i >= 0 {
+		return slices.Delete(slices.Clone(pinned), i, i+1)
+	}
+	return append(slices.Clone(pinned), id)
+}
+
+// movePin moves the pinned id one place up (delta -1) or down (delta 1)
+// within the pinned block. It reports false if id isn't pinned or is
+// already at that end of the block.
+func movePin(pinned []string, id string, delta int) ([]string, bool) {
+	i := slices.Index(pinned, id)
+	j := i + delta
+	if i < 0 || j < 0 || j >= len(pinned) {
+		return pinned, false
+	}
+	moved := slices.Clone(pinned)
+	moved[i], moved[j] = moved[j], moved[i]
+	return moved, true
+}
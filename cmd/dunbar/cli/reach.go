@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/arjungandhi/dunbar/pkg/contacts"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// reachAction is something the contacts TUI does with one of a contact's
+// phone numbers or email addresses
+type reachAction int
+
+const (
+	reachCall      reachAction = iota // Open a tel: link
+	reachMail                         // Open a mailto: link
+	reachCopyPhone                    // Copy the phone number
+	reachCopyEmail                    // Copy the email address
+)
+
+// reachKeys maps the contacts TUI keys to their actions
+var reachKeys = map[string]reachAction{
+	"c": reachCall,
+	"m": reachMail,
+	"y": reachCopyPhone,
+	"Y": reachCopyEmail,
+}
+
+// usesEmail reports whether the action takes an email address rather than a
+// phone number
+func (a reachAction) usesEmail() bool {
+	return a == reachMail || a == reachCopyEmail
+}
+
+// startReach runs the action on the contact under the cursor. With more than
+// one phone number or email address to choose from, it opens a chooser first.
+func (m contactsModel) startReach(action reachAction) (tea.Model, tea.Cmd) {
+	contact := m.contacts[m.cursor]
+	options, preselect := m.reachOptions(action, contact)
+	switch len(options) {
+	case 0:
+		what := "phone number"
+		if action.usesEmail() {
+			what = "email address"
+		}
+		return m, m.setTransientStatus(fmt.Sprintf("%s has no %s", contact.DisplayName(), what))
+	case 1:
+		return m.reach(action, options[0].Value)
+	}
+
+	title := map[reachAction]string{
+		reachCall:      "Call which number?",
+		reachMail:      "Email which address?",
+		reachCopyPhone: "Copy which number?",
+		reachCopyEmail: "Copy which address?",
+	}[action]
+
+	// esc cancels as well as ctrl+c
+	keymap := huh.NewDefaultKeyMap()
+	keymap.Quit = key.NewBinding(key.WithKeys("esc", "ctrl+c"))
+
+	m.reachAction = action
+	m.reachUID = contact.UID
+	m.reachValue = &preselect
+	m.chooser = huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title(title).
+				Description(contact.DisplayName()).
+				Options(options...).
+				Value(m.reachValue),
+		),
+	).WithKeyMap(keymap).WithShowHelp(false)
+	return m, m.chooser.Init()
+}
+
+// updateChooser passes a message to the open chooser and runs the action
+// once a choice is made
+func (m contactsModel) updateChooser(msg tea.Msg) (tea.Model, tea.Cmd) {
+	form, cmd := m.chooser.Update(msg)
+	m.chooser = form.(*huh.Form)
+
+	switch m.chooser.State {
+	case huh.StateAborted:
+		m.chooser = nil
+		return m, nil
+	case huh.StateCompleted:
+		m.chooser = nil
+		if err := m.rememberReach(m.reachAction, m.reachUID, *m.reachValue); err != nil {
+			m.status = fmt.Sprintf("Failed to remember the choice: %v", err)
+		}
+		return m.reach(m.reachAction, *m.reachValue)
+	}
+	return m, cmd
+}
+
+// reach calls, emails, or copies value
+func (m contactsModel) reach(action reachAction, value string) (tea.Model, tea.Cmd) {
+	var err error
+	var status string
+	switch action {
+	case reachCall:
+		err = openBrowser("tel:" + strings.ReplaceAll(value, " ", ""))
+		status = "Calling " + value
+	case reachMail:
+		err = openBrowser("mailto:" + value)
+		status = "Emailing " + value
+	case reachCopyPhone, reachCopyEmail:
+		err = copyToClipboard(value)
+		status = "Copied " + value
+	}
+	if err != nil {
+		m.status = fmt.Sprintf("Failed to reach %s: %v", value, err)
+		return m, nil
+	}
+	return m, m.setTransientStatus(status)
+}
+
+// reachOptions returns the contact's phone numbers or email addresses for
+// the action, and which to preselect: the one last chosen for this contact
+// if it's still there, otherwise the primary one
+func (m contactsModel) reachOptions(action reachAction, contact contacts.Contact) ([]huh.Option[string], string) {
+	var options []huh.Option[string]
+	add := func(value, kind string) {
+		label := value
+		if kind != "" {
+			label += " (" + kind + ")"
+		}
+		options = append(options, huh.NewOption(label, value))
+	}
+
+	primary := contact.PrimaryPhone()
+	if action.usesEmail() {
+		primary = contact.PrimaryEmail()
+		for _, e := range contact.EmailAddresses {
+			add(e.Value, e.Type)
+		}
+	} else {
+		for _, p := range contact.PhoneNumbers {
+			add(p.Value, p.Type)
+		}
+	}
+
+	last := m.lastReach(action, contact.UID)
+	if last != "" && slices.ContainsFunc(options, func(o huh.Option[string]) bool { return o.Value == last }) {
+		return options, last
+	}
+	return options, primary
+}
+
+// lastReach returns the phone number or email address last chosen for the
+// contact, or "" if there isn't one
+func (m contactsModel) lastReach(action reachAction, uid string) string {
+	if m.cfg == nil {
+		return ""
+	}
+	state, err := m.cfg.LoadTUIState()
+	if err != nil {
+		return ""
+	}
+	if action.usesEmail() {
+		return state.ContactEmails[uid]
+	}
+	return state.ContactPhones[uid]
+}
+
+// rememberReach saves value as the phone number or email address last
+// chosen for the contact
+func (m contactsModel) rememberReach(action reachAction, uid, value string) error {
+	if m.cfg == nil {
+		return nil
+	}
+	state, err := m.cfg.LoadTUIState()
+	if err != nil {
+		return err
+	}
+
+	choices := &state.ContactPhones
+	if action.usesEmail() {
+		choices = &state.ContactEmails
+	}
+	if *choices == nil {
+		*choices = make(map[string]string)
+	}
+	(*choices)[uid] = value
+
+	return m.cfg.SaveTUIState(state)
+}
+
+// renderChooser draws an open chooser in a box, centered in width x height
+func renderChooser(form *huh.Form, width, height int) string {
+	return renderFormBox(form, "enter: choose • esc: cancel", width, height)
+}
+
+// renderFormBox draws a form in a box with a key hint below it, centered in
+// width x height
+func renderFormBox(form *huh.Form, hint string, width, height int) string {
+	boxStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("240")).
+		Padding(1, 2)
+	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	content := form.View() + "\n\n" + footerStyle.Render(hint)
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, boxStyle.Render(content))
+}
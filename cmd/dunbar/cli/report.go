@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/arjungandhi/dunbar/pkg/contacts"
+	"github.com/arjungandhi/dunbar/pkg/messages"
+	"github.com/arjungandhi/dunbar/pkg/util"
+	Z "github.com/rwxrob/bonzai/z"
+)
+
+// reportRecentMessages is how many of the latest messages a report quotes
+const reportRecentMessages = 10
+
+var ContactsReport = &Z.Cmd{
+	Name:    "report",
+	Summary: "Write a Markdown relationship report for a contact",
+	Usage:   "<uid|name> [--out FILE]",
+	MinArgs: 1,
+	Description: `
+Generate a Markdown dossier for a contact: their details, tags and notes,
+when you were last in touch, message counts per platform across all linked
+conversations, and the most recent messages. The report is printed, or
+written to FILE with --out.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		cfg := loadConfig()
+		cm, err := getContactManager(cfg)
+		if err != nil {
+			return err
+		}
+
+		uid, err := resolveContactUID(cm, args[0])
+		if err != nil {
+			return err
+		}
+		contact, err := cm.GetContact(uid)
+		if err != nil {
+			return fmt.Errorf("failed to read contact: %w", err)
+		}
+		if contact == nil {
+			return fmt.Errorf("%w: %s", contacts.ErrNotFound, uid)
+		}
+
+		notes, err := cm.ListNotes(uid)
+		if err != nil {
+			return fmt.Errorf("failed to list notes: %w", err)
+		}
+
+		mm, err := newMessageManager(cfg, io.Discard)
+		if err != nil {
+			return err
+		}
+		defer mm.Close()
+
+		timeline, err := mm.GetUnifiedTimelineForContact(*contact)
+		if err != nil {
+			return fmt.Errorf("failed to load messages: %w", err)
+		}
+
+		report := renderContactReport(*contact, notes, timeline, cfg.DisplayTimeFormat())
+
+		path := flagValue(args, "--out")
+		if path == "" {
+			fmt.Print(report)
+			return nil
+		}
+		if err := os.WriteFile(path, []byte(report), 0644); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+		fmt.Printf("Report written to %s\n", path)
+		return nil
+	},
+}
+
+// platformCount tallies the messages exchanged on one platform
+type platformCount struct {
+	platform string
+	sent     int
+	received int
+}
+
+// renderContactReport renders a Markdown report from a contact, their dated
+// notes, and their unified message timeline (newest first)
+func renderContactReport(contact contacts.Contact, notes []contacts.Note, timeline []messages.Message, tf util.TimeFormat) string {
+	var sb strings.Builder
+
+	title := contact.DisplayName()
+	if contact.IsFavorite {
+		title += " ⭐"
+	}
+	fmt.Fprintf(&sb, "# %s\n\n", title)
+	fmt.Fprintf(&sb, "_Relationship report generated %s_\n\n", tf.Now().Format(tf.MonthDayYear()))
+
+	// Details
+	sb.WriteString("## Details\n\n")
+	if contact.Nickname != "" {
+		fmt.Fprintf(&sb, "- **Nickname:** %s\n", contact.Nickname)
+	}
+	for _, p := range contact.PhoneNumbers {
+		fmt.Fprintf(&sb, "- **Phone (%s):** %s\n", p.Type, p.Value)
+	}
+	for _, e := range contact.EmailAddresses {
+		fmt.Fprintf(&sb, "- **Email (%s):** %s\n", e.Type, e.Value)
+	}
+	if org := contact.Organization; org != nil && org.Name != "" {
+		work := org.Name
+		if org.Title != "" {
+			work = org.Title + ", " + work
+		}
+		fmt.Fprintf(&sb, "- **Work:** %s\n", work)
+	}
+	if contact.Birthday != nil {
+		fmt.Fprintf(&sb, "- **Birthday:** %s\n", formatEventDate(*contact.Birthday))
+	}
+	if contact.Anniversary != nil {
+		fmt.Fprintf(&sb, "- **Anniversary:** %s\n", formatEventDate(*contact.Anniversary))
+	}
+	if len(contact.Tags) > 0 {
+		fmt.Fprintf(&sb, "- **Tags:** %s\n", strings.Join(contact.Tags, ", "))
+	}
+	if len(contact.Groups) > 0 {
+		fmt.Fprintf(&sb, "- **Groups:** %s\n", strings.Join(contact.Groups, ", "))
+	}
+	sb.WriteString("\n")
+
+	// Notes
+	if contact.Notes != "" || len(notes) > 0 {
+		sb.WriteString("## Notes\n\n")
+		if contact.Notes != "" {
+			sb.WriteString(contact.Notes)
+			sb.WriteString("\n\n")
+		}
+		for _, note := range notes {
+			fmt.Fprintf(&sb, "- **%s:** %s\n", tf.In(note.Timestamp).Format("2006-01-02"), note.Text)
+		}
+		if len(notes) > 0 {
+			sb.WriteString("\n")
+		}
+	}
+
+	// Messaging activity
+	sb.WriteString("## Messages\n\n")
+	if len(timeline) == 0 {
+		sb.WriteString("No messages found.\n")
+		return sb.String()
+	}
+
+	last := timeline[0].Timestamp
+	fmt.Fprintf(&sb, "- **Last contacted:** %s (%s)\n", tf.In(last).Format(tf.MonthDayYear()), util.TimeAgo(last, tf))
+	fmt.Fprintf(&sb, "- **Total messages:** %d\n\n", len(timeline))
+
+	sb.WriteString("| Platform | Messages | Sent | Received |\n")
+	sb.WriteString("|---|---:|---:|---:|\n")
+	for _, p := range countByPlatform(timeline) {
+		fmt.Fprintf(&sb, "| %s | %d | %d | %d |\n", p.platform, p.sent+p.received, p.sent, p.received)
+	}
+	sb.WriteString("\n")
+
+	// Most recent messages, oldest first
+	recent := timeline[:min(reportRecentMessages, len(timeline))]
+	sb.WriteString("## Recent messages\n\n")
+	for i := len(recent) - 1; i >= 0; i-- {
+		msg := recent[i]
+		sender := contact.DisplayName()
+		if msg.IsSent {
+			sender = "You"
+		}
+		fmt.Fprintf(&sb, "- %s %s **%s:** %s\n",
+			tf.In(msg.Timestamp).Format("2006-01-02 15:04"),
+			getPlatformIcon(msg.Platform),
+			sender,
+			reportMessageText(msg),
+		)
+	}
+
+	return sb.String()
+}
+
+// countByPlatform tallies sent and received messages per platform, busiest first
+func countByPlatform(msgs []messages.Message) []platformCount {
+	counts := make(map[string]*platformCount)
+	var order []*platformCount
+	for _, msg := range msgs {
+		c, ok := counts[msg.Platform]
+		if !ok {
+			c = &platformCount{platform: msg.Platform}
+			counts[msg.Platform] = c
+			order = append(order, c)
+		}
+		if msg.IsSent {
+			c.sent++
+		} else {
+			c.received++
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return order[i].sent+order[i].received > order[j].sent+order[j].received
+	})
+	result := make([]platformCount, len(order))
+	for i, c := range order {
+		result[i] = *c
+	}
+	return result
+}
+
+// reportMessageText flattens a message to one line of Markdown text
+func reportMessageText(msg messages.Message) string {
+	text := cleanMessageText(msg.Text)
+	switch {
+	case msg.IsDeleted:
+		return "_(message deleted)_"
+	case text == "" && len(msg.Attachments) > 0:
+		return fmt.Sprintf("_(%d attachment(s))_", len(msg.Attachments))
+	case text == "":
+		return "_(no text)_"
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,402 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+)
+
+var Schedule = &Z.Cmd{
+	Name:     "schedule",
+	Summary:  "Run 'dunbar sync' in the background on a schedule",
+	Commands: []*Z.Cmd{help.Cmd, ScheduleInstall, ScheduleUninstall, ScheduleStatus},
+	Description: `
+Keep contacts and messages fresh without thinking about it. 'install'
+registers a background job that runs 'dunbar sync' every interval using
+the platform's scheduler: a systemd user timer on Linux, a launchd agent on
+macOS, or a Scheduled Task on Windows. 'uninstall' removes it and 'status'
+shows whether it's installed and when it runs.
+
+On Linux, user timers only run while you're logged in unless lingering is
+enabled with 'loginctl enable-linger'. Sync errors end up in dunbar.log,
+and on macOS the job's output goes to sync.log in the data directory.
+`,
+}
+
+var ScheduleInstall = &Z.Cmd{
+	Name:    "install",
+	Summary: "Install the background sync job",
+	Usage:   "[--interval DURATION]",
+	Description: `
+Install (or replace) the background job running 'dunbar sync', every hour
+unless --interval says otherwise (e.g. --interval 30m). The interval must
+be between 5m and 24h. The job runs this dunbar executable, so reinstall
+after moving it. If --dir or DUNBAR_DIR is in effect, the job uses the same
+directory.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		interval := defaultScheduleInterval
+		if value := flagValue(args, "--interval"); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid --interval %q: use a duration like 30m or 2h", value)
+			}
+			interval = d
+		}
+		if interval < minScheduleInterval || interval > maxScheduleInterval {
+			return fmt.Errorf("--interval must be between %s and %s", minScheduleInterval, maxScheduleInterval)
+		}
+
+		command, err := scheduledSyncCommand()
+		if err != nil {
+			return err
+		}
+
+		where, err := installSchedule(command, interval)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Installed: %s will run every %s (%s)\n", strings.Join(command, " "), interval, where)
+		return nil
+	},
+}
+
+var ScheduleUninstall = &Z.Cmd{
+	Name:    "uninstall",
+	Summary: "Remove the background sync job",
+	Call: func(x *Z.Cmd, args ...string) error {
+		if err := uninstallSchedule(); err != nil {
+			return err
+		}
+		fmt.Println("Removed the background sync job")
+		return nil
+	},
+}
+
+var ScheduleStatus = &Z.Cmd{
+	Name:    "status",
+	Summary: "Show whether the background sync job is installed",
+	Call: func(x *Z.Cmd, args ...string) error {
+		return scheduleStatus()
+	},
+}
+
+// Bounds and default for the background sync interval
+const (
+	defaultScheduleInterval = time.Hour
+	minScheduleInterval     = 5 * time.Minute
+	maxScheduleInterval     = 24 * time.Hour
+)
+
+// scheduleName identifies the background job to the platform's scheduler
+const scheduleName = "dunbar-sync"
+
+// launchdLabel is the background job's launchd label on macOS
+const launchdLabel = "com.github.arjungandhi.dunbar.sync"
+
+// scheduledSyncCommand returns the command line the background job runs:
+// this executable's 'sync', pinned to the current dunbar directory if one
+// was chosen with --dir or DUNBAR_DIR
+func scheduledSyncCommand() ([]string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the dunbar executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+
+	command := []string{exe}
+	dir := configDir
+	if dir == "" {
+		dir = os.Getenv("DUNBAR_DIR")
+	}
+	if dir != "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", dir, err)
+		}
+		command = append(command, "--dir", abs)
+	}
+	return append(command, "sync"), nil
+}
+
+// installSchedule registers the background job with the platform's
+// scheduler and returns where it was installed
+func installSchedule(command []string, interval time.Duration) (string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return installSystemdTimer(command, interval)
+	case "darwin":
+		return installLaunchAgent(command, interval)
+	case "windows":
+		return installScheduledTask(command, interval)
+	default:
+		return "", fmt.Errorf("scheduling isn't supported on %s; run 'dunbar sync' from cron instead", runtime.GOOS)
+	}
+}
+
+// uninstallSchedule removes the background job, if it's installed
+func uninstallSchedule() error {
+	switch runtime.GOOS {
+	case "linux":
+		return uninstallSystemdTimer()
+	case "darwin":
+		return uninstallLaunchAgent()
+	case "windows":
+		return runScheduler("schtasks", "/Delete", "/F", "/TN", scheduleName)
+	default:
+		return fmt.Errorf("scheduling isn't supported on %s", runtime.GOOS)
+	}
+}
+
+// scheduleStatus prints whether the background job is installed and what
+// the platform's scheduler says about it
+func scheduleStatus() error {
+	var path string
+	var status *exec.Cmd
+	switch runtime.GOOS {
+	case "linux":
+		dir, err := systemdUserDir()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(dir, scheduleName+".timer")
+		status = exec.Command("systemctl", "--user", "list-timers", "--all", scheduleName+".timer")
+	case "darwin":
+		var err error
+		path, err = launchAgentPath()
+		if err != nil {
+			return err
+		}
+		status = exec.Command("launchctl", "list", launchdLabel)
+	case "windows":
+		status = exec.Command("schtasks", "/Query", "/TN", scheduleName, "/FO", "LIST", "/V")
+	default:
+		return fmt.Errorf("scheduling isn't supported on %s", runtime.GOOS)
+	}
+
+	if path != "" {
+		if _, err := os.Stat(path); err != nil {
+			fmt.Println("Not installed. Run 'dunbar schedule install' to sync in the background.")
+			return nil
+		}
+		fmt.Printf("Installed: %s\n\n", path)
+	}
+	status.Stdout = os.Stdout
+	status.Stderr = os.Stderr
+	if err := status.Run(); err != nil && path == "" {
+		// Windows has no file to check; schtasks fails for a missing task
+		fmt.Println("Not installed. Run 'dunbar schedule install' to sync in the background.")
+	}
+	return nil
+}
+
+// runScheduler runs a scheduler command, including its output in the error
+// if it fails
+func runScheduler(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w\n%s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// systemdUserDir returns where systemd looks for user units
+func systemdUserDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the config directory: %w", err)
+	}
+	return filepath.Join(dir, "systemd", "user"), nil
+}
+
+// installSystemdTimer writes a oneshot service running the sync and a
+// timer starting it every interval, then enables the timer
+func installSystemdTimer(command []string, interval time.Duration) (string, error) {
+	dir, err := systemdUserDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = systemdQuote(arg)
+	}
+	service := fmt.Sprintf(`[Unit]
+Description=Sync dunbar contacts and messages
+
+[Service]
+Type=oneshot
+ExecStart=%s
+`, strings.Join(quoted, " "))
+	timer := fmt.Sprintf(`[Unit]
+Description=Run dunbar sync every %s
+
+[Timer]
+OnBootSec=5min
+OnUnitActiveSec=%ds
+
+[Install]
+WantedBy=timers.target
+`, interval, int(interval.Seconds()))
+
+	files := map[string]string{scheduleName + ".service": service, scheduleName + ".timer": timer}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	if err := runScheduler("systemctl", "--user", "daemon-reload"); err != nil {
+		return "", err
+	}
+	if err := runScheduler("systemctl", "--user", "enable", "--now", scheduleName+".timer"); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, scheduleName+".timer"), nil
+}
+
+// uninstallSystemdTimer stops and removes the timer and its service
+func uninstallSystemdTimer() error {
+	dir, err := systemdUserDir()
+	if err != nil {
+		return err
+	}
+	timer := filepath.Join(dir, scheduleName+".timer")
+	if _, err := os.Stat(timer); err != nil {
+		return fmt.Errorf("no background sync job is installed")
+	}
+
+	if err := runScheduler("systemctl", "--user", "disable", "--now", scheduleName+".timer"); err != nil {
+		return err
+	}
+	for _, name := range []string{scheduleName + ".timer", scheduleName + ".service"} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", name, err)
+		}
+	}
+	return runScheduler("systemctl", "--user", "daemon-reload")
+}
+
+// systemdQuote quotes a word of an ExecStart line if it needs it
+func systemdQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\$%;") {
+		return s
+	}
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", "$$", "%", "%%").Replace(s)
+	return `"` + s + `"`
+}
+
+// launchAgentPath returns where the launchd agent's plist is written
+func launchAgentPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the home directory: %w", err)
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist"), nil
+}
+
+// installLaunchAgent writes a launchd agent running the sync every
+// interval, with its output logged to the data directory, and loads it
+func installLaunchAgent(command []string, interval time.Duration) (string, error) {
+	path, err := launchAgentPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+
+	var arguments strings.Builder
+	for _, arg := range command {
+		fmt.Fprintf(&arguments, "\t\t<string>%s</string>\n", xmlEscape(arg))
+	}
+	logPath := filepath.Join(loadConfig().DataDir, "sync.log")
+	plist := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+%s	</array>
+	<key>StartInterval</key>
+	<integer>%d</integer>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>StandardOutPath</key>
+	<string>%s</string>
+	<key>StandardErrorPath</key>
+	<string>%s</string>
+</dict>
+</plist>
+`, launchdLabel, arguments.String(), int(interval.Seconds()), xmlEscape(logPath), xmlEscape(logPath))
+
+	// Replacing a loaded agent needs it unloaded first; failure means it wasn't loaded
+	if _, err := os.Stat(path); err == nil {
+		exec.Command("launchctl", "unload", path).Run()
+	}
+	if err := os.WriteFile(path, []byte(plist), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := runScheduler("launchctl", "load", "-w", path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// uninstallLaunchAgent unloads and removes the launchd agent
+func uninstallLaunchAgent() error {
+	path, err := launchAgentPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("no background sync job is installed")
+	}
+	if err := runScheduler("launchctl", "unload", "-w", path); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
+
+// xmlEscape escapes text for a plist string
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// installScheduledTask creates (or replaces) a Scheduled Task running the
+// sync every interval
+func installScheduledTask(command []string, interval time.Duration) (string, error) {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = `"` + arg + `"`
+	}
+
+	// schtasks counts minutes up to a day; a full day is a daily task
+	schedule := []string{"/SC", "MINUTE", "/MO", fmt.Sprint(int(interval.Minutes()))}
+	if interval >= 24*time.Hour {
+		schedule = []string{"/SC", "DAILY"}
+	}
+
+	args := append([]string{"/Create", "/F", "/TN", scheduleName, "/TR", strings.Join(quoted, " ")}, schedule...)
+	if err := runScheduler("schtasks", args...); err != nil {
+		return "", err
+	}
+	return "Scheduled Task " + scheduleName, nil
+}
@@ -0,0 +1,388 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	Z "github.com/rwxrob/bonzai/z"
+
+	"github.com/arjungandhi/dunbar/pkg/config"
+	"github.com/arjungandhi/dunbar/pkg/contacts"
+	"github.com/arjungandhi/dunbar/pkg/logging"
+	"github.com/arjungandhi/dunbar/pkg/messages"
+	"github.com/arjungandhi/dunbar/pkg/util"
+)
+
+var Search = &Z.Cmd{
+	Name:    "search",
+	Summary: "Search contacts and conversations at once",
+	Usage:   "[QUERY]",
+	Description: `
+Search contact names, nicknames, emails, phone numbers, organizations,
+tags, and groups, and conversation titles and message text, all at once.
+Results are printed in two sections, each headed by its name and count:
+
+  Contacts: UID|DisplayName|PrimaryEmail|PrimaryPhone
+  Conversations: ID|Title|Platform|Matched|LatestMatchingMessage
+
+Without a query, open a search TUI instead: results update as you type,
+and enter opens the chosen contact in the contacts TUI or the chosen
+conversation in the messages TUI, with matching messages highlighted.
+Conversations are only searched once 'dunbar messages sync' has run.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		query := strings.TrimSpace(strings.Join(args, " "))
+
+		cfg := loadConfig()
+		s, err := openSearcher(cfg)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+
+		if query == "" {
+			return runSearchTUI(cfg, s)
+		}
+
+		results, err := s.search(query)
+		if err != nil {
+			return err
+		}
+		if results.empty() {
+			fmt.Printf("No contacts or conversations match %q\n", query)
+			return nil
+		}
+
+		fmt.Printf("Contacts (%d)\n", len(results.contacts))
+		for _, contact := range results.contacts {
+			fmt.Printf("%s|%s|%s|%s\n",
+				contact.UID,
+				contact.DisplayName(),
+				contact.PrimaryEmail(),
+				contact.PrimaryPhone(),
+			)
+		}
+
+		fmt.Printf("\nConversations (%d)\n", len(results.conversations))
+		for _, match := range results.conversations {
+			latest := ""
+			if match.Latest != nil {
+				latest = escapeLineBreaks(match.Latest.Text)
+			}
+			fmt.Printf("%s|%s|%s|%s|%s\n",
+				match.Conversation.ID,
+				match.Conversation.Title,
+				match.Conversation.Platform,
+				match.Reason(),
+				latest,
+			)
+		}
+		return nil
+	},
+}
+
+// searcher runs a search across contacts and conversations by composing the
+// search of each
+type searcher struct {
+	cm *contacts.ContactManager
+	mm *messages.MessageManager // Nil until messages have been synced
+}
+
+// searchResults are what a search found, by category
+type searchResults struct {
+	contacts      []contacts.Contact
+	conversations []messages.ConversationMatch
+}
+
+// empty reports whether nothing was found
+func (r searchResults) empty() bool {
+	return len(r.contacts) == 0 && len(r.conversations) == 0
+}
+
+// openSearcher opens the local contacts and, if they've been synced, the
+// local messages. No provider is needed.
+func openSearcher(cfg *config.Config) (*searcher, error) {
+	cm, err := contacts.NewContactManager(nil, *cfg, cfg.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open contacts: %w", err)
+	}
+	s := &searcher{cm: cm}
+
+	// Don't create an empty database for someone who doesn't use messages
+	if _, err := os.Stat(messages.DBPath(*cfg)); errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	s.mm, err = newMessageManager(cfg, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the messages database, if it was opened
+func (s *searcher) Close() error {
+	if s.mm == nil {
+		return nil
+	}
+	return s.mm.Close()
+}
+
+// search finds the contacts and conversations matching query
+func (s *searcher) search(query string) (searchResults, error) {
+	var results searchResults
+	var err error
+
+	results.contacts, err = s.cm.SearchContacts(query)
+	if err != nil {
+		return results, fmt.Errorf("failed to search contacts: %w", err)
+	}
+	if s.mm != nil {
+		results.conversations, err = s.mm.SearchConversations(query)
+		if err != nil {
+			return results, fmt.Errorf("failed to search conversations: %w", err)
+		}
+	}
+	return results, nil
+}
+
+// searchDelay is how long the search TUI waits after a keystroke before
+// searching, so fast typing doesn't run a search per key
+const searchDelay = 150 * time.Millisecond
+
+// searchDueMsg asks the search TUI to run the search for an edit, if no
+// later edit has superseded it
+type searchDueMsg struct{ id int }
+
+// searchDoneMsg carries the results of a search TUI search
+type searchDoneMsg struct {
+	id      int
+	results searchResults
+	err     error
+}
+
+// searchModel is the Bubble Tea model for the search TUI
+type searchModel struct {
+	s           *searcher
+	input       textinput.Model
+	searchID    int // Incremented on each edit so stale searches are ignored
+	query       string
+	results     searchResults
+	err         error
+	cursor      int // Index into the contacts followed by the conversations
+	viewportTop int
+	height      int
+	width       int
+
+	chosenContact      *contacts.Contact
+	chosenConversation *messages.ConversationMatch
+}
+
+func newSearchModel(s *searcher) searchModel {
+	input := textinput.New()
+	input.Prompt = "Search: "
+	input.Placeholder = "name, email, phone, or message text"
+	input.Focus()
+	return searchModel{s: s, input: input}
+}
+
+// runSearchTUI runs the search TUI, then opens whatever was chosen in the
+// contacts or messages TUI
+func runSearchTUI(cfg *config.Config, s *searcher) error {
+	// Logs go to the file only; writing to the terminal would corrupt the screen
+	logging.DisableConsole()
+	result, err := tea.NewProgram(newSearchModel(s), tea.WithAltScreen()).Run()
+	if err != nil {
+		return fmt.Errorf("TUI error: %w", err)
+	}
+	final := result.(searchModel)
+
+	switch {
+	case final.chosenContact != nil:
+		// The contacts TUI starts on the contact it was last left on
+		saveTUIState(cfg, func(st *config.TUIState) { st.ContactUID = final.chosenContact.UID })
+		_, err := contactsTUI(false, false)
+		return err
+	case final.chosenConversation != nil:
+		// Close the database first; the messages TUI opens its own
+		s.Close()
+		s.mm = nil
+		highlight := ""
+		if final.chosenConversation.Messages > 0 {
+			highlight = final.query
+		}
+		_, err := messagesTUI(false, false, final.chosenConversation.Conversation.ID, highlight)
+		return err
+	}
+	return nil
+}
+
+func (m searchModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// total returns how many results are listed
+func (m searchModel) total() int {
+	return len(m.results.contacts) + len(m.results.conversations)
+}
+
+func (m searchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = max(1, msg.Height-4) // Input, blank line, and footer
+		m.clampViewport()
+		return m, nil
+
+	case searchDueMsg:
+		if msg.id != m.searchID {
+			return m, nil
+		}
+		query := m.input.Value()
+		s := m.s
+		return m, func() tea.Msg {
+			results, err := s.search(query)
+			return searchDoneMsg{id: msg.id, results: results, err: err}
+		}
+
+	case searchDoneMsg:
+		if msg.id != m.searchID {
+			return m, nil
+		}
+		m.query = strings.TrimSpace(m.input.Value())
+		m.results = msg.results
+		m.err = msg.err
+		m.cursor = 0
+		m.viewportTop = 0
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			return m, tea.Quit
+
+		case "up", "ctrl+p", "shift+tab":
+			if m.cursor > 0 {
+				m.cursor--
+				m.clampViewport()
+			}
+			return m, nil
+
+		case "down", "ctrl+n", "tab":
+			if m.cursor < m.total()-1 {
+				m.cursor++
+				m.clampViewport()
+			}
+			return m, nil
+
+		case "enter":
+			if m.cursor < len(m.results.contacts) {
+				m.chosenContact = &m.results.contacts[m.cursor]
+				return m, tea.Quit
+			}
+			if i := m.cursor - len(m.results.contacts); i < len(m.results.conversations) {
+				m.chosenConversation = &m.results.conversations[i]
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+	}
+
+	before := m.input.Value()
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() == before {
+		return m, cmd
+	}
+	m.searchID++
+	id := m.searchID
+	return m, tea.Batch(cmd, tea.Tick(searchDelay, func(time.Time) tea.Msg { return searchDueMsg{id: id} }))
+}
+
+// clampViewport keeps the cursor in range and on screen. Section headers
+// take a line each, so the cursor is kept a couple of lines clear of the bottom.
+func (m *searchModel) clampViewport() {
+	m.cursor = max(0, min(m.cursor, m.total()-1))
+	visible := max(1, m.height-2)
+	if m.cursor < m.viewportTop {
+		m.viewportTop = m.cursor
+	}
+	if m.cursor >= m.viewportTop+visible {
+		m.viewportTop = m.cursor - visible + 1
+	}
+}
+
+func (m searchModel) View() string {
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+	normalStyle := lipgloss.NewStyle()
+	selectedStyle := lipgloss.NewStyle().Bold(true).Background(lipgloss.Color("240"))
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("243"))
+	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	var sb strings.Builder
+	sb.WriteString(m.input.View())
+	sb.WriteString("\n\n")
+
+	// Every result gets one line, and each section a header line
+	var lines []string
+	line := func(i int, text string) {
+		if i < m.viewportTop || len(lines) >= m.height {
+			return
+		}
+		style := normalStyle
+		if i == m.cursor {
+			style = selectedStyle
+		}
+		lines = append(lines, style.Render(util.Truncate(" "+text, max(1, m.width-1))))
+	}
+	header := func(text string) {
+		if len(lines) < m.height {
+			lines = append(lines, headerStyle.Render(text))
+		}
+	}
+
+	switch {
+	case m.err != nil:
+		lines = append(lines, fmt.Sprintf("Search failed: %v", m.err))
+	case m.query == "":
+		lines = append(lines, dimStyle.Render("Type to search contacts and conversations"))
+	case m.results.empty():
+		lines = append(lines, dimStyle.Render(fmt.Sprintf("Nothing matches %q", m.query)))
+	default:
+		if len(m.results.contacts) > 0 && m.viewportTop < len(m.results.contacts) {
+			header(fmt.Sprintf("Contacts (%d)", len(m.results.contacts)))
+		}
+		for i, contact := range m.results.contacts {
+			detail := contact.PrimaryEmail()
+			if detail == "" {
+				detail = contact.PrimaryPhone()
+			}
+			text := contact.DisplayName()
+			if detail != "" {
+				text += " · " + detail
+			}
+			line(i, text)
+		}
+		if len(m.results.conversations) > 0 {
+			header(fmt.Sprintf("Conversations (%d)", len(m.results.conversations)))
+		}
+		for i, match := range m.results.conversations {
+			text := fmt.Sprintf("%s (%s) — %s", match.Conversation.Title, match.Conversation.Platform, match.Reason())
+			if match.Latest != nil {
+				text += ": " + strings.Join(strings.Fields(match.Latest.Text), " ")
+			}
+			line(len(m.results.contacts)+i, text)
+		}
+	}
+	sb.WriteString(strings.Join(lines, "\n"))
+	sb.WriteString("\n")
+
+	sb.WriteString(footerStyle.Render("↑/↓: move • enter: open • esc: quit"))
+	return sb.String()
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/arjungandhi/dunbar/pkg/config"
+)
+
+// splitStep is how far '<' and '>' move the divider in the split views
+const splitStep = 0.05
+
+// minDetailWidth keeps the right pane of a split view readable when the
+// divider is moved right
+const minDetailWidth = 30
+
+// paneSeparatorWidth is the width of the " │ " drawn between the panes
+const paneSeparatorWidth = 3
+
+// leftPaneWidth returns the width of a split view's list pane for the given
+// terminal width and split ratio. The detail pane keeps at least
+// minDetailWidth columns when there's room, and the list pane never drops
+// below minLeft.
+func leftPaneWidth(width int, ratio float64, minLeft int) int {
+	left := int(float64(width) * config.ClampSplitRatio(ratio))
+	left = min(left, width-paneSeparatorWidth-minDetailWidth)
+	return max(minLeft, left)
+}
+
+// nudgeSplit moves the divider of a split view by delta and saves the new
+// ratio to the config so it's used next time. It returns the new ratio and
+// a status message.
+func nudgeSplit(cfg *config.Config, ratio, delta float64) (float64, string) {
+	ratio = config.ClampSplitRatio(config.ClampSplitRatio(ratio) + delta)
+	status := fmt.Sprintf("List pane: %d%%", int(ratio*100+0.5))
+	if cfg == nil {
+		return ratio, status
+	}
+	cfg.SplitRatio = ratio
+	if err := cfg.Save(); err != nil {
+		return ratio, fmt.Sprintf("Failed to save split: %v", err)
+	}
+	return ratio, status
+}
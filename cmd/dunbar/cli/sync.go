@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	Z "github.com/rwxrob/bonzai/z"
+
+	"github.com/arjungandhi/dunbar/pkg/config"
+	"github.com/arjungandhi/dunbar/pkg/messages"
+)
+
+var Sync = &Z.Cmd{
+	Name:    "sync",
+	Summary: "Sync contacts and messages in one go",
+	Usage:   "[--contacts-only | --messages-only] [--wait]",
+	Description: `
+Refresh everything: sync contacts from their provider, then messages from
+Beeper, and print a summary of both. The syncs run one after the other so
+neither provider sees the load of both at once. Suited to a cron job:
+the exit status is non-zero if any sync failed.
+
+Whichever of contacts and messages hasn't been set up with its init
+command is skipped with a note. --contacts-only and --messages-only sync
+just one, and fail if it isn't set up. --wait waits for Beeper Desktop to
+start, as for 'dunbar messages sync'.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		contactsOnly := hasFlag(args, "--contacts-only")
+		messagesOnly := hasFlag(args, "--messages-only")
+		if contactsOnly && messagesOnly {
+			return fmt.Errorf("--contacts-only and --messages-only can't be used together")
+		}
+
+		cfg := loadConfig()
+		var results []syncResult
+		if !messagesOnly {
+			results = append(results, runSyncStep("contacts", contactsOnly, cfg.ContactsProvider != "",
+				"run 'dunbar contacts init'", func() (string, error) { return syncAllContacts(cfg) }))
+		}
+		if !contactsOnly {
+			results = append(results, runSyncStep("messages", messagesOnly, messagesConfigured(cfg),
+				"run 'dunbar messages init'", func() (string, error) { return syncAllMessages(cfg, hasFlag(args, "--wait")) }))
+		}
+
+		fmt.Println("\nSummary:")
+		var errs []error
+		synced := 0
+		for _, r := range results {
+			switch {
+			case r.err != nil:
+				fmt.Printf("  %-9s failed: %v\n", r.name, r.err)
+				errs = append(errs, fmt.Errorf("%s: %w", r.name, r.err))
+			case r.skipped != "":
+				fmt.Printf("  %-9s skipped: %s\n", r.name, r.skipped)
+			default:
+				fmt.Printf("  %-9s %s (%s)\n", r.name, r.summary, r.took.Round(100*time.Millisecond))
+				synced++
+			}
+		}
+
+		if len(errs) > 0 {
+			return fmt.Errorf("%d of %d syncs failed:\n%w", len(errs), len(results), errors.Join(errs...))
+		}
+		if synced == 0 {
+			return fmt.Errorf("nothing to sync. Run 'dunbar contacts init' or 'dunbar messages init' first")
+		}
+		return nil
+	},
+}
+
+// syncResult is the outcome of one part of 'dunbar sync'
+type syncResult struct {
+	name    string
+	summary string // What was synced, e.g. "523 contacts"
+	skipped string // Why the sync didn't run, if it didn't
+	err     error
+	took    time.Duration
+}
+
+// runSyncStep runs one part of 'dunbar sync'. A part that isn't configured
+// is skipped, unless it was asked for on its own, which is an error. Once
+// ctrl+c or --timeout has stopped an earlier part, later ones are skipped.
+func runSyncStep(name string, required, configured bool, setup string, sync func() (string, error)) syncResult {
+	r := syncResult{name: name}
+	switch {
+	case !configured && required:
+		r.err = fmt.Errorf("%s aren't set up; %s first", name, setup)
+		return r
+	case !configured:
+		r.skipped = fmt.Sprintf("not set up (%s to enable)", setup)
+		return r
+	case cmdCtx.Err() != nil:
+		r.skipped = "stopped before it started"
+		return r
+	}
+
+	fmt.Printf("==> Syncing %s\n", name)
+	start := time.Now()
+	r.summary, r.err = sync()
+	r.took = time.Since(start)
+	return r
+}
+
+// syncAllContacts syncs contacts and describes the result
+func syncAllContacts(cfg *config.Config) (string, error) {
+	cm, err := getContactManager(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	cm.SetSyncProgress(func(fetched, total int) {
+		fmt.Printf("\r\033[KFetched %d/%d contacts...", fetched, total)
+	})
+	err = cm.SyncContacts(cmdCtx)
+	fmt.Println() // New line after progress
+	if err != nil {
+		if cmdCtx.Err() != nil {
+			return "", fmt.Errorf("stopped early; the contacts fetched so far were saved: %w", err)
+		}
+		return "", explainContactsError(err)
+	}
+
+	contactsList, err := cm.ListContacts()
+	if err != nil {
+		return "", fmt.Errorf("failed to list contacts: %w", err)
+	}
+	summary := plural(len(contactsList), "contact")
+	if skipped := cm.SkippedEmpty(); skipped > 0 {
+		summary += fmt.Sprintf(", skipped %s", emptyEntries(skipped))
+	}
+	if edits := len(cm.LocalEdits()); edits > 0 {
+		summary += fmt.Sprintf(", kept local edits to %s (see 'dunbar contacts sync')", plural(edits, "contact"))
+	}
+	return summary, nil
+}
+
+// syncAllMessages syncs messages and describes the result
+func syncAllMessages(cfg *config.Config, wait bool) (string, error) {
+	mm, err := getMessageManager(cfg)
+	if err != nil {
+		return "", err
+	}
+	defer mm.Close()
+
+	if wait {
+		if err := messages.WaitForBeeper(cmdCtx, os.Stdout); err != nil {
+			return "", err
+		}
+	}
+	if err := mm.Sync(cmdCtx); err != nil {
+		return "", err
+	}
+
+	stats, err := mm.Stats(0)
+	if err != nil {
+		return "", fmt.Errorf("failed to count messages: %w", err)
+	}
+	return fmt.Sprintf("%s, %s", plural(stats.Conversations, "conversation"), plural(stats.Messages, "message")), nil
+}
+
+// messagesConfigured reports whether messages have been set up: a provider
+// was chosen, a Beeper token was saved, or one is in the environment
+func messagesConfigured(cfg *config.Config) bool {
+	if cfg.MessagesProvider != "" || strings.TrimSpace(os.Getenv(messages.AccessTokenEnv)) != "" {
+		return true
+	}
+	provider, err := messages.NewBeeperProvider(cfg.DunbarDir)
+	if err != nil {
+		return false
+	}
+	creds, err := provider.LoadCredentials()
+	return err == nil && creds != nil && creds.AccessToken != ""
+}
@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/arjungandhi/dunbar/pkg/contacts"
+	"github.com/arjungandhi/dunbar/pkg/logging"
+	"github.com/arjungandhi/dunbar/pkg/messages"
+	"github.com/arjungandhi/dunbar/pkg/util"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	Z "github.com/rwxrob/bonzai/z"
+)
+
+var ContactsTimeline = &Z.Cmd{
+	Name:    "timeline",
+	Summary: "Browse every conversation with a contact as one timeline",
+	Usage:   "[uid|name]",
+	MaxArgs: 1,
+	Description: `
+Open a scrollable timeline of the messages exchanged with a contact across
+all platforms, oldest first. Direct conversations are linked to the contact
+by phone number or email address, and each run of messages is marked with
+its platform.
+`,
+	Call: func(x *Z.Cmd, args ...string) error {
+		cfg := loadConfig()
+		cm, err := getContactManager(cfg)
+		if err != nil {
+			return err
+		}
+
+		query := ""
+		if len(args) > 0 {
+			query = args[0]
+		}
+		uid, err := resolveContactUID(cm, query)
+		if err != nil {
+			return err
+		}
+		contact, err := cm.GetContact(uid)
+		if err != nil {
+			return fmt.Errorf("failed to read contact: %w", err)
+		}
+		if contact == nil {
+			return fmt.Errorf("%w: %s", contacts.ErrNotFound, uid)
+		}
+
+		mm, err := newMessageManager(cfg, io.Discard)
+		if err != nil {
+			return err
+		}
+		defer mm.Close()
+
+		timeline, err := mm.GetUnifiedTimelineForContact(*contact)
+		if err != nil {
+			return fmt.Errorf("failed to load timeline: %w", err)
+		}
+		if len(timeline) == 0 {
+			return fmt.Errorf("no messages found with %s", contact.DisplayName())
+		}
+
+		// Name the contact's messages after the contact on every platform
+		for i := range timeline {
+			if !timeline[i].IsSent {
+				timeline[i].SenderName = contact.DisplayName()
+			}
+		}
+		slices.Reverse(timeline)
+
+		logging.DisableConsole()
+		p := tea.NewProgram(newTimelineModel(*contact, timeline, cfg.DisplayTimeFormat()), tea.WithAltScreen())
+		if _, err := p.Run(); err != nil {
+			return fmt.Errorf("TUI error: %w", err)
+		}
+		return nil
+	},
+}
+
+// timelineModel shows a contact's messages from all platforms, oldest first.
+// The timeline is rendered once per width and scrolled by line.
+type timelineModel struct {
+	contact    contacts.Contact
+	msgs       []messages.Message
+	timeFormat util.TimeFormat
+	lines      []string // Rendered timeline
+	top        int      // First visible line
+	height     int
+	width      int
+	showHelp   bool // True while the '?' help overlay is open
+}
+
+func newTimelineModel(contact contacts.Contact, msgs []messages.Message, tf util.TimeFormat) timelineModel {
+	m := timelineModel{
+		contact:    contact,
+		msgs:       msgs,
+		timeFormat: tf,
+		height:     25,
+		width:      80,
+	}
+	m.render()
+	m.top = m.maxTop() // Start at the most recent messages
+	return m
+}
+
+// render lays out the timeline for the current width
+func (m *timelineModel) render() {
+	m.lines = strings.Split(strings.TrimRight(renderTimeline(m.msgs, max(20, m.width-4), m.timeFormat), "\n"), "\n")
+}
+
+// visibleLines is the number of timeline lines that fit between header and footer
+func (m timelineModel) visibleLines() int {
+	return max(1, m.height-3)
+}
+
+func (m timelineModel) maxTop() int {
+	return max(0, len(m.lines)-m.visibleLines())
+}
+
+func (m timelineModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m timelineModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		atBottom := m.top >= m.maxTop()
+		m.height = msg.Height
+		if msg.Width != m.width {
+			m.width = msg.Width
+			m.render()
+		}
+		if atBottom {
+			m.top = m.maxTop()
+		}
+		m.top = min(m.top, m.maxTop())
+
+	case tea.KeyMsg:
+		// Keys close the help overlay rather than scrolling behind it
+		if m.showHelp {
+			switch msg.String() {
+			case "?", "esc", "q":
+				m.showHelp = false
+			case "ctrl+c":
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case "?":
+			m.showHelp = true
+		case "up", "k":
+			m.top = max(0, m.top-1)
+		case "down", "j":
+			m.top = min(m.maxTop(), m.top+1)
+		case "pgup":
+			m.top = max(0, m.top-m.visibleLines())
+		case "pgdown":
+			m.top = min(m.maxTop(), m.top+m.visibleLines())
+		case "g", "home":
+			m.top = 0
+		case "G", "end":
+			m.top = m.maxTop()
+		}
+	}
+
+	return m, nil
+}
+
+func (m timelineModel) View() string {
+	if m.showHelp {
+		return renderKeyHelp("Timeline keys", timelineKeyHelp, m.width, m.height)
+	}
+
+	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("39"))
+	footerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	var sb strings.Builder
+	sb.WriteString(headerStyle.Render(fmt.Sprintf("🕑 %s (%d messages)", m.contact.DisplayName(), len(m.msgs))))
+	sb.WriteString("\n\n")
+
+	end := min(m.top+m.visibleLines(), len(m.lines))
+	for _, line := range m.lines[m.top:end] {
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	for i := end - m.top; i < m.visibleLines(); i++ {
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(footerStyle.Render("j/k: down/up • pgup/pgdn: page up/down • g/G: top/bottom • ?: help • q: quit"))
+	return sb.String()
+}
+
+// renderTimeline renders messages from several conversations, oldest first,
+// with date separators and a platform badge whenever the conversation changes
+func renderTimeline(msgs []messages.Message, width int, tf util.TimeFormat) string {
+	badgeStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("170"))
+
+	var sb strings.Builder
+	var prevMsg *messages.Message
+	for _, item := range insertDateSeparators(msgs, tf) {
+		if item.isSeparator() {
+			sb.WriteString(renderDateSeparator(*item.dateSeparator, width))
+			prevMsg = nil // Reset grouping after date separator
+			continue
+		}
+
+		msg := item.message
+		if prevMsg == nil || msg.ConversationUID != prevMsg.ConversationUID {
+			if prevMsg != nil {
+				sb.WriteString("\n")
+				prevMsg = nil // The badge starts a new group
+			}
+			sb.WriteString(badgeStyle.Render(getPlatformIcon(msg.Platform) + " " + msg.Platform))
+			sb.WriteString("\n")
+		}
+		sb.WriteString(formatMessage(*msg, width, prevMsg, tf, ""))
+		prevMsg = msg
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/arjungandhi/dunbar/pkg/config"
+)
+
+// loadTUIState returns where the TUIs were left last time, or an empty
+// state if reset is set ('--reset') or the state can't be read
+func loadTUIState(cfg *config.Config, reset bool) config.TUIState {
+	if reset {
+		return config.TUIState{}
+	}
+	state, err := cfg.LoadTUIState()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	return state
+}
+
+// saveTUIState applies update to the saved TUI state and writes it back,
+// keeping what the other TUI saved. It runs after a TUI quits, so failures
+// are only warned about.
+func saveTUIState(cfg *config.Config, update func(*config.TUIState)) {
+	state, err := cfg.LoadTUIState()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	update(&state)
+	if err := cfg.SaveTUIState(state); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+}
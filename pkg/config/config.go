@@ -1,45 +1,395 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/arjungandhi/dunbar/pkg/util"
 )
 
 // Config holds the configuration for the dunbar CLI
 type Config struct {
-	DunbarDir string
+	// DunbarDir holds configuration and credentials
+	DunbarDir string `json:"-"`
+
+	// DataDir holds the larger synced data: messages.db and contact files.
+	// It's the same as DunbarDir when DUNBAR_DIR is set or for installs
+	// that predate the split.
+	DataDir string `json:"-"`
+
+	// SavedDataDir records DataDir when the config is first saved, so data
+	// stays where it was put even if what the default is worked out from
+	// changes later. DUNBAR_DIR takes precedence over it.
+	SavedDataDir string `json:"data_dir,omitempty"`
+
+	// ContactsProvider is the contacts provider chosen during 'dunbar contacts
+	// init'. Once set, init goes straight to that provider's setup.
+	ContactsProvider string `json:"provider,omitempty"`
+
+	// MessagesProvider is the messages provider chosen during 'dunbar
+	// messages init'. Once set, init goes straight to that provider's setup.
+	MessagesProvider string `json:"messages_provider,omitempty"`
+
+	// GooglePersonFields lists the People API person fields requested when
+	// syncing Google contacts. Empty means the provider's default set. Fields
+	// left out keep their local values and aren't sent when editing.
+	GooglePersonFields []string `json:"google_person_fields,omitempty"`
+
+	// GooglePageSize is how many contacts are requested per People API page,
+	// up to 1000. Zero means the provider's default.
+	GooglePageSize int `json:"google_page_size,omitempty"`
+
+	// GoogleMaxContacts stops a Google sync after this many contacts. Zero
+	// means no limit.
+	GoogleMaxContacts int `json:"google_max_contacts,omitempty"`
+
+	// ContactsStore selects how contacts are stored locally: "files" (one
+	// JSON file per contact, the default) or "json" (a single JSON index)
+	ContactsStore string `json:"contacts_store,omitempty"`
+
+	// ContactsCompactJSON writes stored contacts as compact rather than
+	// indented JSON, which is smaller and quicker to read for large address
+	// books. Contacts already stored keep their format until next written.
+	ContactsCompactJSON bool `json:"contacts_compact_json,omitempty"`
+
+	// MessagesConcurrency is how many chats are fetched at once when syncing
+	// messages. Zero means the provider's default.
+	MessagesConcurrency int `json:"messages_concurrency,omitempty"`
+
+	// MessagesAccounts limits message syncs to these Beeper account IDs.
+	// Empty means every account.
+	MessagesAccounts []string `json:"messages_accounts,omitempty"`
+
+	// MessagesNetworks limits message syncs to chats on these networks, e.g.
+	// ["whatsapp", "telegram"]. Empty means every network.
+	MessagesNetworks []string `json:"messages_networks,omitempty"`
+
+	// Clock24Hour shows times as "15:04" instead of "3:04 PM"
+	Clock24Hour bool `json:"clock_24_hour,omitempty"`
+
+	// DateDayFirst shows dates day first, e.g. "2 Jan" instead of "Jan 2"
+	DateDayFirst bool `json:"date_day_first,omitempty"`
+
+	// TimeZone is the zone timestamps are shown in: "Local" (the default),
+	// "UTC", or an IANA name such as "Europe/Berlin"
+	TimeZone string `json:"time_zone,omitempty"`
+
+	// SplitRatio is the share of the terminal width given to the list pane
+	// in the contacts and messages split views, e.g. 0.4. Zero means the
+	// default.
+	SplitRatio float64 `json:"split_ratio,omitempty"`
+
+	// ContactColumn is what the contacts TUI lists next to each name:
+	// "company", "last_contacted", or "phone". Empty (or "name") lists
+	// names only.
+	ContactColumn string `json:"contact_column,omitempty"`
+
+	// DeleteConfirm is how the TUIs confirm deleting contacts and
+	// conversations: "key" (a single key press, the default), "name" (type
+	// the name of what's being deleted), or "none" (delete right away)
+	DeleteConfirm string `json:"delete_confirm,omitempty"`
+
+	// PreviewMaxChars is how wide each message in the messages TUI's
+	// preview pane may get, in terminal columns, before it's cut short.
+	// Zero means DefaultPreviewMaxChars.
+	PreviewMaxChars int `json:"preview_max_chars,omitempty"`
+
+	// PhoneTypeOrder lists the phone types to show and call first, most
+	// preferred first, e.g. ["work", "mobile"]. Empty means mobile first.
+	PhoneTypeOrder []string `json:"phone_type_order,omitempty"`
+
+	// EmailTypeOrder lists the email types to show and copy first, e.g.
+	// ["personal", "work"]. Empty means the first address listed.
+	EmailTypeOrder []string `json:"email_type_order,omitempty"`
+
+	// PreviewMaxMessages caps how many messages the preview pane shows, even
+	// when more would fit. Zero means as many as fit.
+	PreviewMaxMessages int `json:"preview_max_messages,omitempty"`
 }
 
-// New creates a new Config instance with defaults
+// DefaultPreviewMaxChars is how wide a preview pane message gets by default
+const DefaultPreviewMaxChars = 200
+
+// Values for ContactColumn
+const (
+	ContactColumnName          = "name"
+	ContactColumnCompany       = "company"
+	ContactColumnLastContacted = "last_contacted"
+	ContactColumnPhone         = "phone"
+)
+
+// Values for DeleteConfirm
+const (
+	DeleteConfirmKey  = "key"
+	DeleteConfirmName = "name"
+	DeleteConfirmNone = "none"
+)
+
+// Bounds for SplitRatio, so neither pane of a split view collapses
+const (
+	DefaultSplitRatio = 0.4
+	MinSplitRatio     = 0.2
+	MaxSplitRatio     = 0.8
+)
+
+// New creates a new Config instance with defaults, overridden by the
+// DUNBAR_DIR environment variable and the settings in config.json.
+//
+// By default configuration lives in $XDG_CONFIG_HOME/dunbar and data in
+// $XDG_DATA_HOME/dunbar (falling back to ~/.config and ~/.local/share).
+// DUNBAR_DIR puts both in one directory.
+//
+// A config file that can't be read is ignored in favor of defaults; use
+// Open to handle the error instead.
 func New() *Config {
-	cfg := &Config{
-		DunbarDir: getDefaultDunbarDir(),
+	return NewIn("")
+}
+
+// NewIn is New with dir, if not empty, used in place of DUNBAR_DIR (e.g.
+// from the --dir flag)
+func NewIn(dir string) *Config {
+	cfg, _ := OpenIn(dir)
+	return cfg
+}
+
+// Open works out the config and data directories as New does and loads the
+// saved settings. If the config file can't be read or has an invalid
+// setting, the error is returned along with a config that's still usable,
+// falling back to defaults.
+func Open() (*Config, error) {
+	return OpenIn("")
+}
+
+// OpenIn is Open with dir, if not empty, used in place of DUNBAR_DIR
+func OpenIn(dir string) (*Config, error) {
+	cfg := &Config{}
+
+	if dir == "" {
+		dir = os.Getenv("DUNBAR_DIR")
+	}
+	if dir != "" {
+		cfg.DunbarDir = dir
+		cfg.DataDir = dir
+	} else {
+		cfg.DunbarDir = getDefaultDunbarDir()
+		cfg.DataDir = getDefaultDataDir(cfg.DunbarDir)
 	}
 
-	// Override with environment variable if set
-	if envDir := os.Getenv("DUNBAR_DIR"); envDir != "" {
-		cfg.DunbarDir = envDir
+	if err := cfg.Load(); err != nil {
+		return cfg, err
+	}
+	if dir == "" && cfg.SavedDataDir != "" {
+		cfg.DataDir = cfg.SavedDataDir
+	}
+	if cfg.TimeZone != "" {
+		if _, err := time.LoadLocation(cfg.TimeZone); err != nil {
+			return cfg, fmt.Errorf("unknown time zone %q, using local time", cfg.TimeZone)
+		}
+	}
+	switch cfg.ContactColumn {
+	case "", ContactColumnName, ContactColumnCompany, ContactColumnLastContacted, ContactColumnPhone:
+	default:
+		err := fmt.Errorf("unknown contact_column %q (use company, last_contacted, or phone), listing names only", cfg.ContactColumn)
+		cfg.ContactColumn = ""
+		return cfg, err
+	}
+	switch cfg.DeleteConfirm {
+	case "", DeleteConfirmKey, DeleteConfirmName, DeleteConfirmNone:
+	default:
+		err := fmt.Errorf("unknown delete_confirm %q (use key, name, or none), confirming with a key press", cfg.DeleteConfirm)
+		cfg.DeleteConfirm = ""
+		return cfg, err
+	}
+	if cfg.PreviewMaxChars < 0 || cfg.PreviewMaxMessages < 0 {
+		err := fmt.Errorf("preview_max_chars and preview_max_messages can't be negative, using the defaults")
+		cfg.PreviewMaxChars = max(cfg.PreviewMaxChars, 0)
+		cfg.PreviewMaxMessages = max(cfg.PreviewMaxMessages, 0)
+		return cfg, err
 	}
 
-	return cfg
+	return cfg, nil
 }
 
-// getDefaultDunbarDir returns the default directory for dunbar data
+// getDefaultDunbarDir returns the default directory for dunbar
+// configuration. Installs from before XDG_CONFIG_HOME was followed keep
+// using ~/.config/dunbar until the new directory is set up.
 func getDefaultDunbarDir() string {
 	home, err := os.UserHomeDir()
 	if err != nil {
+		home = ""
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		dir := filepath.Join(xdg, "dunbar")
+		if _, err := os.Stat(dir); err != nil && home != "" {
+			if legacy := filepath.Join(home, ".config", "dunbar"); legacy != dir && hasData(legacy) {
+				return legacy
+			}
+		}
+		return dir
+	}
+	if home == "" {
 		return ".dunbar"
 	}
 	return filepath.Join(home, ".config", "dunbar")
 }
 
-// SetDunbarDir sets the dunbar directory and creates it if it doesn't exist
+// getDefaultDataDir returns the default directory for dunbar data. Installs
+// from before data moved out of the config directory keep using it, so
+// existing messages and contacts are still found.
+func getDefaultDataDir(configDir string) string {
+	if hasData(configDir) {
+		return configDir
+	}
+
+	if xdg := os.Getenv("XDG_DATA_HOME"); xdg != "" {
+		return filepath.Join(xdg, "dunbar")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return configDir
+	}
+	return filepath.Join(home, ".local", "share", "dunbar")
+}
+
+// hasData reports whether dir contains synced messages or contacts. Other
+// files, such as provider credentials, don't count: they're kept in the
+// config directory either way.
+func hasData(dir string) bool {
+	for _, name := range []string{"messages.db", filepath.Join("contacts", "people.json")} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	// Contacts stored a file each, now under people/ and before that
+	// directly in contacts/
+	for _, sub := range []string{filepath.Join("contacts", "people"), "contacts"} {
+		entries, _ := os.ReadDir(filepath.Join(dir, sub))
+		for _, entry := range entries {
+			name := entry.Name()
+			if !entry.IsDir() && strings.HasSuffix(name, ".json") && !strings.HasPrefix(name, "google_") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Path returns the path of the config file
+func (c *Config) Path() string {
+	return filepath.Join(c.DunbarDir, "config.json")
+}
+
+// Load reads settings from the config file, if it exists
+func (c *Config) Load() error {
+	data, err := os.ReadFile(c.Path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err := json.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("failed to parse config %s: %w", c.Path(), err)
+	}
+
+	return nil
+}
+
+// Save writes the current settings to the config file, recording the data
+// directory the first time
+func (c *Config) Save() error {
+	if err := c.EnsureDunbarDir(); err != nil {
+		return err
+	}
+	if c.SavedDataDir == "" {
+		c.SavedDataDir = c.DataDir
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := os.WriteFile(c.Path(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+
+	return nil
+}
+
+// SetDunbarDir sets the dunbar directory, used for both config and data, and
+// creates it if it doesn't exist
 func (c *Config) SetDunbarDir(dir string) error {
 	c.DunbarDir = dir
+	c.DataDir = dir
 	return os.MkdirAll(dir, 0755)
 }
 
-// EnsureDunbarDir creates the dunbar directory if it doesn't exist
+// EnsureDunbarDir creates the config and data directories if they don't
+// exist and checks that files can be written to them, so commands fail
+// before prompting for anything they can't save
 func (c *Config) EnsureDunbarDir() error {
-	return os.MkdirAll(c.DunbarDir, 0755)
+	dirs := []string{c.DunbarDir}
+	if c.DataDir != "" && c.DataDir != c.DunbarDir {
+		dirs = append(dirs, c.DataDir)
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s (check its parent's permissions or DUNBAR_DIR): %w", dir, err)
+		}
+		if err := checkWritable(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkWritable returns an error if a file can't be created in dir
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".dunbar-write-check-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable (check its permissions or DUNBAR_DIR): %w", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// DisplayTimeFormat returns the time format settings used when showing
+// timestamps. An unknown time zone falls back to local time.
+func (c *Config) DisplayTimeFormat() util.TimeFormat {
+	f := util.TimeFormat{Hour24: c.Clock24Hour, DayFirst: c.DateDayFirst}
+	if c.TimeZone != "" {
+		// Open reports an unknown time zone, so it isn't repeated here
+		if loc, err := time.LoadLocation(c.TimeZone); err == nil {
+			f.Location = loc
+		}
+	}
+	return f
+}
+
+// PaneSplit returns the list pane's share of the width in the split views
+func (c *Config) PaneSplit() float64 {
+	return ClampSplitRatio(c.SplitRatio)
+}
+
+// PreviewChars returns how wide a preview pane message may get
+func (c *Config) PreviewChars() int {
+	if c.PreviewMaxChars == 0 {
+		return DefaultPreviewMaxChars
+	}
+	return c.PreviewMaxChars
+}
+
+// ClampSplitRatio limits a split ratio to the supported range. Zero means
+// the default ratio.
+func ClampSplitRatio(r float64) float64 {
+	if r == 0 {
+		return DefaultSplitRatio
+	}
+	return min(max(r, MinSplitRatio), MaxSplitRatio)
 }
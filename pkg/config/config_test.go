@@ -0,0 +1,168 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnsureDunbarDirRejectsUnwritableDirs(t *testing.T) {
+	tests := []struct {
+		name      string
+		needsPerm bool // Relies on file permissions, which root ignores
+		dir       func(t *testing.T) string
+	}{
+		{"read-only dir", true, func(t *testing.T) string {
+			return readOnlyDir(t)
+		}},
+		{"inside a read-only dir", true, func(t *testing.T) string {
+			return filepath.Join(readOnlyDir(t), "dunbar")
+		}},
+		{"under a file", false, func(t *testing.T) string {
+			file := filepath.Join(t.TempDir(), "file")
+			if err := os.WriteFile(file, nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+			return filepath.Join(file, "dunbar")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.needsPerm && os.Geteuid() == 0 {
+				t.Skip("root can write to read-only directories")
+			}
+			dir := tt.dir(t)
+			c := &Config{DunbarDir: dir, DataDir: dir}
+
+			err := c.EnsureDunbarDir()
+			if err == nil {
+				t.Fatalf("EnsureDunbarDir() = nil, want an error for %s", dir)
+			}
+			if !strings.Contains(err.Error(), dir) || !strings.Contains(err.Error(), "DUNBAR_DIR") {
+				t.Errorf("EnsureDunbarDir() = %q, want it to name %s and DUNBAR_DIR", err, dir)
+			}
+		})
+	}
+}
+
+func TestEnsureDunbarDirCreatesDirs(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "dunbar")
+	c := &Config{DunbarDir: dir, DataDir: filepath.Join(dir, "data")}
+	if err := c.EnsureDunbarDir(); err != nil {
+		t.Fatalf("EnsureDunbarDir() = %v", err)
+	}
+	if info, err := os.Stat(c.DunbarDir); err != nil || !info.IsDir() {
+		t.Errorf("Stat(%s) = %v, want a directory", c.DunbarDir, err)
+	}
+	// The write check cleans up after itself
+	entries, err := os.ReadDir(c.DataDir)
+	if err != nil {
+		t.Fatalf("ReadDir(%s) = %v", c.DataDir, err)
+	}
+	if len(entries) > 0 {
+		t.Errorf("%s has %d entries left by the write check, want none", c.DataDir, len(entries))
+	}
+}
+
+// readOnlyDir returns a temporary directory with mode 0555, made writable
+// again afterwards so it can be removed
+func readOnlyDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.Chmod(dir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(dir, 0755) })
+	return dir
+}
+
+// setHome points the default directories at a temporary home directory
+func setHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("DUNBAR_DIR", "")
+	return home
+}
+
+func TestOpenFindsLegacyData(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      []string // Created under ~/.config/dunbar
+		xdgConfig  bool     // Whether XDG_CONFIG_HOME is set
+		wantLegacy bool     // Whether data stays in ~/.config/dunbar
+	}{
+		{"fresh install", nil, false, false},
+		{"credentials only", []string{"google_creds.json", "contacts/google_sync_token.txt", "contacts/people/"}, false, false},
+		{"messages", []string{"messages.db"}, false, true},
+		{"contact files", []string{"contacts/people/c1.json"}, false, true},
+		{"contact files before people/", []string{"contacts/c1.json"}, false, true},
+		{"contact index", []string{"contacts/people.json"}, false, true},
+		{"messages with XDG_CONFIG_HOME", []string{"messages.db"}, true, true},
+		{"fresh install with XDG_CONFIG_HOME", nil, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := setHome(t)
+			legacy := filepath.Join(home, ".config", "dunbar")
+			for _, file := range tt.files {
+				path := filepath.Join(legacy, file)
+				if strings.HasSuffix(file, "/") {
+					os.MkdirAll(path, 0755)
+					continue
+				}
+				os.MkdirAll(filepath.Dir(path), 0755)
+				if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.xdgConfig {
+				t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg"))
+			}
+
+			cfg, err := Open()
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			if got := cfg.DataDir == legacy; got != tt.wantLegacy {
+				t.Errorf("DataDir = %s, want legacy dir %v", cfg.DataDir, tt.wantLegacy)
+			}
+			if tt.wantLegacy && cfg.DunbarDir != legacy {
+				t.Errorf("DunbarDir = %s, want %s", cfg.DunbarDir, legacy)
+			}
+		})
+	}
+}
+
+func TestOpenUsesSavedDataDir(t *testing.T) {
+	home := setHome(t)
+	cfg, err := Open()
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	want := cfg.DataDir
+
+	// Data left in the config directory no longer moves the data directory
+	os.WriteFile(filepath.Join(cfg.DunbarDir, "messages.db"), nil, 0644)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "elsewhere"))
+
+	cfg, err = Open()
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if cfg.DataDir != want {
+		t.Errorf("DataDir = %s, want the saved %s", cfg.DataDir, want)
+	}
+
+	// DUNBAR_DIR still wins
+	t.Setenv("DUNBAR_DIR", cfg.DunbarDir)
+	if cfg, _ = Open(); cfg.DataDir != cfg.DunbarDir {
+		t.Errorf("DataDir = %s with DUNBAR_DIR set, want %s", cfg.DataDir, cfg.DunbarDir)
+	}
+}
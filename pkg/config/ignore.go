@@ -0,0 +1,91 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// IgnoreList names the conversations and contacts kept out of dunbar, such
+// as spam numbers and automated senders. Syncs skip them, so they stay out
+// across re-syncs until they're unignored.
+type IgnoreList struct {
+	Conversations []IgnoredItem `json:"conversations,omitempty"`
+	Contacts      []IgnoredItem `json:"contacts,omitempty"`
+}
+
+// IgnoredItem is an ignored conversation or contact
+type IgnoredItem struct {
+	ID   string `json:"id"`             // Conversation ID or contact UID
+	Name string `json:"name,omitempty"` // Title or name when it was ignored, for listing
+}
+
+// IgnorePath returns the path of the ignore list file
+func (c *Config) IgnorePath() string {
+	return filepath.Join(c.DunbarDir, "ignore.json")
+}
+
+// LoadIgnoreList reads the ignore list. A missing file gives an empty list.
+func (c *Config) LoadIgnoreList() (IgnoreList, error) {
+	var list IgnoreList
+	data, err := os.ReadFile(c.IgnorePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return list, nil
+		}
+		return list, fmt.Errorf("failed to read ignore list: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &list); err != nil {
+		return IgnoreList{}, fmt.Errorf("failed to parse ignore list %s: %w", c.IgnorePath(), err)
+	}
+
+	return list, nil
+}
+
+// SaveIgnoreList writes the ignore list file
+func (c *Config) SaveIgnoreList(list IgnoreList) error {
+	if err := c.EnsureDunbarDir(); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal ignore list: %w", err)
+	}
+
+	if err := os.WriteFile(c.IgnorePath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write ignore list: %w", err)
+	}
+
+	return nil
+}
+
+// IgnoredIDs returns the IDs of the items as a set
+func IgnoredIDs(items []IgnoredItem) map[string]bool {
+	ids := make(map[string]bool, len(items))
+	for _, item := range items {
+		ids[item.ID] = true
+	}
+	return ids
+}
+
+// AddIgnored adds an item to a list unless its ID is already there, and
+// reports whether it was added
+func AddIgnored(items *[]IgnoredItem, item IgnoredItem) bool {
+	if slices.ContainsFunc(*items, func(i IgnoredItem) bool { return i.ID == item.ID }) {
+		return false
+	}
+	*items = append(*items, item)
+	return true
+}
+
+// RemoveIgnored removes the item with the given ID from a list, and reports
+// whether it was there
+func RemoveIgnored(items *[]IgnoredItem, id string) bool {
+	n := len(*items)
+	*items = slices.DeleteFunc(*items, func(i IgnoredItem) bool { return i.ID == id })
+	return len(*items) < n
+}
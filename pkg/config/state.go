@@ -0,0 +1,71 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// TUIState remembers where the contacts and messages TUIs were left, so
+// they reopen on the same item with the same sort and filter
+type TUIState struct {
+	// ContactUID is the contact under the cursor when the contacts TUI quit
+	ContactUID string `json:"contact_uid,omitempty"`
+
+	// ContactSort and ContactFilter are how the contacts TUI list was sorted
+	// ('o') and filtered ('/') when it quit
+	ContactSort   string `json:"contact_sort,omitempty"`
+	ContactFilter string `json:"contact_filter,omitempty"`
+
+	// ConversationID is the conversation under the cursor when the messages
+	// TUI quit
+	ConversationID string `json:"conversation_id,omitempty"`
+
+	// ContactPhones and ContactEmails map contact UIDs to the phone number
+	// and email address last chosen for them, preselected next time
+	ContactPhones map[string]string `json:"contact_phones,omitempty"`
+	ContactEmails map[string]string `json:"contact_emails,omitempty"`
+}
+
+// StatePath returns the path of the TUI state file
+func (c *Config) StatePath() string {
+	return filepath.Join(c.DunbarDir, "state.json")
+}
+
+// LoadTUIState reads the saved TUI state. A missing state file gives an
+// empty state.
+func (c *Config) LoadTUIState() (TUIState, error) {
+	var state TUIState
+	data, err := os.ReadFile(c.StatePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return state, fmt.Errorf("failed to read TUI state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return TUIState{}, fmt.Errorf("failed to parse TUI state %s: %w", c.StatePath(), err)
+	}
+
+	return state, nil
+}
+
+// SaveTUIState writes the TUI state file
+func (c *Config) SaveTUIState(state TUIState) error {
+	if err := c.EnsureDunbarDir(); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal TUI state: %w", err)
+	}
+
+	if err := os.WriteFile(c.StatePath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write TUI state: %w", err)
+	}
+
+	return nil
+}
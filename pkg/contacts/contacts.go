@@ -1,10 +1,14 @@
 package contacts
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -41,6 +45,9 @@ type Organization struct {
 	Department string `json:"department,omitempty"`
 }
 
+// SourceGoogle marks contacts that came from Google Contacts
+const SourceGoogle = "google"
+
 // Contact represents a person in the contact database
 type Contact struct {
 	// CardDAV sync fields
@@ -48,6 +55,10 @@ type Contact struct {
 	ETag string `json:"etag"`  // ETag for sync tracking
 	URL  string `json:"url"`   // CardDAV resource URL
 
+	// Provenance
+	Source     string `json:"source,omitempty"`      // Provider the contact came from (e.g. SourceGoogle); empty for local-only contacts
+	ProviderID string `json:"provider_id,omitempty"` // The contact's ID at its provider
+
 	// Name information
 	GivenName  string `json:"given_name,omitempty"`  // First name
 	FamilyName string `json:"family_name,omitempty"` // Last name
@@ -68,118 +79,322 @@ type Contact struct {
 	PhotoURL     string     `json:"photo_url,omitempty"`
 	PhotoData    []byte     `json:"photo_data,omitempty"` // Base64 encoded photo
 
+	// OtherEvents are the provider's dated events besides the anniversary,
+	// kept so writes, which replace every event, send them back
+	OtherEvents []ContactEvent `json:"other_events,omitempty"`
+
 	// Metadata
-	Tags  []string `json:"tags,omitempty"`  // Custom tags for organizing contacts
-	Notes string   `json:"notes,omitempty"` // Freeform notes about the contact
+	Tags       []string `json:"tags,omitempty"`        // Custom tags for organizing contacts
+	Groups     []string `json:"groups,omitempty"`      // Provider contact groups (Google labels) it belongs to; edited at the provider
+	Notes      string   `json:"notes,omitempty"`       // Freeform notes about the contact
+	IsFavorite bool     `json:"is_favorite,omitempty"` // Local-only; favorites are listed first
+	PinOrder   int      `json:"pin_order,omitempty"`   // Local-only; pinned contacts (1, 2, ...) are listed before everyone else
 
 	LastModified *time.Time `json:"last_modified,omitempty"` // When contact was last modified locally
+	LastEdited   *time.Time `json:"last_edited,omitempty"`   // When fields the provider stores were last edited locally
 	LastSynced   *time.Time `json:"last_synced,omitempty"`   // When contact was last synced with provider
+
+	// photoChanged is set on writes whose PhotoData differs from the stored
+	// copy's, so providers only upload the photo when there's a new one
+	photoChanged bool
+
+	// eventsChanged is set on writes whose Anniversary or OtherEvents differ
+	// from the stored copy's, so providers only replace events when needed
+	eventsChanged bool
+}
+
+// ContactEvent is a dated event on a contact, such as a custom "first met"
+// date
+type ContactEvent struct {
+	Type string    `json:"type"` // As the provider names it, e.g. "other"
+	Date time.Time `json:"date"` // Year 0 if unknown
+}
+
+// DefaultPhoneTypeOrder is the phone types PrimaryPhone prefers unless
+// SetPrimaryTypeOrder says otherwise
+var DefaultPhoneTypeOrder = []string{"mobile", "cell"}
+
+// The types PrimaryPhone and PrimaryEmail prefer, most preferred first
+var (
+	phoneTypeOrder = DefaultPhoneTypeOrder
+	emailTypeOrder []string
+)
+
+// SetPrimaryTypeOrder sets the types PrimaryPhone and PrimaryEmail prefer,
+// most preferred first, e.g. ["work", "mobile"]. Types match regardless of
+// case; numbers and addresses of other types come after, in their stored
+// order. An empty phone order restores DefaultPhoneTypeOrder.
+func SetPrimaryTypeOrder(phone, email []string) {
+	phoneTypeOrder = phone
+	if len(phone) == 0 {
+		phoneTypeOrder = DefaultPhoneTypeOrder
+	}
+	emailTypeOrder = email
 }
 
-// PrimaryPhone returns the first phone number, preferring mobile
+// preferredType returns the index of the entry whose type comes first in
+// order, or 0 when none of the types are in it
+func preferredType(types []string, order []string) int {
+	for _, want := range order {
+		for i, t := range types {
+			if strings.EqualFold(t, want) {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+// PrimaryPhone returns the phone number of the most preferred type (mobile,
+// unless SetPrimaryTypeOrder changed it), or else the first one
 func (c *Contact) PrimaryPhone() string {
 	if len(c.PhoneNumbers) == 0 {
 		return ""
 	}
-	// Try to find mobile first
-	for _, p := range c.PhoneNumbers {
-		if p.Type == "mobile" || p.Type == "cell" {
-			return p.Value
-		}
+	types := make([]string, len(c.PhoneNumbers))
+	for i, p := range c.PhoneNumbers {
+		types[i] = p.Type
 	}
-	return c.PhoneNumbers[0].Value
+	return c.PhoneNumbers[preferredType(types, phoneTypeOrder)].Value
 }
 
-// PrimaryEmail returns the first email address
+// PrimaryEmail returns the email address of the most preferred type, if
+// SetPrimaryTypeOrder set any, or else the first one
 func (c *Contact) PrimaryEmail() string {
 	if len(c.EmailAddresses) == 0 {
 		return ""
 	}
-	return c.EmailAddresses[0].Value
+	types := make([]string, len(c.EmailAddresses))
+	for i, e := range c.EmailAddresses {
+		types[i] = e.Type
+	}
+	return c.EmailAddresses[preferredType(types, emailTypeOrder)].Value
 }
 
-type ContactManager struct {
-	provider    ContactProvider
-	config      config.Config
-	storagePath string // Directory where JSON contact files are stored
+// DisplayName returns the contact's full name, falling back to the nickname,
+// primary email, or primary phone so contacts without a name can still be
+// identified
+func (c *Contact) DisplayName() string {
+	for _, name := range []string{c.FullName, c.Nickname, c.PrimaryEmail(), c.PrimaryPhone()} {
+		if name = strings.TrimSpace(name); name != "" {
+			return name
+		}
+	}
+	return "(no name)"
 }
 
-type ContactProvider interface {
-	FetchContacts() ([]Contact, error)
-	WriteContact(Contact) error
-	DeleteContact(uid string) error
+// IsEmpty reports whether a contact has no name, phone number, or email
+// address, like the metadata-only entries Google can list among connections
+func (c *Contact) IsEmpty() bool {
+	names := []string{c.FullName, c.GivenName, c.FamilyName, c.Nickname}
+	if c.Organization != nil {
+		names = append(names, c.Organization.Name)
+	}
+	for _, name := range names {
+		if strings.TrimSpace(name) != "" {
+			return false
+		}
+	}
+	return strings.TrimSpace(c.PrimaryPhone()) == "" && strings.TrimSpace(c.PrimaryEmail()) == ""
 }
 
-func NewContactManager(provider ContactProvider, config config.Config, storagePath string) (*ContactManager, error) {
-	// Create contacts people directory if it doesn't exist
-	contactsDir := filepath.Join(storagePath, "contacts", "people")
-	if err := os.MkdirAll(contactsDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create contacts directory: %w", err)
+// NormalizePhone strips formatting from a phone number, keeping only digits
+// and a leading "+" so numbers written differently can be compared
+func NormalizePhone(phone string) string {
+	var sb strings.Builder
+	for i, r := range strings.TrimSpace(phone) {
+		if r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+		} else if r == '+' && i == 0 {
+			sb.WriteRune(r)
+		}
 	}
+	return sb.String()
+}
 
-	return &ContactManager{
-		provider:    provider,
-		config:      config,
-		storagePath: contactsDir,
-	}, nil
+// phonesMatch reports whether two phone numbers refer to the same line,
+// ignoring formatting and a missing country code
+func phonesMatch(a, b string) bool {
+	a = strings.TrimPrefix(NormalizePhone(a), "+")
+	b = strings.TrimPrefix(NormalizePhone(b), "+")
+	if a == "" || b == "" {
+		return false
+	}
+	if a == b {
+		return true
+	}
+	// Compare the national number when one side lacks the country code
+	const nationalDigits = 10
+	if len(a) >= nationalDigits && len(b) >= nationalDigits {
+		return a[len(a)-nationalDigits:] == b[len(b)-nationalDigits:]
+	}
+	return false
 }
 
-// GetContact reads a single contact from disk by UID
-func (cm *ContactManager) GetContact(uid string) (*Contact, error) {
-	filePath := filepath.Join(cm.storagePath, uid+".json")
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil // Contact not found
+// FindByPhoneOrEmail returns the first contact with a matching phone number or
+// email address, or nil if none match
+func FindByPhoneOrEmail(contacts []Contact, phone, email string) *Contact {
+	for i := range contacts {
+		if phone != "" {
+			for _, p := range contacts[i].PhoneNumbers {
+				if phonesMatch(p.Value, phone) {
+					return &contacts[i]
+				}
+			}
+		}
+		if email != "" {
+			for _, e := range contacts[i].EmailAddresses {
+				if strings.EqualFold(strings.TrimSpace(e.Value), strings.TrimSpace(email)) {
+					return &contacts[i]
+				}
+			}
 		}
-		return nil, fmt.Errorf("failed to read contact file: %w", err)
 	}
+	return nil
+}
 
-	var contact Contact
-	if err := json.Unmarshal(data, &contact); err != nil {
-		return nil, fmt.Errorf("failed to parse contact file: %w", err)
-	}
+type ContactManager struct {
+	provider  ContactProvider
+	config    config.Config
+	store     ContactStore // Active contacts
+	archive   ContactStore // Archived contacts, hidden from listings
+	notesPath string       // Directory where local-only dated notes are stored
+
+	syncProgress func(fetched, total int) // Called as SyncContacts writes each batch; may be nil
+	keepEmpty    bool                     // Keep contacts with no name, phone, or email when syncing
+	skippedEmpty int                      // How many empty contacts the last sync skipped
+	overwrite    bool                     // Let syncs overwrite contacts edited locally
+	localEdits   []LocalEdit              // Contacts the last sync left alone because of local edits
+}
 
-	return &contact, nil
+// ContactProvider is a remote source of contacts. Implementations should
+// abort when ctx is cancelled; FetchContacts may return the contacts fetched
+// so far alongside the error.
+type ContactProvider interface {
+	FetchContacts(ctx context.Context) ([]Contact, error)
+	FetchContact(ctx context.Context, providerID string) (*Contact, error)      // nil if it doesn't exist
+	WriteContact(ctx context.Context, contact Contact) (etag string, err error) // The ETag the write left the contact with
+
+	DeleteContact(ctx context.Context, providerID string) error
 }
 
-// ListContacts reads all contact JSON files from disk and returns them
-func (cm *ContactManager) ListContacts() ([]Contact, error) {
-	entries, err := os.ReadDir(cm.storagePath)
+// PartialContactProvider is a ContactProvider that can be set to fetch only
+// some of each contact's fields. SyncContacts has it copy the rest from the
+// stored copy, so they aren't cleared.
+type PartialContactProvider interface {
+	ContactProvider
+	KeepUnfetchedFields(fetched *Contact, local Contact)
+}
+
+// BatchDeleteProvider is a ContactProvider that can delete several contacts
+// in one request, used by DeleteContacts. DeleteContacts should delete all
+// of them or, on error, none.
+type BatchDeleteProvider interface {
+	ContactProvider
+	DeleteContacts(ctx context.Context, providerIDs []string) error
+}
+
+// PagedContactProvider is a ContactProvider that can fetch contacts a page at
+// a time. SyncContacts uses it to write each page as it arrives, so progress
+// can be shown and a cancelled sync keeps what it fetched. fn gets the page
+// and the number of contacts expected in total; an error from fn stops the
+// fetch and is returned.
+type PagedContactProvider interface {
+	ContactProvider
+	FetchContactPages(ctx context.Context, fn func(page []Contact, total int) error) error
+}
+
+func NewContactManager(provider ContactProvider, config config.Config, storagePath string) (*ContactManager, error) {
+	contactsDir := filepath.Join(storagePath, "contacts")
+
+	// Active and archived contacts use the configured storage backend
+	store, err := newContactStore(config.ContactsStore, filepath.Join(contactsDir, "people"), config.ContactsCompactJSON)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read contacts directory: %w", err)
+		return nil, err
+	}
+	archive, err := newContactStore(config.ContactsStore, filepath.Join(contactsDir, "archive"), config.ContactsCompactJSON)
+	if err != nil {
+		return nil, err
 	}
 
-	var contacts []Contact
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
-			continue
-		}
+	// Create notes directory if it doesn't exist
+	notesDir := filepath.Join(contactsDir, "notes")
+	if err := os.MkdirAll(notesDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create notes directory: %w", err)
+	}
 
-		// Skip non-contact files
-		if entry.Name() == "google_creds.json" || entry.Name() == "config.json" {
-			continue
-		}
+	// Cache contacts in memory so repeated listings (e.g. on every TUI
+	// keystroke) don't re-read storage
+	return &ContactManager{
+		provider:  provider,
+		config:    config,
+		store:     newCachedStore(store),
+		archive:   newCachedStore(archive),
+		notesPath: notesDir,
+	}, nil
+}
 
-		filePath := filepath.Join(cm.storagePath, entry.Name())
-		data, err := os.ReadFile(filePath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read contact file %s: %w", entry.Name(), err)
-		}
+// GetContact reads a single contact from local storage by UID
+func (cm *ContactManager) GetContact(uid string) (*Contact, error) {
+	return cm.store.Get(uid)
+}
 
-		var contact Contact
-		if err := json.Unmarshal(data, &contact); err != nil {
-			return nil, fmt.Errorf("failed to parse contact file %s: %w", entry.Name(), err)
-		}
+// IsProviderContact reports whether the contact exists at a provider
+func (c *Contact) IsProviderContact() bool {
+	return c.ProviderID != ""
+}
 
-		contacts = append(contacts, contact)
+// migrateProvenance fills in Source and ProviderID for contacts saved by older
+// versions, which told Google contacts apart by their dash-free IDs. Google
+// was the only provider then. Reports whether the contact changed.
+func migrateProvenance(contact *Contact) bool {
+	if contact.Source != "" || contact.ProviderID != "" {
+		return false
 	}
+	if contact.UID == "" || strings.Contains(contact.UID, "-") {
+		return false // Local UUID
+	}
+	contact.Source = SourceGoogle
+	contact.ProviderID = contact.UID
+	return true
+}
+
+// ListContacts returns all contacts from local storage.
+// Archived contacts are not included.
+func (cm *ContactManager) ListContacts() ([]Contact, error) {
+	return cm.store.List()
+}
+
+// ListArchivedContacts returns contacts that have been archived
+func (cm *ContactManager) ListArchivedContacts() ([]Contact, error) {
+	return cm.archive.List()
+}
 
-	return contacts, nil
+// WriteContact validates what was changed in a contact (see
+// ValidateChanges), writes it locally, and pushes the update to the provider
+func (cm *ContactManager) WriteContact(ctx context.Context, contact Contact) error {
+	var stored *Contact
+	if contact.UID != "" {
+		var err error
+		if stored, err = cm.store.Get(contact.UID); err != nil {
+			return fmt.Errorf("failed to read contact: %w", err)
+		}
+	}
+	if err := contact.ValidateChanges(stored); err != nil {
+		return fmt.Errorf("invalid contact: %w", err)
+	}
+	return cm.writeContact(ctx, contact, stored)
 }
 
-// WriteContact writes a contact locally and pushes the update to the provider
-func (cm *ContactManager) WriteContact(contact Contact) error {
+// writeContact writes a contact locally and pushes it to the provider,
+// without validating it. stored is the copy it replaces, if any.
+func (cm *ContactManager) writeContact(ctx context.Context, contact Contact, stored *Contact) error {
+	contact.photoChanged = len(contact.PhotoData) > 0 && (stored == nil || !bytes.Equal(stored.PhotoData, contact.PhotoData))
+	contact.eventsChanged = stored == nil || dateValue(stored.Anniversary) != dateValue(contact.Anniversary) ||
+		!slices.EqualFunc(stored.OtherEvents, contact.OtherEvents, func(a, b ContactEvent) bool {
+			return a.Type == b.Type && a.Date.Equal(b.Date)
+		})
+
 	// Generate UID if not set
 	if contact.UID == "" {
 		contact.UID = uuid.New().String()
@@ -188,97 +403,569 @@ func (cm *ContactManager) WriteContact(contact Contact) error {
 	// Set LastModified timestamp
 	now := time.Now()
 	contact.LastModified = &now
+	contact.LastEdited = &now
 
-	// Write to local storage
-	data, err := json.MarshalIndent(contact, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal contact: %w", err)
+	// Updates are checked against the provider's ETag; read it first if we
+	// never stored one
+	if contact.IsProviderContact() && contact.ETag == "" {
+		if err := cm.refreshETag(ctx, &contact); err != nil {
+			return err
+		}
 	}
 
-	filePath := filepath.Join(cm.storagePath, contact.UID+".json")
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write contact file: %w", err)
+	// Write to local storage
+	if err := cm.store.Put(contact); err != nil {
+		return err
 	}
 
 	// Push update to provider
-	if err := cm.provider.WriteContact(contact); err != nil {
+	etag, err := cm.provider.WriteContact(ctx, contact)
+	if err != nil {
+		if errors.Is(err, ErrStale) {
+			return cm.conflict(ctx, contact)
+		}
 		return fmt.Errorf("failed to write contact to provider: %w", err)
 	}
 
+	// The update gave the contact a new ETag; store it so the next update
+	// isn't rejected as stale. It comes from the write itself, so an edit
+	// made at the provider since then still makes the next update fail.
+	if contact.IsProviderContact() && etag != "" {
+		contact.ETag = etag
+	}
+
+	// The provider has the edit now, so syncs needn't hold it back
+	synced := time.Now()
+	contact.LastSynced = &synced
+	return cm.store.Put(contact)
+}
+
+// FetchContact reads a contact's current version from the provider, without
+// changing local storage. It returns nil if the provider no longer has it.
+func (cm *ContactManager) FetchContact(ctx context.Context, uid string) (*Contact, error) {
+	contact, err := cm.GetContact(uid)
+	if err != nil {
+		return nil, err
+	}
+	if contact == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, uid)
+	}
+	if !contact.IsProviderContact() {
+		return nil, fmt.Errorf("contact %s is local only", uid)
+	}
+
+	remote, err := cm.provider.FetchContact(ctx, contact.ProviderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contact from provider: %w", err)
+	}
+	return remote, nil
+}
+
+// conflict re-reads a contact the provider rejected as stale and reports the
+// conflict with the provider's current version
+func (cm *ContactManager) conflict(ctx context.Context, local Contact) error {
+	remote, err := cm.provider.FetchContact(ctx, local.ProviderID)
+	if err != nil {
+		return fmt.Errorf("%w (%s), and re-reading it failed: %w", ErrConflict, local.DisplayName(), err)
+	}
+	return &ConflictError{Local: local, Remote: remote}
+}
+
+// refreshETag sets a provider contact's ETag to the provider's current one
+func (cm *ContactManager) refreshETag(ctx context.Context, contact *Contact) error {
+	remote, err := cm.provider.FetchContact(ctx, contact.ProviderID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch contact from provider: %w", err)
+	}
+	if remote == nil {
+		return fmt.Errorf("contact %s no longer exists at the provider", contact.DisplayName())
+	}
+	contact.ETag = remote.ETag
 	return nil
 }
 
 // WriteContacts writes multiple contacts to disk and pushes them to the provider (batch operation)
-func (cm *ContactManager) WriteContacts(contacts []Contact) error {
+func (cm *ContactManager) WriteContacts(ctx context.Context, contacts []Contact) error {
 	for _, contact := range contacts {
-		if err := cm.WriteContact(contact); err != nil {
+		if err := cm.WriteContact(ctx, contact); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// DeleteContact removes a contact from disk and provider by UID
-func (cm *ContactManager) DeleteContact(uid string) error {
+// DeleteContact removes a contact from disk and provider by UID. Its notes
+// are deleted too if deleteNotes is set; otherwise they're kept, and
+// UndeleteContact gives them back to the contact.
+func (cm *ContactManager) DeleteContact(ctx context.Context, uid string, deleteNotes bool) error {
+	contact, err := cm.GetContact(uid)
+	if err != nil {
+		return err
+	}
+	if contact == nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, uid)
+	}
+
 	// Delete from provider first (if it's a provider contact)
-	// UIDs from Google are numeric IDs, new ones are UUIDs
-	isProviderContact := !strings.Contains(uid, "-") // UUIDs have dashes, provider IDs don't
-	if isProviderContact {
-		if err := cm.provider.DeleteContact(uid); err != nil {
+	if contact.IsProviderContact() {
+		if err := cm.provider.DeleteContact(ctx, contact.ProviderID); err != nil {
 			return fmt.Errorf("failed to delete contact from provider: %w", err)
 		}
 	}
 
 	// Delete from local storage
-	filePath := filepath.Join(cm.storagePath, uid+".json")
-	if err := os.Remove(filePath); err != nil {
-		if os.IsNotExist(err) {
-			return fmt.Errorf("contact not found: %s", uid)
-		}
+	if err := cm.store.Delete(uid); err != nil {
 		return fmt.Errorf("failed to delete contact: %w", err)
 	}
+	if deleteNotes {
+		return cm.removeNotes(uid)
+	}
 	return nil
 }
 
-// SyncContacts performs a pull-only sync from the provider to local storage
-// This fetches all contacts from the provider and writes them to local storage
-func (cm *ContactManager) SyncContacts() error {
-	// Fetch contacts from provider
-	remoteContacts, err := cm.provider.FetchContacts()
+// DeleteContacts removes several contacts from disk and provider by UID. A
+// provider that implements BatchDeleteProvider gets them in batches of
+// batchDeleteSize; if a batch fails, its contacts are deleted one at a time.
+// A contact that can't be deleted doesn't stop the rest: the deleted
+// contacts are returned along with the per-contact errors, joined. Notes
+// are deleted or kept according to deleteNotes, as for DeleteContact.
+func (cm *ContactManager) DeleteContacts(ctx context.Context, uids []string, deleteNotes bool) ([]Contact, error) {
+	var errs []error
+	var found []Contact
+	for _, uid := range uids {
+		contact, err := cm.GetContact(uid)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if contact == nil {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrNotFound, uid))
+			continue
+		}
+		found = append(found, *contact)
+	}
+
+	// Delete from provider first, as DeleteContact does
+	var remote []Contact
+	for _, contact := range found {
+		if contact.IsProviderContact() {
+			remote = append(remote, contact)
+		}
+	}
+	failed := cm.deleteFromProvider(ctx, remote)
+
+	var deleted []Contact
+	for _, contact := range found {
+		if err, ok := failed[contact.UID]; ok {
+			errs = append(errs, fmt.Errorf("failed to delete %s from provider: %w", contact.DisplayName(), err))
+			continue
+		}
+		if err := cm.store.Delete(contact.UID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete %s: %w", contact.DisplayName(), err))
+			continue
+		}
+		deleted = append(deleted, contact)
+		if deleteNotes {
+			if err := cm.removeNotes(contact.UID); err != nil {
+				errs = append(errs, fmt.Errorf("deleted %s but not its notes: %w", contact.DisplayName(), err))
+			}
+		}
+	}
+
+	return deleted, errors.Join(errs...)
+}
+
+// batchDeleteSize is the most contacts passed to a BatchDeleteProvider at
+// once, the People API's limit
+const batchDeleteSize = 500
+
+// deleteFromProvider deletes provider contacts, in batches where the provider
+// supports it. It returns the errors for contacts that couldn't be deleted,
+// keyed by UID.
+func (cm *ContactManager) deleteFromProvider(ctx context.Context, contactsList []Contact) map[string]error {
+	failed := make(map[string]error)
+	batcher, canBatch := cm.provider.(BatchDeleteProvider)
+
+	for batch := range slices.Chunk(contactsList, batchDeleteSize) {
+		if canBatch {
+			ids := make([]string, len(batch))
+			for i, contact := range batch {
+				ids[i] = contact.ProviderID
+			}
+			err := batcher.DeleteContacts(ctx, ids)
+			if err == nil {
+				continue
+			}
+			slog.Warn("batch delete failed, deleting contacts one at a time", "count", len(batch), "error", err)
+		}
+
+		for _, contact := range batch {
+			if err := cm.provider.DeleteContact(ctx, contact.ProviderID); err != nil {
+				failed[contact.UID] = err
+			}
+		}
+	}
+
+	return failed
+}
+
+// ArchiveContact moves a contact's local file to the archive so it no longer
+// appears in listings but can be restored. The provider record is kept unless
+// removeFromProvider is set.
+func (cm *ContactManager) ArchiveContact(ctx context.Context, uid string, removeFromProvider bool) error {
+	contact, err := cm.GetContact(uid)
 	if err != nil {
-		return fmt.Errorf("failed to fetch remote contacts: %w", err)
+		return err
+	}
+	if contact == nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, uid)
 	}
 
-	// Write all remote contacts to local storage
-	for _, contact := range remoteContacts {
-		if err := cm.writeContactWithoutModifyingTimestamp(contact); err != nil {
-			return fmt.Errorf("failed to write local contact: %w", err)
+	if removeFromProvider && contact.IsProviderContact() {
+		if err := cm.provider.DeleteContact(ctx, contact.ProviderID); err != nil {
+			return fmt.Errorf("failed to delete contact from provider: %w", err)
 		}
 	}
 
+	if err := cm.archive.Put(*contact); err != nil {
+		return fmt.Errorf("failed to archive contact: %w", err)
+	}
+	if err := cm.store.Delete(uid); err != nil {
+		return fmt.Errorf("failed to archive contact: %w", err)
+	}
 	return nil
 }
 
-// writeContactWithoutModifyingTimestamp writes a contact without updating LastModified
-// Used during sync to preserve modification times
-func (cm *ContactManager) writeContactWithoutModifyingTimestamp(contact Contact) error {
-	if contact.UID == "" {
+// RestoreContact moves an archived contact back into the active contacts
+func (cm *ContactManager) RestoreContact(uid string) error {
+	contact, err := cm.archive.Get(uid)
+	if err != nil {
+		return err
+	}
+	if contact == nil {
+		return fmt.Errorf("archived %w: %s", ErrNotFound, uid)
+	}
+
+	if err := cm.store.Put(*contact); err != nil {
+		return fmt.Errorf("failed to restore contact: %w", err)
+	}
+	if err := cm.archive.Delete(uid); err != nil {
+		return fmt.Errorf("failed to restore contact: %w", err)
+	}
+	return nil
+}
+
+// UndeleteContact re-creates a contact captured before it was deleted and
+// returns its UID. A provider contact can't be revived under its old ID, so
+// it is given a new local UID, along with any notes kept when it was deleted,
+// and created again at the provider.
+func (cm *ContactManager) UndeleteContact(ctx context.Context, contact Contact) (string, error) {
+	if contact.IsProviderContact() {
+		oldUID := contact.UID
 		contact.UID = uuid.New().String()
+		contact.ProviderID = ""
+		contact.ETag = ""
+		contact.URL = ""
+		// It's recreated as it was, so it isn't validated: what came from
+		// the provider may not pass
+		if err := cm.writeContact(ctx, contact, nil); err != nil {
+			return "", err
+		}
+		return contact.UID, cm.moveNotes(oldUID, contact.UID)
+	}
+
+	// Local-only contacts just need to be stored again, exactly as they were
+	if err := cm.store.Put(contact); err != nil {
+		return "", fmt.Errorf("failed to restore contact: %w", err)
+	}
+	return contact.UID, nil
+}
+
+// SetFavorite marks or unmarks a contact as a favorite. Favorites are local
+// only, so the provider is not updated.
+func (cm *ContactManager) SetFavorite(uid string, favorite bool) error {
+	contact, err := cm.store.Get(uid)
+	if err != nil {
+		return fmt.Errorf("failed to read contact: %w", err)
+	}
+	if contact == nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, uid)
+	}
+
+	now := time.Now()
+	contact.IsFavorite = favorite
+	contact.LastModified = &now
+	if err := cm.store.Put(*contact); err != nil {
+		return fmt.Errorf("failed to save contact: %w", err)
+	}
+	return nil
+}
+
+// SetPinned pins the given contacts in that order and unpins every other
+// contact. Pins are local only, so the provider is not updated.
+func (cm *ContactManager) SetPinned(uids []string) error {
+	order := make(map[string]int, len(uids))
+	for i, uid := range uids {
+		order[uid] = i + 1
+	}
+
+	all, err := cm.store.List()
+	if err != nil {
+		return fmt.Errorf("failed to read contacts: %w", err)
+	}
+
+	now := time.Now()
+	var changed []Contact
+	for _, contact := range all {
+		if contact.PinOrder == order[contact.UID] {
+			continue
+		}
+		contact.PinOrder = order[contact.UID]
+		contact.LastModified = &now
+		changed = append(changed, contact)
+	}
+
+	if err := cm.store.Put(changed...); err != nil {
+		return fmt.Errorf("failed to save contacts: %w", err)
+	}
+	return nil
+}
+
+// SetTags adds a tag to, or removes it from, each of the given contacts and
+// saves them in one batch. Tags are local only, so the provider is not updated.
+func (cm *ContactManager) SetTags(uids []string, tag string, present bool) error {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return fmt.Errorf("tag is required")
 	}
 
-	// Update LastSynced but not LastModified
 	now := time.Now()
-	contact.LastSynced = &now
+	var changed []Contact
+	for _, uid := range uids {
+		contact, err := cm.store.Get(uid)
+		if err != nil {
+			return fmt.Errorf("failed to read contact: %w", err)
+		}
+		if contact == nil {
+			return fmt.Errorf("%w: %s", ErrNotFound, uid)
+		}
+
+		if contact.HasTag(tag) == present {
+			continue
+		}
+		if present {
+			contact.Tags = append(contact.Tags, tag)
+		} else {
+			contact.Tags = slices.DeleteFunc(contact.Tags, func(t string) bool { return strings.EqualFold(t, tag) })
+		}
+		contact.LastModified = &now
+		changed = append(changed, *contact)
+	}
+
+	if err := cm.store.Put(changed...); err != nil {
+		return fmt.Errorf("failed to save contacts: %w", err)
+	}
+	return nil
+}
 
-	data, err := json.MarshalIndent(contact, "", "  ")
+// preserveLocalFields copies fields the provider doesn't store from the local
+// copy of a contact onto its freshly synced version
+func preserveLocalFields(synced *Contact, local Contact) {
+	synced.Tags = local.Tags
+	synced.IsFavorite = local.IsFavorite
+	synced.PinOrder = local.PinOrder
+}
+
+// archivedUIDs returns the set of UIDs of archived contacts
+func (cm *ContactManager) archivedUIDs() (map[string]bool, error) {
+	archived, err := cm.archive.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archived contacts: %w", err)
+	}
+	uids := make(map[string]bool, len(archived))
+	for _, c := range archived {
+		uids[c.UID] = true
+	}
+	return uids, nil
+}
+
+// SyncContacts performs a pull-only sync from the provider to local storage
+// This fetches all contacts from the provider and writes them to local storage.
+// Providers that fetch in pages have each page written as it arrives, so if
+// the fetch fails or ctx is cancelled part way, the contacts fetched so far
+// are kept and the error is returned.
+//
+// Contacts edited locally since they were last synced aren't overwritten
+// when the provider's version differs; LocalEdits lists them afterwards.
+// SetOverwriteLocalEdits overwrites them anyway.
+func (cm *ContactManager) SyncContacts(ctx context.Context) error {
+	archivedUIDs, err := cm.archivedUIDs()
 	if err != nil {
-		return fmt.Errorf("failed to marshal contact: %w", err)
+		return err
+	}
+	ignoredUIDs, err := cm.ignoredUIDs()
+	if err != nil {
+		return err
+	}
+
+	localContacts, err := cm.store.List()
+	if err != nil {
+		return fmt.Errorf("failed to read local contacts: %w", err)
+	}
+	local := make(map[string]Contact, len(localContacts))
+	for _, c := range localContacts {
+		local[c.UID] = c
+	}
+
+	// Write remote contacts to local storage a batch at a time, leaving
+	// archived ones archived. LastSynced is updated but not LastModified, to
+	// preserve modification times.
+	now := time.Now()
+	fetched, archived := 0, 0
+	cm.skippedEmpty = 0
+	cm.localEdits = nil
+	var writeErr error
+	writePage := func(page []Contact, total int) error {
+		var toWrite []Contact
+		for _, contact := range page {
+			if archivedUIDs[contact.UID] {
+				archived++
+				continue
+			}
+			// Ignored contacts stay out, even if a copy was left behind
+			if ignoredUIDs[contact.UID] {
+				if _, ok := local[contact.UID]; ok {
+					if err := cm.store.Delete(contact.UID); err != nil {
+						slog.Warn("failed to remove ignored contact", "uid", contact.UID, "error", err)
+					}
+				}
+				continue
+			}
+			// Entries with nothing to show would be blank rows; drop any
+			// copy an earlier sync stored too
+			if !cm.keepEmpty && contact.IsEmpty() {
+				cm.skippedEmpty++
+				if _, ok := local[contact.UID]; ok && contact.UID != "" {
+					if err := cm.store.Delete(contact.UID); err != nil {
+						slog.Warn("failed to remove empty contact", "uid", contact.UID, "error", err)
+					}
+				}
+				continue
+			}
+			if contact.UID == "" {
+				contact.UID = uuid.New().String()
+			}
+			contact.LastSynced = &now
+			if existing, ok := local[contact.UID]; ok {
+				preserveLocalFields(&contact, existing)
+				if partial, ok := cm.provider.(PartialContactProvider); ok {
+					partial.KeepUnfetchedFields(&contact, existing)
+				}
+				if !cm.overwrite && existing.EditedSinceSync() {
+					if changes := DiffContacts(existing, contact); len(changes) > 0 {
+						cm.localEdits = append(cm.localEdits, LocalEdit{Local: existing, Remote: contact, Changes: changes})
+						continue
+					}
+				}
+			}
+			toWrite = append(toWrite, contact)
+		}
+
+		if err := cm.store.Put(toWrite...); err != nil {
+			slog.Error("failed to write local contacts", "count", len(toWrite), "error", err)
+			writeErr = fmt.Errorf("failed to write local contacts: %w", err)
+			return writeErr
+		}
+
+		fetched += len(page)
+		if cm.syncProgress != nil {
+			cm.syncProgress(fetched, total)
+		}
+		return nil
 	}
 
-	filePath := filepath.Join(cm.storagePath, contact.UID+".json")
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write contact file: %w", err)
+	var fetchErr error
+	if paged, ok := cm.provider.(PagedContactProvider); ok {
+		fetchErr = paged.FetchContactPages(ctx, writePage)
+	} else {
+		var remoteContacts []Contact
+		remoteContacts, fetchErr = cm.provider.FetchContacts(ctx)
+		if len(remoteContacts) > 0 || fetchErr == nil {
+			writePage(remoteContacts, len(remoteContacts))
+		}
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+	if fetchErr != nil {
+		slog.Error("failed to fetch remote contacts", "fetched", fetched, "error", fetchErr)
+		return fmt.Errorf("failed to fetch remote contacts: %w", fetchErr)
+	}
+
+	slog.Info("contacts sync complete", "fetched", fetched, "skipped_archived", archived, "skipped_empty", cm.skippedEmpty,
+		"kept_local_edits", len(cm.localEdits))
+	return nil
+}
+
+// LocalEdit is a contact SyncContacts didn't overwrite because it was edited
+// locally since it was last synced and the provider's version differs
+type LocalEdit struct {
+	Local   Contact       // The locally edited contact, as kept
+	Remote  Contact       // The provider's version the sync would have written
+	Changes []FieldChange // What writing Remote would change
+}
+
+// EditedSinceSync reports whether fields the provider stores were changed
+// locally after the contact was last synced, or it was never synced at all.
+// Local-only fields such as favorites, pins, and tags don't count, since
+// syncs keep them anyway.
+func (c *Contact) EditedSinceSync() bool {
+	if c.LastEdited == nil {
+		return false
 	}
+	return c.LastSynced == nil || c.LastEdited.After(*c.LastSynced)
+}
+
+// SetOverwriteLocalEdits makes SyncContacts overwrite contacts edited
+// locally since they were last synced. By default they're left alone and
+// reported by LocalEdits, since the sync would lose the edits.
+func (cm *ContactManager) SetOverwriteLocalEdits(overwrite bool) {
+	cm.overwrite = overwrite
+}
+
+// LocalEdits returns the contacts the last SyncContacts left alone because
+// of local edits
+func (cm *ContactManager) LocalEdits() []LocalEdit {
+	return cm.localEdits
+}
 
+// OverwriteLocalEdits replaces locally edited contacts with the provider's
+// versions fetched by the sync that reported them
+func (cm *ContactManager) OverwriteLocalEdits(edits []LocalEdit) error {
+	remote := make([]Contact, len(edits))
+	for i, e := range edits {
+		remote[i] = e.Remote
+	}
+	if err := cm.store.Put(remote...); err != nil {
+		return fmt.Errorf("failed to write local contacts: %w", err)
+	}
 	return nil
 }
+
+// SetKeepEmpty makes SyncContacts keep contacts with no name, phone number,
+// or email address (see Contact.IsEmpty). They're skipped by default.
+func (cm *ContactManager) SetKeepEmpty(keep bool) {
+	cm.keepEmpty = keep
+}
+
+// SkippedEmpty returns how many empty contacts the last SyncContacts skipped
+func (cm *ContactManager) SkippedEmpty() int {
+	return cm.skippedEmpty
+}
+
+// SetSyncProgress sets a function SyncContacts calls after each batch of
+// contacts is written, with the number fetched so far and the number
+// expected in total. Pass nil to stop reporting progress.
+func (cm *ContactManager) SetSyncProgress(fn func(fetched, total int)) {
+	cm.syncProgress = fn
+}
@@ -0,0 +1,181 @@
+package contacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/arjungandhi/dunbar/pkg/config"
+)
+
+// fakeProvider is a ContactProvider holding contacts in memory. Writes fail
+// with writeErr when it's set, or with ErrStale for an out-of-date ETag, and
+// otherwise give the contact a new ETag.
+type fakeProvider struct {
+	contacts map[string]Contact // By provider ID
+	writeErr error
+	writes   int
+	fetches  int // Calls to FetchContact
+}
+
+func (p *fakeProvider) FetchContacts(ctx context.Context) ([]Contact, error) {
+	var all []Contact
+	for _, c := range p.contacts {
+		all = append(all, c)
+	}
+	return all, nil
+}
+
+func (p *fakeProvider) FetchContact(ctx context.Context, providerID string) (*Contact, error) {
+	p.fetches++
+	c, ok := p.contacts[providerID]
+	if !ok {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+func (p *fakeProvider) WriteContact(ctx context.Context, contact Contact) (string, error) {
+	p.writes++
+	if p.writeErr != nil {
+		return "", p.writeErr
+	}
+	if current, ok := p.contacts[contact.ProviderID]; ok && current.ETag != contact.ETag {
+		return "", fmt.Errorf("failed to update contact %s: %w", contact.FullName, ErrStale)
+	}
+	contact.ETag = fmt.Sprintf("etag-written-%d", p.writes)
+	p.contacts[contact.ProviderID] = contact
+	return contact.ETag, nil
+}
+
+func (p *fakeProvider) DeleteContact(ctx context.Context, providerID string) error {
+	delete(p.contacts, providerID)
+	return nil
+}
+
+// newTestManager creates a ContactManager storing contacts in a temporary
+// directory
+func newTestManager(t *testing.T, provider ContactProvider) *ContactManager {
+	t.Helper()
+	cm, err := NewContactManager(provider, config.Config{}, t.TempDir())
+	if err != nil {
+		t.Fatalf("NewContactManager: %v", err)
+	}
+	return cm
+}
+
+func TestWriteContactStaleETagReportsConflict(t *testing.T) {
+	remote := Contact{UID: "c1", ProviderID: "c1", ETag: "etag-2", FullName: "Ada Lovelace (edited elsewhere)"}
+	provider := &fakeProvider{
+		contacts: map[string]Contact{"c1": remote},
+		writeErr: fmt.Errorf("failed to update contact Ada Lovelace: %w", ErrStale),
+	}
+	cm := newTestManager(t, provider)
+
+	local := Contact{UID: "c1", ProviderID: "c1", ETag: "etag-1", FullName: "Ada Lovelace", Source: SourceGoogle}
+	err := cm.WriteContact(context.Background(), local)
+
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("WriteContact() error = %v, want a *ConflictError", err)
+	}
+	if !errors.Is(err, ErrConflict) || !errors.Is(err, ErrStale) {
+		t.Errorf("WriteContact() error = %v, want it to match ErrConflict and ErrStale", err)
+	}
+	if conflict.Local.FullName != local.FullName {
+		t.Errorf("conflict Local.FullName = %q, want %q", conflict.Local.FullName, local.FullName)
+	}
+	if conflict.Remote == nil || conflict.Remote.ETag != "etag-2" || conflict.Remote.FullName != remote.FullName {
+		t.Errorf("conflict Remote = %+v, want the provider's current version", conflict.Remote)
+	}
+
+	// The edit is kept locally, as an edit a sync won't silently overwrite
+	stored, err := cm.GetContact("c1")
+	if err != nil || stored == nil {
+		t.Fatalf("GetContact() = %v, %v", stored, err)
+	}
+	if stored.FullName != local.FullName || !stored.EditedSinceSync() {
+		t.Errorf("stored contact = %q (edited since sync: %v), want the local edit pending",
+			stored.FullName, stored.EditedSinceSync())
+	}
+}
+
+func TestWriteContactSuccessIsNotALocalEdit(t *testing.T) {
+	provider := &fakeProvider{contacts: map[string]Contact{
+		"c1": {UID: "c1", ProviderID: "c1", ETag: "etag-1", FullName: "Ada Lovelace"},
+	}}
+	cm := newTestManager(t, provider)
+
+	contact := Contact{UID: "c1", ProviderID: "c1", ETag: "etag-1", FullName: "Ada King", Source: SourceGoogle}
+	if err := cm.WriteContact(context.Background(), contact); err != nil {
+		t.Fatalf("WriteContact() error = %v", err)
+	}
+	if provider.writes != 1 {
+		t.Errorf("provider writes = %d, want 1", provider.writes)
+	}
+
+	stored, err := cm.GetContact("c1")
+	if err != nil || stored == nil {
+		t.Fatalf("GetContact() = %v, %v", stored, err)
+	}
+	if stored.EditedSinceSync() {
+		t.Error("EditedSinceSync() = true after a successful push, want false")
+	}
+}
+
+func TestWriteContactKeepsETagFromWrite(t *testing.T) {
+	provider := &fakeProvider{contacts: map[string]Contact{
+		"c1": {UID: "c1", ProviderID: "c1", ETag: "etag-1", FullName: "Ada Lovelace"},
+	}}
+	cm := newTestManager(t, provider)
+
+	contact := Contact{UID: "c1", ProviderID: "c1", ETag: "etag-1", FullName: "Ada King", Source: SourceGoogle}
+	if err := cm.WriteContact(context.Background(), contact); err != nil {
+		t.Fatalf("WriteContact() error = %v", err)
+	}
+
+	// An edit made at the provider after the write must not lend the
+	// contact its ETag, so the contact isn't read back
+	provider.contacts["c1"] = Contact{UID: "c1", ProviderID: "c1", ETag: "etag-remote", FullName: "Ada (remote)"}
+	if provider.fetches != 0 {
+		t.Errorf("provider fetches = %d, want 0", provider.fetches)
+	}
+	stored, err := cm.GetContact("c1")
+	if err != nil || stored == nil {
+		t.Fatalf("GetContact() = %v, %v", stored, err)
+	}
+	if stored.ETag != "etag-written-1" {
+		t.Errorf("stored ETag = %q, want the one the write returned", stored.ETag)
+	}
+
+	// So the next write is checked against the ETag from the write
+	stored.FullName = "Ada Lovelace King"
+	var conflict *ConflictError
+	if err := cm.WriteContact(context.Background(), *stored); !errors.As(err, &conflict) {
+		t.Errorf("second WriteContact() error = %v, want a *ConflictError", err)
+	}
+}
+
+func TestUndeleteContactReturnsNewUID(t *testing.T) {
+	provider := &fakeProvider{contacts: map[string]Contact{}}
+	cm := newTestManager(t, provider)
+	ctx := context.Background()
+
+	deleted := Contact{UID: "c1", ProviderID: "c1", ETag: "etag-1", FullName: "Ada Lovelace", Source: SourceGoogle}
+	uid, err := cm.UndeleteContact(ctx, deleted)
+	if err != nil {
+		t.Fatalf("UndeleteContact() error = %v", err)
+	}
+	if uid == "" || uid == deleted.UID {
+		t.Fatalf("UndeleteContact() = %q, want a new UID for a provider contact", uid)
+	}
+	if stored, err := cm.GetContact(uid); err != nil || stored == nil || stored.FullName != deleted.FullName {
+		t.Errorf("GetContact(%q) = %v, %v, want the restored contact", uid, stored, err)
+	}
+
+	local := Contact{UID: "local-1", FullName: "Ada Lovelace"}
+	if uid, err := cm.UndeleteContact(ctx, local); err != nil || uid != local.UID {
+		t.Errorf("UndeleteContact(local) = %q, %v, want its own UID %q", uid, err, local.UID)
+	}
+}
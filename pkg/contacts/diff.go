@@ -0,0 +1,149 @@
+package contacts
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Kinds of field change
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// FieldChange describes one difference between two versions of a contact
+type FieldChange struct {
+	Field string // e.g. "phone", "email", "org"
+	Kind  string // ChangeAdded, ChangeRemoved, or ChangeChanged
+	Old   string // Previous value, empty for additions
+	New   string // New value, empty for removals
+}
+
+// String formats the change for display, e.g. "phone added +1 555 0100"
+func (f FieldChange) String() string {
+	switch f.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("%s added %s", f.Field, f.New)
+	case ChangeRemoved:
+		return fmt.Sprintf("%s removed %s", f.Field, f.Old)
+	default:
+		if f.Old == "" && f.New == "" {
+			return fmt.Sprintf("%s changed", f.Field)
+		}
+		return fmt.Sprintf("%s changed %q -> %q", f.Field, f.Old, f.New)
+	}
+}
+
+// DiffContacts lists the fields that differ between two versions of a
+// contact. Sync bookkeeping (ETag, URL, and timestamps) is ignored.
+func DiffContacts(old, new Contact) []FieldChange {
+	var changes []FieldChange
+
+	single := func(field, a, b string) {
+		switch {
+		case a == b:
+		case a == "":
+			changes = append(changes, FieldChange{Field: field, Kind: ChangeAdded, New: b})
+		case b == "":
+			changes = append(changes, FieldChange{Field: field, Kind: ChangeRemoved, Old: a})
+		default:
+			changes = append(changes, FieldChange{Field: field, Kind: ChangeChanged, Old: a, New: b})
+		}
+	}
+
+	// multi reports values added and removed from a list, ignoring order
+	multi := func(field string, a, b []string) {
+		for _, v := range b {
+			if !slices.Contains(a, v) {
+				changes = append(changes, FieldChange{Field: field, Kind: ChangeAdded, New: v})
+			}
+		}
+		for _, v := range a {
+			if !slices.Contains(b, v) {
+				changes = append(changes, FieldChange{Field: field, Kind: ChangeRemoved, Old: v})
+			}
+		}
+	}
+
+	single("name", old.FullName, new.FullName)
+	single("given name", old.GivenName, new.GivenName)
+	single("family name", old.FamilyName, new.FamilyName)
+	single("nickname", old.Nickname, new.Nickname)
+
+	multi("phone", phoneValues(old.PhoneNumbers), phoneValues(new.PhoneNumbers))
+	multi("email", emailValues(old.EmailAddresses), emailValues(new.EmailAddresses))
+	multi("address", addressValues(old.Addresses), addressValues(new.Addresses))
+
+	single("org", orgValue(old.Organization), orgValue(new.Organization))
+	single("birthday", dateValue(old.Birthday), dateValue(new.Birthday))
+	single("anniversary", dateValue(old.Anniversary), dateValue(new.Anniversary))
+
+	if old.PhotoURL != new.PhotoURL || !bytes.Equal(old.PhotoData, new.PhotoData) {
+		changes = append(changes, FieldChange{Field: "photo", Kind: ChangeChanged})
+	}
+
+	multi("tag", old.Tags, new.Tags)
+	multi("group", old.Groups, new.Groups)
+	if old.Notes != new.Notes {
+		changes = append(changes, FieldChange{Field: "notes", Kind: ChangeChanged})
+	}
+
+	return changes
+}
+
+func phoneValues(phones []PhoneNumber) []string {
+	values := make([]string, len(phones))
+	for i, p := range phones {
+		values[i] = p.Value
+	}
+	return values
+}
+
+func emailValues(emails []EmailAddress) []string {
+	values := make([]string, len(emails))
+	for i, e := range emails {
+		values[i] = e.Value
+	}
+	return values
+}
+
+func addressValues(addresses []Address) []string {
+	values := make([]string, len(addresses))
+	for i, a := range addresses {
+		var parts []string
+		for _, p := range []string{a.Street, a.City, a.State, a.PostalCode, a.Country} {
+			if p != "" {
+				parts = append(parts, p)
+			}
+		}
+		values[i] = strings.Join(parts, ", ")
+	}
+	return values
+}
+
+func orgValue(org *Organization) string {
+	if org == nil {
+		return ""
+	}
+	var parts []string
+	for _, p := range []string{org.Title, org.Department, org.Name} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func dateValue(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	if t.Year() <= 0 {
+		return t.Format("01-02")
+	}
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,31 @@
+// Package contacts stores contacts locally and syncs them with a provider
+// such as Google Contacts. It's what the dunbar CLI is built on, and it can be
+// embedded in other front-ends: nothing in it prints or reads the terminal,
+// and calls that reach the provider take a context.
+//
+// The entry point is ContactManager, created with NewContactManager from a
+// ContactProvider (e.g. NewGoogleContactsProvider) and a config.Config:
+//
+//   - SyncContacts pulls contacts from the provider; SetSyncProgress reports
+//     how far it has got, and SetKeepEmpty keeps the entries with no name,
+//     phone, or email that it otherwise skips. Contacts edited locally since
+//     their last sync are kept and reported by LocalEdits rather than
+//     overwritten.
+//   - ListContacts, GetContact, ListArchivedContacts, and SearchContacts read
+//     local contacts.
+//   - WriteContact, DeleteContact, ArchiveContact, and RestoreContact change
+//     them, pushing the change to the provider where it has one.
+//   - SetFavorite, SetPinned, SetTags, and AddNote change local-only data.
+//     IgnoreContact removes a contact and keeps later syncs from bringing it
+//     back, until UnignoreContact.
+//
+// Functions on plain contact slices, such as FilterContacts, FieldCoverage,
+// DiffContacts, UpcomingEvents, and Export, don't touch storage at all.
+// SetPrimaryTypeOrder chooses which phone and email types PrimaryPhone and
+// PrimaryEmail prefer, for the whole program.
+//
+// Errors are returned rather than printed, wrapping ErrNotInitialized,
+// ErrNotFound, ErrReauthRequired, or ErrConflict where one applies, so
+// callers can check them with errors.Is. Diagnostics go to log/slog, so an
+// embedding program decides where they end up.
+package contacts
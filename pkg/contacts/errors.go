@@ -0,0 +1,47 @@
+package contacts
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by ContactManager and the providers, wrapped with details,
+// for callers to check with errors.Is
+var (
+	// ErrNotInitialized means the provider has no credentials or
+	// authorization yet
+	ErrNotInitialized = errors.New("contacts provider not initialized")
+
+	// ErrNotFound means no stored contact has the given UID
+	ErrNotFound = errors.New("contact not found")
+
+	// ErrReauthRequired is returned when the stored Google authorization has
+	// expired or been revoked and the user must authorize again
+	ErrReauthRequired = errors.New("google authorization expired or revoked")
+
+	// ErrConflict means an update was refused because the contact changed at
+	// the provider since it was last synced. ContactManager returns it as a
+	// *ConflictError, which has both versions.
+	ErrConflict = errors.New("contact changed at the provider since it was last synced")
+
+	// ErrStale is returned by ContactProvider.WriteContact when the contact's
+	// ETag no longer matches the provider's, i.e. it was changed elsewhere
+	ErrStale = errors.New("contact was changed at the provider")
+)
+
+// ConflictError is returned by ContactManager.WriteContact when the contact
+// was changed at the provider since it was last read. Nothing was written to
+// the provider; Remote holds its current version for resolving the conflict.
+// It matches both ErrConflict and ErrStale.
+type ConflictError struct {
+	Local  Contact  // The version we tried to write
+	Remote *Contact // The provider's current version, nil if it was deleted
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("contact %s was changed at the provider since it was last synced", e.Local.DisplayName())
+}
+
+func (e *ConflictError) Unwrap() []error {
+	return []error{ErrConflict, ErrStale}
+}
@@ -0,0 +1,84 @@
+package contacts
+
+import (
+	"sort"
+	"time"
+)
+
+// Event kinds
+const (
+	EventBirthday    = "birthday"
+	EventAnniversary = "anniversary"
+)
+
+// Event is an upcoming birthday or anniversary for a contact
+type Event struct {
+	Contact Contact
+	Kind    string    // EventBirthday or EventAnniversary
+	Date    time.Time // Original date (year 0 if unknown)
+	Next    time.Time // Next occurrence on or after the reference date
+}
+
+// HasYear reports whether the event's original date includes a year
+func (e Event) HasYear() bool {
+	return e.Date.Year() > 0
+}
+
+// Years returns how many years the next occurrence marks (age or anniversary
+// count), or 0 if the original date has no year
+func (e Event) Years() int {
+	if !e.HasYear() {
+		return 0
+	}
+	return e.Next.Year() - e.Date.Year()
+}
+
+// UpcomingEvents returns birthdays and anniversaries occurring within the given
+// number of days from the reference time, sorted by next occurrence
+func UpcomingEvents(contacts []Contact, from time.Time, days int) []Event {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, days)
+
+	var events []Event
+	for _, c := range contacts {
+		dates := []struct {
+			kind string
+			date *time.Time
+		}{
+			{EventBirthday, c.Birthday},
+			{EventAnniversary, c.Anniversary},
+		}
+
+		for _, d := range dates {
+			if d.date == nil {
+				continue
+			}
+			next := nextOccurrence(*d.date, start)
+			if next.After(end) {
+				continue
+			}
+			events = append(events, Event{
+				Contact: c,
+				Kind:    d.kind,
+				Date:    *d.date,
+				Next:    next,
+			})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Next.Before(events[j].Next)
+	})
+
+	return events
+}
+
+// nextOccurrence returns the next anniversary of date on or after start.
+// Feb 29 falls on Mar 1 in non-leap years.
+func nextOccurrence(date time.Time, start time.Time) time.Time {
+	next := time.Date(start.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if next.Before(start) {
+		next = time.Date(start.Year()+1, date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return next
+}
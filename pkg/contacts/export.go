@@ -0,0 +1,167 @@
+package contacts
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Export formats
+const (
+	FormatJSON  = "json"
+	FormatVCard = "vcard"
+)
+
+// Export writes contacts in the given format. Callers choose which contacts
+// to export, e.g. after filtering with FilterContacts.
+func Export(w io.Writer, contacts []Contact, format string) error {
+	switch format {
+	case FormatJSON:
+		return ExportJSON(w, contacts)
+	case FormatVCard:
+		return ExportVCard(w, contacts)
+	default:
+		return fmt.Errorf("unknown export format %q (expected %q or %q)", format, FormatJSON, FormatVCard)
+	}
+}
+
+// ExportJSON writes contacts as an indented JSON array
+func ExportJSON(w io.Writer, contacts []Contact) error {
+	if contacts == nil {
+		contacts = []Contact{}
+	}
+	data, err := json.MarshalIndent(contacts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal contacts: %w", err)
+	}
+	if _, err := fmt.Fprintln(w, string(data)); err != nil {
+		return fmt.Errorf("failed to write contacts: %w", err)
+	}
+	return nil
+}
+
+// ExportVCard writes contacts as vCard 3.0 cards
+func ExportVCard(w io.Writer, contacts []Contact) error {
+	for _, c := range contacts {
+		if _, err := io.WriteString(w, vCard(c)); err != nil {
+			return fmt.Errorf("failed to write contacts: %w", err)
+		}
+	}
+	return nil
+}
+
+// vCard renders a single contact as a vCard 3.0 card
+func vCard(c Contact) string {
+	var sb strings.Builder
+	line := func(name, value string) {
+		sb.WriteString(foldVCardLine(name + ":" + value))
+	}
+
+	line("BEGIN", "VCARD")
+	line("VERSION", "3.0")
+	line("UID", escapeVCard(c.UID))
+	line("FN", escapeVCard(c.FullName))
+	line("N", escapeVCard(c.FamilyName)+";"+escapeVCard(c.GivenName)+";;;")
+	if c.Nickname != "" {
+		line("NICKNAME", escapeVCard(c.Nickname))
+	}
+
+	for _, p := range c.PhoneNumbers {
+		line(vCardTyped("TEL", p.Type), escapeVCard(p.Value))
+	}
+	for _, e := range c.EmailAddresses {
+		line(vCardTyped("EMAIL", e.Type), escapeVCard(e.Value))
+	}
+	for _, a := range c.Addresses {
+		line(vCardTyped("ADR", a.Type), strings.Join([]string{
+			"", "", escapeVCard(a.Street), escapeVCard(a.City),
+			escapeVCard(a.State), escapeVCard(a.PostalCode), escapeVCard(a.Country),
+		}, ";"))
+	}
+
+	if org := c.Organization; org != nil {
+		if org.Name != "" || org.Department != "" {
+			line("ORG", escapeVCard(org.Name)+";"+escapeVCard(org.Department))
+		}
+		if org.Title != "" {
+			line("TITLE", escapeVCard(org.Title))
+		}
+	}
+
+	if c.Birthday != nil {
+		line("BDAY", vCardDate(*c.Birthday))
+	}
+	if c.Anniversary != nil {
+		line("X-ANNIVERSARY", vCardDate(*c.Anniversary))
+	}
+
+	if len(c.PhotoData) > 0 {
+		line("PHOTO;ENCODING=b", base64.StdEncoding.EncodeToString(c.PhotoData))
+	} else if c.PhotoURL != "" {
+		line("PHOTO;VALUE=uri", c.PhotoURL)
+	}
+
+	if len(c.Tags) > 0 {
+		tags := make([]string, len(c.Tags))
+		for i, t := range c.Tags {
+			tags[i] = escapeVCard(t)
+		}
+		line("CATEGORIES", strings.Join(tags, ","))
+	}
+	if c.Notes != "" {
+		line("NOTE", escapeVCard(c.Notes))
+	}
+
+	line("END", "VCARD")
+	return sb.String()
+}
+
+// vCardTyped adds a TYPE parameter to a property name when the type is known
+func vCardTyped(name, typ string) string {
+	if typ == "" {
+		return name
+	}
+	return name + ";TYPE=" + strings.ToUpper(escapeVCard(typ))
+}
+
+// vCardDate formats a date, using the "--MM-DD" form when the year is unknown
+func vCardDate(t time.Time) string {
+	if t.Year() <= 0 {
+		return t.Format("--01-02")
+	}
+	return t.Format("2006-01-02")
+}
+
+// escapeVCard escapes characters with special meaning in vCard values
+func escapeVCard(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		",", `\,`,
+		";", `\;`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// foldVCardLine terminates a content line, folding it at 75 octets as the
+// vCard spec requires without splitting multi-byte characters
+func foldVCardLine(s string) string {
+	const maxLine = 75
+
+	var sb strings.Builder
+	width := 0
+	for _, r := range s {
+		n := len(string(r))
+		if width+n > maxLine {
+			sb.WriteString("\r\n ")
+			width = 1
+		}
+		sb.WriteRune(r)
+		width += n
+	}
+	sb.WriteString("\r\n")
+	return sb.String()
+}
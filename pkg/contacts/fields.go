@@ -0,0 +1,75 @@
+package contacts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CompletenessFields are the fields FieldCoverage counts, in report order
+var CompletenessFields = []string{"phone", "email", "birthday", "org", "address", "photo"}
+
+// HasField reports whether the contact has a value for one of
+// CompletenessFields
+func (c *Contact) HasField(field string) (bool, error) {
+	switch field {
+	case "phone":
+		for _, p := range c.PhoneNumbers {
+			if strings.TrimSpace(p.Value) != "" {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "email":
+		for _, e := range c.EmailAddresses {
+			if strings.TrimSpace(e.Value) != "" {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "birthday":
+		return c.Birthday != nil, nil
+	case "org":
+		return c.Organization != nil && (c.Organization.Name != "" || c.Organization.Title != ""), nil
+	case "address":
+		for _, a := range c.Addresses {
+			if a.Street != "" || a.City != "" || a.State != "" || a.PostalCode != "" || a.Country != "" {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "photo":
+		return c.PhotoURL != "" || len(c.PhotoData) > 0, nil
+	default:
+		return false, fmt.Errorf("unknown field %q (use %s)", field, strings.Join(CompletenessFields, ", "))
+	}
+}
+
+// FieldCoverage counts how many of the contacts have each of
+// CompletenessFields
+func FieldCoverage(contacts []Contact) map[string]int {
+	coverage := make(map[string]int, len(CompletenessFields))
+	for i := range contacts {
+		for _, field := range CompletenessFields {
+			if has, _ := contacts[i].HasField(field); has {
+				coverage[field]++
+			}
+		}
+	}
+	return coverage
+}
+
+// MissingField returns the contacts without a value for field, one of
+// CompletenessFields
+func MissingField(contacts []Contact, field string) ([]Contact, error) {
+	var missing []Contact
+	for i := range contacts {
+		has, err := contacts[i].HasField(field)
+		if err != nil {
+			return nil, err
+		}
+		if !has {
+			missing = append(missing, contacts[i])
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,96 @@
+package contacts
+
+import (
+	"strings"
+)
+
+// HasTag reports whether the contact has the given tag, ignoring case
+func (c *Contact) HasTag(tag string) bool {
+	for _, t := range c.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// InGroup reports whether the contact belongs to the provider contact group
+// with the given name, ignoring case
+func (c *Contact) InGroup(group string) bool {
+	for _, g := range c.Groups {
+		if strings.EqualFold(g, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches reports whether a case-insensitive query appears in the contact's
+// name, nickname, email addresses, phone numbers, organization, tags, or
+// groups
+func (c *Contact) Matches(query string) bool {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return true
+	}
+
+	fields := []string{c.FullName, c.GivenName, c.FamilyName, c.Nickname}
+	for _, e := range c.EmailAddresses {
+		fields = append(fields, e.Value)
+	}
+	for _, p := range c.PhoneNumbers {
+		fields = append(fields, p.Value)
+	}
+	if c.Organization != nil {
+		fields = append(fields, c.Organization.Name, c.Organization.Title)
+	}
+	fields = append(fields, c.Tags...)
+	fields = append(fields, c.Groups...)
+
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), query) {
+			return true
+		}
+	}
+
+	// Let "5551234" find "+1 (555) 123-4567"
+	digits := strings.TrimPrefix(NormalizePhone(query), "+")
+	if strings.Trim(query, "+0123456789 -().") == "" && len(digits) >= minPhoneDigits {
+		for _, p := range c.PhoneNumbers {
+			if strings.Contains(NormalizePhone(p.Value), digits) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// FilterContacts returns the contacts that have the tag (if not empty) and
+// match the search query (if not empty)
+func FilterContacts(contacts []Contact, tag, query string) []Contact {
+	var filtered []Contact
+	for _, c := range contacts {
+		if tag != "" && !c.HasTag(tag) {
+			continue
+		}
+		if !c.Matches(query) {
+			continue
+		}
+		filtered = append(filtered, c)
+	}
+	return filtered
+}
+
+// SearchContacts returns the stored contacts matching query (see
+// Contact.Matches). An empty query matches nothing.
+func (cm *ContactManager) SearchContacts(query string) ([]Contact, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, nil
+	}
+	contacts, err := cm.ListContacts()
+	if err != nil {
+		return nil, err
+	}
+	return FilterContacts(contacts, "", query), nil
+}
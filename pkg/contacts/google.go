@@ -3,13 +3,20 @@ package contacts
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/oauth2"
@@ -32,24 +39,113 @@ type GoogleContactsProvider struct {
 	credsPath   string
 	syncToken   string
 	syncTokenPath string
+	personFields  []string // People API person fields requested on fetch
+	pageSize      int      // Contacts requested per People API page
+	maxContacts   int      // Stop fetching after this many contacts; zero for no limit
+
+	groupsMu sync.Mutex
+	groups   map[string]string // Contact group names by resource name, kept for the process lifetime (contactGroups)
+}
+
+// People API page sizes. A page that times out is retried at half the size,
+// down to MinGooglePageSize.
+const (
+	DefaultGooglePageSize = 1000 // Also the largest page the People API allows
+	MinGooglePageSize     = 50
+)
+
+// DefaultGooglePersonFields is the set of People API person fields fetched by default
+var DefaultGooglePersonFields = []string{
+	"names", "nicknames", "emailAddresses", "phoneNumbers", "addresses", "organizations",
+	"birthdays", "events", "photos", "biographies", "memberships",
 }
 
-// NewGoogleContactsProvider creates a new Google Contacts provider
+// allowedGooglePersonFields is the set of valid People API personFields values
+var allowedGooglePersonFields = map[string]bool{
+	"addresses": true, "ageRanges": true, "biographies": true, "birthdays": true,
+	"calendarUrls": true, "clientData": true, "coverPhotos": true, "emailAddresses": true,
+	"events": true, "externalIds": true, "genders": true, "imClients": true,
+	"interests": true, "locales": true, "locations": true, "memberships": true,
+	"metadata": true, "miscKeywords": true, "names": true, "nicknames": true,
+	"occupations": true, "organizations": true, "phoneNumbers": true, "photos": true,
+	"relations": true, "sipAddresses": true, "skills": true, "urls": true,
+	"userDefined": true,
+}
+
+// ValidatePersonFields checks that every field is a valid People API person field
+func ValidatePersonFields(fields []string) error {
+	if len(fields) == 0 {
+		return fmt.Errorf("at least one person field is required")
+	}
+	for _, f := range fields {
+		if !allowedGooglePersonFields[f] {
+			return fmt.Errorf("unknown person field %q", f)
+		}
+	}
+	return nil
+}
+
+// NewGoogleContactsProvider creates a new Google Contacts provider. Its
+// credentials and sync token are kept in dunbarDir, the config directory,
+// and moved there from the contacts directory they used to share with the
+// contacts themselves.
 func NewGoogleContactsProvider(dunbarDir string) (*GoogleContactsProvider, error) {
-	contactsDir := filepath.Join(dunbarDir, "contacts")
-	if err := os.MkdirAll(contactsDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create contacts directory: %w", err)
+	if err := os.MkdirAll(dunbarDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create dunbar directory: %w", err)
 	}
 
-	credsPath := filepath.Join(contactsDir, "google_creds.json")
-	syncTokenPath := filepath.Join(contactsDir, "google_sync_token.txt")
+	credsPath := filepath.Join(dunbarDir, "google_creds.json")
+	syncTokenPath := filepath.Join(dunbarDir, "google_sync_token.txt")
+	for _, path := range []string{credsPath, syncTokenPath} {
+		if err := moveLegacyFile(filepath.Join(dunbarDir, "contacts", filepath.Base(path)), path); err != nil {
+			return nil, err
+		}
+	}
 
 	return &GoogleContactsProvider{
 		credsPath:     credsPath,
 		syncTokenPath: syncTokenPath,
+		personFields:  DefaultGooglePersonFields,
+		pageSize:      DefaultGooglePageSize,
 	}, nil
 }
 
+// moveLegacyFile moves the file at oldPath to newPath, unless there's nothing
+// to move or newPath already exists
+func moveLegacyFile(oldPath, newPath string) error {
+	if _, err := os.Stat(newPath); err == nil {
+		return nil
+	}
+	if err := os.Rename(oldPath, newPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to move %s to %s: %w", oldPath, newPath, err)
+	}
+	return nil
+}
+
+// SetPageSize sets how many contacts are requested per page (values below 1
+// use the default; values above the People API's limit are capped)
+func (g *GoogleContactsProvider) SetPageSize(n int) {
+	if n < 1 {
+		n = DefaultGooglePageSize
+	}
+	g.pageSize = min(n, DefaultGooglePageSize)
+}
+
+// SetMaxContacts limits how many contacts a fetch returns (values below 1
+// mean no limit)
+func (g *GoogleContactsProvider) SetMaxContacts(n int) {
+	g.maxContacts = max(n, 0)
+}
+
+// SetPersonFields sets which People API person fields are requested on fetch
+func (g *GoogleContactsProvider) SetPersonFields(fields []string) error {
+	if err := ValidatePersonFields(fields); err != nil {
+		return err
+	}
+	g.personFields = fields
+	return nil
+}
+
 // SaveCredentials saves OAuth credentials to the credentials file
 func (g *GoogleContactsProvider) SaveCredentials(creds *GoogleCredentials) error {
 	data, err := json.MarshalIndent(creds, "", "  ")
@@ -69,7 +165,7 @@ func (g *GoogleContactsProvider) LoadCredentials() (*GoogleCredentials, error) {
 	data, err := os.ReadFile(g.credsPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("credentials file not found at %s: please run setup first", g.credsPath)
+			return nil, fmt.Errorf("%w: credentials file not found at %s", ErrNotInitialized, g.credsPath)
 		}
 		return nil, fmt.Errorf("failed to read credentials file: %w", err)
 	}
@@ -82,6 +178,50 @@ func (g *GoogleContactsProvider) LoadCredentials() (*GoogleCredentials, error) {
 	return &creds, nil
 }
 
+// googleClientSecretFile mirrors the client_secret.json file downloaded from
+// the Google Cloud console. Desktop clients are under "installed", web
+// clients under "web".
+type googleClientSecretFile struct {
+	Installed *googleClientSecret `json:"installed"`
+	Web       *googleClientSecret `json:"web"`
+}
+
+type googleClientSecret struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+}
+
+// LoadGoogleClientSecret reads the client ID and secret from a downloaded
+// OAuth client_secret.json. Only desktop app clients are supported.
+func LoadGoogleClientSecret(path string) (*GoogleCredentials, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client secret file: %w", err)
+	}
+
+	var file googleClientSecretFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse client secret file %s: %w", path, err)
+	}
+
+	if file.Installed == nil {
+		if file.Web != nil {
+			return nil, fmt.Errorf("%s is for a web application client; create an OAuth client ID with application type \"Desktop app\" and download that instead", path)
+		}
+		return nil, fmt.Errorf("%s is not a Google OAuth client secret file: no \"installed\" client found", path)
+	}
+
+	creds := &GoogleCredentials{
+		ClientID:     strings.TrimSpace(file.Installed.ClientID),
+		ClientSecret: strings.TrimSpace(file.Installed.ClientSecret),
+	}
+	if creds.ClientID == "" || creds.ClientSecret == "" {
+		return nil, fmt.Errorf("%s is missing the client_id or client_secret", path)
+	}
+
+	return creds, nil
+}
+
 // Initialize sets up the OAuth2 config and loads credentials
 func (g *GoogleContactsProvider) Initialize() error {
 	creds, err := g.LoadCredentials()
@@ -118,6 +258,18 @@ func (g *GoogleContactsProvider) Initialize() error {
 	return nil
 }
 
+// ensureInitialized loads credentials on first use, so commands that only read
+// local storage never need working credentials
+func (g *GoogleContactsProvider) ensureInitialized() error {
+	if g.config != nil {
+		return nil
+	}
+	if err := g.Initialize(); err != nil {
+		return fmt.Errorf("failed to initialize provider: %w", err)
+	}
+	return nil
+}
+
 // GetAuthURL returns the URL users should visit to authorize the app
 func (g *GoogleContactsProvider) GetAuthURL() string {
 	if g.config == nil {
@@ -132,7 +284,7 @@ func (g *GoogleContactsProvider) GetAuthURL() string {
 // ExchangeAuthCode exchanges an authorization code for tokens
 func (g *GoogleContactsProvider) ExchangeAuthCode(ctx context.Context, code string) error {
 	if g.config == nil {
-		return fmt.Errorf("provider not initialized")
+		return ErrNotInitialized
 	}
 
 	token, err := g.config.Exchange(ctx, code)
@@ -157,12 +309,132 @@ func (g *GoogleContactsProvider) ExchangeAuthCode(ctx context.Context, code stri
 // GetHTTPClient returns an authenticated HTTP client
 func (g *GoogleContactsProvider) GetHTTPClient(ctx context.Context) (*oauth2.Config, *oauth2.Token, error) {
 	if g.config == nil || g.token == nil {
-		return nil, nil, fmt.Errorf("provider not initialized or not authenticated")
+		return nil, nil, fmt.Errorf("%w: not authorized with Google yet", ErrNotInitialized)
 	}
 
 	return g.config, g.token, nil
 }
 
+// authorizedClient refreshes the access token if needed and returns an
+// authenticated HTTP client. A revoked or expired refresh token is reported
+// as ErrReauthRequired.
+func (g *GoogleContactsProvider) authorizedClient(ctx context.Context) (*http.Client, error) {
+	if err := g.ensureInitialized(); err != nil {
+		return nil, err
+	}
+	if g.config == nil || g.token == nil {
+		return nil, fmt.Errorf("%w: not authorized with Google yet", ErrNotInitialized)
+	}
+
+	token, err := g.config.TokenSource(ctx, g.token).Token()
+	if err != nil {
+		slog.Error("google token refresh failed", "error", err)
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) && retrieveErr.ErrorCode == "invalid_grant" {
+			return nil, fmt.Errorf("%w: %v", ErrReauthRequired, err)
+		}
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+	g.token = token
+
+	client := g.config.Client(ctx, g.token)
+	client.Transport = &loggingTransport{base: client.Transport}
+	return client, nil
+}
+
+// loggingTransport logs each Google API request and its response status
+type loggingTransport struct {
+	base http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		slog.Error("google api request failed", "method", req.Method, "path", req.URL.Path, "error", err)
+		return nil, err
+	}
+
+	level := slog.LevelDebug
+	if resp.StatusCode >= 400 {
+		level = slog.LevelWarn
+	}
+	attrs := []any{"method", req.Method, "path", req.URL.Path, "status", resp.StatusCode, "duration", time.Since(start)}
+	for name, values := range resp.Header {
+		if isQuotaHeader(name) {
+			attrs = append(attrs, strings.ToLower(name), strings.Join(values, ","))
+		}
+	}
+	slog.Log(req.Context(), level, "google api request", attrs...)
+	return resp, nil
+}
+
+// isQuotaHeader reports whether a response header describes rate limits or
+// quota, such as Retry-After or X-RateLimit-Remaining
+func isQuotaHeader(name string) bool {
+	name = strings.ToLower(name)
+	return name == "retry-after" || strings.HasPrefix(name, "x-ratelimit-") || strings.Contains(name, "quota")
+}
+
+// QuotaStatus is the result of CheckQuota
+type QuotaStatus struct {
+	Latency       time.Duration // How long the check's request took
+	TotalContacts int           // Contacts in the address book, as Google counts them
+	SyncRequests  int           // Read requests a full sync makes at the current page size
+}
+
+// CheckQuota makes the cheapest People API read there is, a one-contact page
+// with no fields, to check the API can be called right now. Google doesn't
+// say how much quota remains, only that a request was refused for lack of
+// it: such an error is a *GoogleAPIError whose IsQuotaExceeded is true.
+func (g *GoogleContactsProvider) CheckQuota(ctx context.Context) (*QuotaStatus, error) {
+	httpClient, err := g.authorizedClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{
+		"personFields": []string{"metadata"},
+		"pageSize":     []string{"1"},
+		"sources":      []string{"READ_SOURCE_TYPE_CONTACT"},
+	}
+	apiURL := "https://people.googleapis.com/v1/people/me/connections?" + params.Encode()
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create contacts request: %w", err)
+	}
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the People API: %w", err)
+	}
+	defer resp.Body.Close()
+	latency := time.Since(start)
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, parseGoogleAPIError(resp.StatusCode, body)
+	}
+
+	var result struct {
+		TotalPeople int `json:"totalPeople"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode People API response: %w", err)
+	}
+
+	total := result.TotalPeople
+	if g.maxContacts > 0 {
+		total = min(total, g.maxContacts)
+	}
+	return &QuotaStatus{
+		Latency:       latency,
+		TotalContacts: result.TotalPeople,
+		SyncRequests:  max(1, (total+g.pageSize-1)/g.pageSize),
+	}, nil
+}
+
 // SaveSyncToken saves the sync token for incremental syncing
 func (g *GoogleContactsProvider) SaveSyncToken(token string) error {
 	g.syncToken = token
@@ -175,8 +447,13 @@ func (g *GoogleContactsProvider) GetSyncToken() string {
 }
 
 // getUserEmail fetches the user's email from Google's userinfo API
-func (g *GoogleContactsProvider) getUserEmail(httpClient *http.Client) (string, error) {
-	resp, err := httpClient.Get("https://www.googleapis.com/oauth2/v2/userinfo")
+func (g *GoogleContactsProvider) getUserEmail(ctx context.Context, httpClient *http.Client) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://www.googleapis.com/oauth2/v2/userinfo", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create userinfo request: %w", err)
+	}
+
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to get userinfo: %w", err)
 	}
@@ -184,7 +461,7 @@ func (g *GoogleContactsProvider) getUserEmail(httpClient *http.Client) (string,
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("userinfo request failed with status %d: %s", resp.StatusCode, string(body))
+		return "", fmt.Errorf("userinfo request failed: %w", parseGoogleAPIError(resp.StatusCode, body))
 	}
 
 	var userInfo struct {
@@ -206,13 +483,16 @@ type peopleAPIPerson struct {
 	ResourceName string                   `json:"resourceName"`
 	ETag         string                   `json:"etag"`
 	Names        []peopleAPIName          `json:"names"`
+	Nicknames    []peopleAPINickname      `json:"nicknames"`
 	PhoneNumbers []peopleAPIPhoneNumber   `json:"phoneNumbers"`
 	EmailAddresses []peopleAPIEmailAddress `json:"emailAddresses"`
 	Addresses    []peopleAPIAddress       `json:"addresses"`
 	Organizations []peopleAPIOrganization `json:"organizations"`
 	Birthdays    []peopleAPIBirthday      `json:"birthdays"`
+	Events       []peopleAPIEvent         `json:"events"`
 	Photos       []peopleAPIPhoto         `json:"photos"`
 	Biographies  []peopleAPIBiography     `json:"biographies"`
+	Memberships  []peopleAPIMembership    `json:"memberships"`
 }
 
 type peopleAPIName struct {
@@ -222,6 +502,10 @@ type peopleAPIName struct {
 	DisplayNameLastFirst string `json:"displayNameLastFirst"`
 }
 
+type peopleAPINickname struct {
+	Value string `json:"value"`
+}
+
 type peopleAPIPhoneNumber struct {
 	Value string `json:"value"`
 	Type  string `json:"type"`
@@ -247,12 +531,19 @@ type peopleAPIOrganization struct {
 	Department string `json:"department"`
 }
 
+type peopleAPIDate struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Day   int `json:"day"`
+}
+
 type peopleAPIBirthday struct {
-	Date struct {
-		Year  int `json:"year"`
-		Month int `json:"month"`
-		Day   int `json:"day"`
-	} `json:"date"`
+	Date peopleAPIDate `json:"date"`
+}
+
+type peopleAPIEvent struct {
+	Date peopleAPIDate `json:"date"`
+	Type string        `json:"type"` // e.g., "anniversary", "other"
 }
 
 type peopleAPIPhoto struct {
@@ -263,6 +554,130 @@ type peopleAPIBiography struct {
 	Value string `json:"value"`
 }
 
+type peopleAPIMembership struct {
+	ContactGroupMembership struct {
+		ContactGroupResourceName string `json:"contactGroupResourceName"`
+	} `json:"contactGroupMembership"`
+}
+
+// starredGroup is the system contact group of starred contacts, the only
+// system group shown: the others, such as "My Contacts", hold nearly everyone
+const starredGroup = "contactGroups/starred"
+
+// contactGroups returns the contact group names like fetchContactGroups,
+// but only requests them the first time; later calls, such as each
+// single-contact read, reuse them
+func (g *GoogleContactsProvider) contactGroups(ctx context.Context, httpClient *http.Client) (map[string]string, error) {
+	g.groupsMu.Lock()
+	defer g.groupsMu.Unlock()
+	if g.groups != nil {
+		return g.groups, nil
+	}
+	groups, err := g.fetchContactGroups(ctx, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	g.groups = groups
+	return groups, nil
+}
+
+// fetchContactGroups returns the names of the user's contact groups (Google
+// Contacts labels) and the starred group, keyed by resource name. It returns
+// nil without a request when memberships aren't among the person fields.
+func (g *GoogleContactsProvider) fetchContactGroups(ctx context.Context, httpClient *http.Client) (map[string]string, error) {
+	if !slices.Contains(g.personFields, "memberships") {
+		return nil, nil
+	}
+
+	groups := make(map[string]string)
+	pageToken := ""
+	for {
+		params := url.Values{
+			"groupFields": []string{"name,groupType"},
+			"pageSize":    []string{"1000"},
+		}
+		if pageToken != "" {
+			params.Set("pageToken", pageToken)
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", "https://people.googleapis.com/v1/contactGroups?"+params.Encode(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create contact groups request: %w", err)
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch contact groups: %w", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch contact groups: %w", parseGoogleAPIError(resp.StatusCode, body))
+		}
+
+		var result struct {
+			ContactGroups []struct {
+				ResourceName  string `json:"resourceName"`
+				Name          string `json:"name"`
+				FormattedName string `json:"formattedName"`
+				GroupType     string `json:"groupType"`
+			} `json:"contactGroups"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("failed to decode contact groups: %w", err)
+		}
+
+		for _, group := range result.ContactGroups {
+			if group.GroupType != "USER_CONTACT_GROUP" && group.ResourceName != starredGroup {
+				continue
+			}
+			name := group.FormattedName
+			if name == "" {
+				name = group.Name
+			}
+			groups[group.ResourceName] = name
+		}
+
+		if result.NextPageToken == "" {
+			return groups, nil
+		}
+		pageToken = result.NextPageToken
+	}
+}
+
+// groupNames returns the sorted names of the groups a person belongs to,
+// leaving out groups not in groups
+func groupNames(memberships []peopleAPIMembership, groups map[string]string) []string {
+	var names []string
+	for _, m := range memberships {
+		if name, ok := groups[m.ContactGroupMembership.ContactGroupResourceName]; ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// convertPeopleAPIDate converts a People API date to a time. Dates without a
+// year (e.g. birthdays entered as month/day only) use year 0.
+func convertPeopleAPIDate(date peopleAPIDate) *time.Time {
+	if date.Month <= 0 || date.Day <= 0 {
+		return nil
+	}
+	t := time.Date(date.Year, time.Month(date.Month), date.Day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+// peopleAPIDateOf converts a date to the People API's format, leaving out
+// a year of 0
+func peopleAPIDateOf(t time.Time) map[string]int {
+	date := map[string]int{"month": int(t.Month()), "day": t.Day()}
+	if t.Year() > 0 {
+		date["year"] = t.Year()
+	}
+	return date
+}
+
 // convertPeopleAPIToContact converts a People API person to our Contact struct
 func convertPeopleAPIToContact(person peopleAPIPerson) Contact {
 	// Extract just the ID from resourceName (e.g., "people/c8935729599066447265" -> "c8935729599066447265")
@@ -272,9 +687,13 @@ func convertPeopleAPIToContact(person peopleAPIPerson) Contact {
 		uid = parts[len(parts)-1]
 	}
 
+	// The Google ID doubles as the local UID, so re-syncing a contact always
+	// maps to the same file
 	contact := Contact{
-		UID:  uid,
-		ETag: person.ETag,
+		UID:        uid,
+		ETag:       person.ETag,
+		Source:     SourceGoogle,
+		ProviderID: uid,
 	}
 
 	// Names
@@ -283,6 +702,16 @@ func convertPeopleAPIToContact(person peopleAPIPerson) Contact {
 		contact.FullName = name.DisplayName
 		contact.GivenName = name.GivenName
 		contact.FamilyName = name.FamilyName
+		// Google can leave the display name empty when only the structured
+		// names are set
+		if strings.TrimSpace(contact.FullName) == "" {
+			contact.FullName = strings.TrimSpace(name.GivenName + " " + name.FamilyName)
+		}
+	}
+
+	// Nickname
+	if len(person.Nicknames) > 0 {
+		contact.Nickname = person.Nicknames[0].Value
 	}
 
 	// Phone numbers
@@ -337,11 +766,20 @@ func convertPeopleAPIToContact(person peopleAPIPerson) Contact {
 
 	// Birthday
 	if len(person.Birthdays) > 0 {
-		bday := person.Birthdays[0]
-		if bday.Date.Year > 0 && bday.Date.Month > 0 && bday.Date.Day > 0 {
-			t := time.Date(bday.Date.Year, time.Month(bday.Date.Month), bday.Date.Day, 0, 0, 0, 0, time.UTC)
-			contact.Birthday = &t
+		contact.Birthday = convertPeopleAPIDate(person.Birthdays[0].Date)
+	}
+
+	// Anniversary, and the other events so writes can send them back
+	for _, event := range person.Events {
+		date := convertPeopleAPIDate(event.Date)
+		if date == nil {
+			continue
 		}
+		if contact.Anniversary == nil && strings.EqualFold(event.Type, "anniversary") {
+			contact.Anniversary = date
+			continue
+		}
+		contact.OtherEvents = append(contact.OtherEvents, ContactEvent{Type: event.Type, Date: *date})
 	}
 
 	// Photo
@@ -358,32 +796,48 @@ func convertPeopleAPIToContact(person peopleAPIPerson) Contact {
 }
 
 // FetchContacts retrieves contacts from Google via People API
-func (g *GoogleContactsProvider) FetchContacts() ([]Contact, error) {
-	ctx := context.Background()
+func (g *GoogleContactsProvider) FetchContacts(ctx context.Context) ([]Contact, error) {
+	var allContacts []Contact
+	err := g.FetchContactPages(ctx, func(page []Contact, total int) error {
+		allContacts = append(allContacts, page...)
+		return nil
+	})
+	return allContacts, err
+}
 
-	if g.config == nil || g.token == nil {
-		return nil, fmt.Errorf("provider not initialized or not authenticated")
+// FetchContactPages retrieves contacts from Google via People API, passing
+// each page to fn as it arrives along with the number of contacts expected in
+// total. It stops if fn returns an error.
+func (g *GoogleContactsProvider) FetchContactPages(ctx context.Context, fn func(page []Contact, total int) error) error {
+	httpClient, err := g.authorizedClient(ctx)
+	if err != nil {
+		return err
 	}
 
-	httpClient := g.config.Client(ctx, g.token)
-
-	// Force a token refresh
-	newToken, err := g.config.TokenSource(ctx, g.token).Token()
+	// A full fetch refreshes the cached group names, picking up new labels
+	groups, err := g.fetchContactGroups(ctx, httpClient)
 	if err != nil {
-		return nil, fmt.Errorf("failed to refresh token: %w", err)
+		return err
 	}
-	g.token = newToken
-	httpClient = g.config.Client(ctx, g.token)
+	g.groupsMu.Lock()
+	g.groups = groups
+	g.groupsMu.Unlock()
 
 	// Fetch contacts from People API
-	var allContacts []Contact
+	fetched := 0
 	pageToken := ""
+	pageSize := g.pageSize
 
 	for {
+		size := pageSize
+		if g.maxContacts > 0 {
+			size = min(size, g.maxContacts-fetched)
+		}
+
 		// Build URL with person fields
 		params := url.Values{
-			"personFields": []string{"names,emailAddresses,phoneNumbers,addresses,organizations,birthdays,photos,biographies"},
-			"pageSize":     []string{"1000"},
+			"personFields": []string{strings.Join(g.personFields, ",")},
+			"pageSize":     []string{strconv.Itoa(size)},
 			"sources":      []string{"READ_SOURCE_TYPE_CONTACT"},
 		}
 		if pageToken != "" {
@@ -391,16 +845,34 @@ func (g *GoogleContactsProvider) FetchContacts() ([]Contact, error) {
 		}
 		apiURL := "https://people.googleapis.com/v1/people/me/connections?" + params.Encode()
 
-		resp, err := httpClient.Get(apiURL)
+		req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch contacts: %w", err)
+			return fmt.Errorf("failed to create contacts request: %w", err)
+		}
+
+		// Pages passed to fn before a failure or cancellation are kept
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() && ctx.Err() == nil && size > MinGooglePageSize {
+				pageSize = max(size/2, MinGooglePageSize)
+				slog.Warn("people api page timed out, retrying with a smaller page", "page_size", pageSize)
+				continue
+			}
+			return fmt.Errorf("failed to fetch contacts: %w", err)
 		}
 		defer resp.Body.Close()
 
 		bodyBytes, _ := io.ReadAll(resp.Body)
 
 		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("People API request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+			apiErr := parseGoogleAPIError(resp.StatusCode, bodyBytes)
+			if apiErr.IsTimeout() && size > MinGooglePageSize {
+				pageSize = max(size/2, MinGooglePageSize)
+				slog.Warn("people api page timed out, retrying with a smaller page", "page_size", pageSize)
+				continue
+			}
+			return fmt.Errorf("People API request failed: %w", apiErr)
 		}
 
 		var result struct {
@@ -411,25 +883,89 @@ func (g *GoogleContactsProvider) FetchContacts() ([]Contact, error) {
 		}
 
 		if err := json.Unmarshal(bodyBytes, &result); err != nil {
-			return nil, fmt.Errorf("failed to decode People API response: %w", err)
+			return fmt.Errorf("failed to decode People API response: %w", err)
 		}
 
 		// Convert People API persons to our Contact format
 		now := time.Now()
+		page := make([]Contact, 0, len(result.Connections))
 		for _, person := range result.Connections {
 			contact := convertPeopleAPIToContact(person)
+			contact.Groups = groupNames(person.Memberships, groups)
 			contact.LastSynced = &now
-			allContacts = append(allContacts, contact)
+			page = append(page, contact)
+		}
+		fetched += len(page)
+
+		total := result.TotalPeople
+		if g.maxContacts > 0 {
+			total = min(total, g.maxContacts)
+		}
+		if err := fn(page, max(total, fetched)); err != nil {
+			return err
 		}
 
 		// Check if there are more pages
 		if result.NextPageToken == "" {
 			break
 		}
+		if g.maxContacts > 0 && fetched >= g.maxContacts {
+			slog.Info("stopped fetching contacts at the configured limit", "max_contacts", g.maxContacts)
+			break
+		}
 		pageToken = result.NextPageToken
 	}
 
-	return allContacts, nil
+	return nil
+}
+
+// FetchContact retrieves a single contact from Google via People API,
+// returning nil if it doesn't exist
+func (g *GoogleContactsProvider) FetchContact(ctx context.Context, providerID string) (*Contact, error) {
+	httpClient, err := g.authorizedClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{
+		"personFields": []string{strings.Join(g.personFields, ",")},
+	}
+	apiURL := fmt.Sprintf("https://people.googleapis.com/v1/people/%s?%s", providerID, params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create contact request: %w", err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contact %s: %w", providerID, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch contact %s: %w", providerID, parseGoogleAPIError(resp.StatusCode, body))
+	}
+
+	var person peopleAPIPerson
+	if err := json.Unmarshal(body, &person); err != nil {
+		return nil, fmt.Errorf("failed to decode People API response: %w", err)
+	}
+
+	groups, err := g.contactGroups(ctx, httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	contact := convertPeopleAPIToContact(person)
+	contact.Groups = groupNames(person.Memberships, groups)
+	now := time.Now()
+	contact.LastSynced = &now
+	return &contact, nil
 }
 
 // convertContactToPeopleAPI converts our Contact struct to People API format
@@ -446,6 +982,15 @@ func convertContactToPeopleAPI(contact Contact) map[string]interface{} {
 		}
 	}
 
+	// Nickname
+	if contact.Nickname != "" {
+		person["nicknames"] = []map[string]interface{}{
+			{
+				"value": contact.Nickname,
+			},
+		}
+	}
+
 	// Phone numbers
 	if len(contact.PhoneNumbers) > 0 {
 		phones := make([]map[string]interface{}, len(contact.PhoneNumbers))
@@ -510,6 +1055,23 @@ func convertContactToPeopleAPI(contact Contact) map[string]interface{} {
 		}
 	}
 
+	// Events: the anniversary and any others. An update replaces them all,
+	// so an empty list clears them.
+	events := []map[string]interface{}{}
+	if contact.Anniversary != nil {
+		events = append(events, map[string]interface{}{
+			"type": "anniversary",
+			"date": peopleAPIDateOf(*contact.Anniversary),
+		})
+	}
+	for _, event := range contact.OtherEvents {
+		events = append(events, map[string]interface{}{
+			"type": event.Type,
+			"date": peopleAPIDateOf(event.Date),
+		})
+	}
+	person["events"] = events
+
 	// Biography/Notes
 	if contact.Notes != "" {
 		person["biographies"] = []map[string]interface{}{
@@ -522,92 +1084,254 @@ func convertContactToPeopleAPI(contact Contact) map[string]interface{} {
 	return person
 }
 
-// WriteContact writes (creates or updates) a contact in Google via People API
-func (g *GoogleContactsProvider) WriteContact(contact Contact) error {
-	ctx := context.Background()
+// WriteContact writes (creates or updates) a contact in Google via People
+// API, returning the ETag Google gave it
+func (g *GoogleContactsProvider) WriteContact(ctx context.Context, contact Contact) (string, error) {
+	httpClient, err := g.authorizedClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	personData := convertContactToPeopleAPI(contact)
 
-	if g.config == nil || g.token == nil {
-		return fmt.Errorf("provider not initialized or not authenticated")
+	var written peopleAPIPerson
+	if contact.IsProviderContact() {
+		updateFields := g.updatePersonFields(contact)
+		if len(updateFields) == 0 {
+			// Nothing the provider syncs is sent, so there's nothing to update
+			written = peopleAPIPerson{ResourceName: "people/" + contact.ProviderID, ETag: contact.ETag}
+		} else {
+			// Fields left out of the update aren't sent either
+			for field := range personData {
+				if !slices.Contains(updateFields, field) {
+					delete(personData, field)
+				}
+			}
+			// The ETag makes the update fail rather than overwrite changes
+			// made at Google since we last read the contact
+			personData["etag"] = contact.ETag
+
+			params := url.Values{}
+			params.Set("updatePersonFields", strings.Join(updateFields, ","))
+			apiURL := fmt.Sprintf("https://people.googleapis.com/v1/people/%s:updateContact?%s", contact.ProviderID, params.Encode())
+			written, err = g.writePerson(ctx, httpClient, "PATCH", apiURL, personData, contact.FullName)
+		}
+	} else {
+		written, err = g.writePerson(ctx, httpClient, "POST", "https://people.googleapis.com/v1/people:createContact", personData, contact.FullName)
+	}
+	if err != nil {
+		return "", err
 	}
 
-	httpClient := g.config.Client(ctx, g.token)
-	personData := convertContactToPeopleAPI(contact)
+	// Photos can't be set through updateContact or createContact and need
+	// their own call, made only when the photo changed since re-uploading
+	// costs quota and changes the photo's ETag
+	if !contact.photoChanged {
+		return written.ETag, nil
+	}
+	providerID := contact.ProviderID
+	if !contact.IsProviderContact() {
+		providerID = strings.TrimPrefix(written.ResourceName, "people/")
+	}
+	if providerID == "" {
+		return written.ETag, nil
+	}
+	etag, err := g.updateContactPhoto(ctx, httpClient, providerID, contact.PhotoData)
+	if err != nil {
+		return "", fmt.Errorf("failed to update photo for contact %s: %w", contact.FullName, err)
+	}
+	return etag, nil
+}
 
-	var req *http.Request
-	var apiURL string
-	var err error
+// personFieldContactFields copies, for each person field, the Contact fields
+// it's converted to from one contact to another
+var personFieldContactFields = map[string]func(dst *Contact, src Contact){
+	"names": func(dst *Contact, src Contact) {
+		dst.FullName, dst.GivenName, dst.FamilyName = src.FullName, src.GivenName, src.FamilyName
+	},
+	"nicknames":      func(dst *Contact, src Contact) { dst.Nickname = src.Nickname },
+	"phoneNumbers":   func(dst *Contact, src Contact) { dst.PhoneNumbers = src.PhoneNumbers },
+	"emailAddresses": func(dst *Contact, src Contact) { dst.EmailAddresses = src.EmailAddresses },
+	"addresses":      func(dst *Contact, src Contact) { dst.Addresses = src.Addresses },
+	"organizations":  func(dst *Contact, src Contact) { dst.Organization = src.Organization },
+	"birthdays":      func(dst *Contact, src Contact) { dst.Birthday = src.Birthday },
+	"events": func(dst *Contact, src Contact) {
+		dst.Anniversary, dst.OtherEvents = src.Anniversary, src.OtherEvents
+	},
+	"photos":      func(dst *Contact, src Contact) { dst.PhotoURL = src.PhotoURL },
+	"biographies": func(dst *Contact, src Contact) { dst.Notes = src.Notes },
+	"memberships": func(dst *Contact, src Contact) { dst.Groups = src.Groups },
+}
 
-	// Check if this is an existing contact or a new one
-	// UIDs from Google are numeric IDs, new ones are UUIDs
-	isExistingGoogleContact := !strings.Contains(contact.UID, "-") // UUIDs have dashes, Google IDs don't
+// KeepUnfetchedFields copies into fetched the fields of local that come from
+// person fields the provider isn't set to fetch, which fetched lacks
+func (g *GoogleContactsProvider) KeepUnfetchedFields(fetched *Contact, local Contact) {
+	for field, copyFields := range personFieldContactFields {
+		if !slices.Contains(g.personFields, field) {
+			copyFields(fetched, local)
+		}
+	}
+}
 
-	if isExistingGoogleContact {
-		// Update existing contact - reconstruct full resourceName
-		resourceName := fmt.Sprintf("people/%s", contact.UID)
-		apiURL = fmt.Sprintf("https://people.googleapis.com/v1/%s:updateContact", resourceName)
+// updatablePersonFields are the person fields WriteContact can update
+var updatablePersonFields = []string{
+	"names", "nicknames", "phoneNumbers", "emailAddresses", "addresses", "organizations",
+	"birthdays", "events", "biographies",
+}
 
-		// Add updatePersonFields to specify what fields to update
-		params := url.Values{}
-		params.Set("updatePersonFields", "names,phoneNumbers,emailAddresses,addresses,organizations,birthdays,biographies")
-		apiURL += "?" + params.Encode()
+// updatePersonFields returns the person fields an update of contact sends:
+// those the provider fetches, since the others were never read and sending
+// them would clear them at Google. Events are only sent when they changed,
+// since the update replaces every event on the contact.
+func (g *GoogleContactsProvider) updatePersonFields(contact Contact) []string {
+	var fields []string
+	for _, field := range updatablePersonFields {
+		if field == "events" && !contact.eventsChanged {
+			continue
+		}
+		if slices.Contains(g.personFields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
 
-		body, _ := json.Marshal(personData)
-		req, err = http.NewRequest("PATCH", apiURL, strings.NewReader(string(body)))
-	} else {
-		// Create new contact
-		apiURL = "https://people.googleapis.com/v1/people:createContact"
-		body, _ := json.Marshal(personData)
-		req, err = http.NewRequest("POST", apiURL, strings.NewReader(string(body)))
+// writePerson sends a person to createContact or updateContact and returns
+// the contact as written
+func (g *GoogleContactsProvider) writePerson(ctx context.Context, httpClient *http.Client, method, apiURL string, person map[string]interface{}, name string) (peopleAPIPerson, error) {
+	var written peopleAPIPerson
+	body, err := json.Marshal(person)
+	if err != nil {
+		return written, fmt.Errorf("failed to marshal contact %s: %w", name, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, apiURL, strings.NewReader(string(body)))
+	if err != nil {
+		return written, fmt.Errorf("failed to create request for contact %s: %w", name, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return written, fmt.Errorf("failed to update contact %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		apiErr := parseGoogleAPIError(resp.StatusCode, respBody)
+		if apiErr.IsETagMismatch() {
+			return written, fmt.Errorf("failed to update contact %s: %w: %w", name, ErrStale, apiErr)
+		}
+		return written, fmt.Errorf("failed to update contact %s: %w", name, apiErr)
+	}
+
+	// Both calls return the contact as written, with its new ETag
+	if err := json.NewDecoder(resp.Body).Decode(&written); err != nil {
+		return written, fmt.Errorf("failed to decode written contact %s: %w", name, err)
 	}
+	return written, nil
+}
+
+// updateContactPhoto uploads a contact's photo via the People API,
+// returning the contact's new ETag
+func (g *GoogleContactsProvider) updateContactPhoto(ctx context.Context, httpClient *http.Client, providerID string, photo []byte) (string, error) {
+	apiURL := fmt.Sprintf("https://people.googleapis.com/v1/people/%s:updateContactPhoto", providerID)
 
+	// []byte marshals as base64, which is what photoBytes expects. Asking
+	// for a person field gets the updated contact, and its ETag, back.
+	body, err := json.Marshal(map[string]interface{}{"photoBytes": photo, "personFields": "metadata"})
 	if err != nil {
-		return fmt.Errorf("failed to create request for contact %s: %w", contact.FullName, err)
+		return "", fmt.Errorf("failed to marshal photo: %w", err)
 	}
 
+	req, err := http.NewRequestWithContext(ctx, "PATCH", apiURL, strings.NewReader(string(body)))
+	if err != nil {
+		return "", fmt.Errorf("failed to create photo request: %w", err)
+	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to update contact %s: %w", contact.FullName, err)
+		return "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to update contact %s (status %d): %s", contact.FullName, resp.StatusCode, string(body))
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", parseGoogleAPIError(resp.StatusCode, respBody)
 	}
 
-	return nil
+	var result struct {
+		Person peopleAPIPerson `json:"person"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode photo response: %w", err)
+	}
+	return result.Person.ETag, nil
 }
 
-// DeleteContact deletes a contact from Google via People API
-func (g *GoogleContactsProvider) DeleteContact(uid string) error {
-	ctx := context.Background()
+// DeleteContacts deletes several contacts from Google in one People API
+// request (at most 500). Either all of them are deleted or none are.
+func (g *GoogleContactsProvider) DeleteContacts(ctx context.Context, providerIDs []string) error {
+	httpClient, err := g.authorizedClient(ctx)
+	if err != nil {
+		return err
+	}
 
-	if g.config == nil || g.token == nil {
-		return fmt.Errorf("provider not initialized or not authenticated")
+	resourceNames := make([]string, len(providerIDs))
+	for i, id := range providerIDs {
+		resourceNames[i] = fmt.Sprintf("people/%s", id)
+	}
+	body, err := json.Marshal(map[string][]string{"resourceNames": resourceNames})
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch delete: %w", err)
 	}
 
-	httpClient := g.config.Client(ctx, g.token)
+	apiURL := "https://people.googleapis.com/v1/people:batchDeleteContacts"
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, strings.NewReader(string(body)))
+	if err != nil {
+		return fmt.Errorf("failed to create batch delete request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete %d contacts: %w", len(providerIDs), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to delete %d contacts: %w", len(providerIDs), parseGoogleAPIError(resp.StatusCode, respBody))
+	}
+
+	return nil
+}
+
+// DeleteContact deletes a contact from Google via People API
+func (g *GoogleContactsProvider) DeleteContact(ctx context.Context, providerID string) error {
+	httpClient, err := g.authorizedClient(ctx)
+	if err != nil {
+		return err
+	}
 
 	// Reconstruct full resourceName
-	resourceName := fmt.Sprintf("people/%s", uid)
+	resourceName := fmt.Sprintf("people/%s", providerID)
 	apiURL := fmt.Sprintf("https://people.googleapis.com/v1/%s:deleteContact", resourceName)
 
-	req, err := http.NewRequest("DELETE", apiURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", apiURL, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create delete request for contact %s: %w", uid, err)
+		return fmt.Errorf("failed to create delete request for contact %s: %w", providerID, err)
 	}
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to delete contact %s: %w", uid, err)
+		return fmt.Errorf("failed to delete contact %s: %w", providerID, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to delete contact %s (status %d): %s", uid, resp.StatusCode, string(body))
+		return fmt.Errorf("failed to delete contact %s: %w", providerID, parseGoogleAPIError(resp.StatusCode, body))
 	}
 
 	return nil
@@ -0,0 +1,219 @@
+package contacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// peopleAPIEnableURL is where users enable the People API for their project
+const peopleAPIEnableURL = "https://console.cloud.google.com/apis/library/people.googleapis.com"
+
+// PeopleAPIQuotaURL is where users see their People API quotas and usage,
+// and request more quota
+const PeopleAPIQuotaURL = "https://console.cloud.google.com/apis/api/people.googleapis.com/quotas"
+
+// Reasons Google reports for permission failures
+const (
+	reasonServiceDisabled   = "SERVICE_DISABLED"
+	reasonAccessNotConfig   = "accessNotConfigured"
+	reasonScopeInsufficient = "ACCESS_TOKEN_SCOPE_INSUFFICIENT"
+	reasonInsufficientPerms = "insufficientPermissions"
+)
+
+// Reasons Google reports when a quota is used up
+const (
+	reasonRateLimitExceeded = "RATE_LIMIT_EXCEEDED"
+	reasonRateLimitLegacy   = "rateLimitExceeded"
+	reasonUserRateLimit     = "userRateLimitExceeded"
+	reasonQuotaExceeded     = "quotaExceeded"
+)
+
+// GoogleAPIError is a parsed Google API error response
+type GoogleAPIError struct {
+	StatusCode  int    // HTTP status code
+	Status      string // Canonical status, e.g. "PERMISSION_DENIED"
+	Message     string // Human-readable message from Google
+	Reason      string // Machine-readable reason, e.g. "SERVICE_DISABLED"
+	ActivateURL string // Link to enable the API, when Google provides one
+	Body        string // Raw response body, kept when it could not be parsed
+
+	// Set for quota errors, as far as Google reports them
+	QuotaMetric     string    // Quota that ran out, e.g. "people.googleapis.com/read_requests"
+	QuotaLimit      string    // Limit that was hit, e.g. "ReadRequestsPerMinutePerUser"
+	QuotaLimitValue string    // The limit's value, e.g. "90"
+	RetryAt         time.Time // When the request can be retried
+}
+
+// googleErrorEnvelope mirrors Google's standard JSON error envelope
+type googleErrorEnvelope struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+		Errors  []struct {
+			Reason string `json:"reason"`
+		} `json:"errors"`
+		Details []struct {
+			Reason     string            `json:"reason"`
+			Metadata   map[string]string `json:"metadata"`
+			RetryDelay string            `json:"retryDelay"` // From google.rpc.RetryInfo, e.g. "30s"
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// parseGoogleAPIError builds a GoogleAPIError from an error response
+func parseGoogleAPIError(statusCode int, body []byte) *GoogleAPIError {
+	apiErr := &GoogleAPIError{StatusCode: statusCode}
+
+	var envelope googleErrorEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.Error.Message == "" {
+		apiErr.Body = strings.TrimSpace(string(body))
+		return apiErr
+	}
+
+	apiErr.Status = envelope.Error.Status
+	apiErr.Message = envelope.Error.Message
+
+	var retryDelay time.Duration
+	for _, detail := range envelope.Error.Details {
+		if detail.Reason != "" && apiErr.Reason == "" {
+			apiErr.Reason = detail.Reason
+		}
+		if url := detail.Metadata["activationUrl"]; url != "" {
+			apiErr.ActivateURL = url
+		}
+		if v := detail.Metadata["quota_metric"]; v != "" {
+			apiErr.QuotaMetric = v
+		}
+		if v := detail.Metadata["quota_limit"]; v != "" {
+			apiErr.QuotaLimit = v
+		}
+		if v := detail.Metadata["quota_limit_value"]; v != "" {
+			apiErr.QuotaLimitValue = v
+		}
+		if d, err := time.ParseDuration(detail.RetryDelay); err == nil && d > 0 {
+			retryDelay = d
+		}
+	}
+	if apiErr.Reason == "" {
+		for _, e := range envelope.Error.Errors {
+			if e.Reason != "" {
+				apiErr.Reason = e.Reason
+				break
+			}
+		}
+	}
+
+	if apiErr.IsQuotaExceeded() {
+		apiErr.RetryAt = quotaRetryTime(time.Now(), retryDelay, apiErr.QuotaLimit)
+		slog.Warn("google api quota exceeded", "metric", apiErr.QuotaMetric, "limit", apiErr.QuotaLimit,
+			"limit_value", apiErr.QuotaLimitValue, "retry_at", apiErr.RetryAt)
+	}
+
+	return apiErr
+}
+
+// quotaRetryTime estimates when a request refused for quota can be retried:
+// after the delay Google asked for, if it gave one, otherwise when the limit
+// resets. Daily quotas reset at midnight Pacific time, and the People API's
+// other limits are per minute.
+func quotaRetryTime(now time.Time, delay time.Duration, limit string) time.Time {
+	switch {
+	case delay > 0:
+		return now.Add(delay)
+	case strings.Contains(limit, "PerDay"):
+		pacific, err := time.LoadLocation("America/Los_Angeles")
+		if err != nil {
+			pacific = time.FixedZone("PST", -8*60*60)
+		}
+		t := now.In(pacific)
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, pacific)
+	default:
+		return now.Add(time.Minute)
+	}
+}
+
+// IsAPIDisabled reports whether the People API is not enabled for the project
+func (e *GoogleAPIError) IsAPIDisabled() bool {
+	return e.Reason == reasonServiceDisabled || e.Reason == reasonAccessNotConfig
+}
+
+// IsInsufficientScope reports whether the token lacks a required OAuth scope
+func (e *GoogleAPIError) IsInsufficientScope() bool {
+	return e.Reason == reasonScopeInsufficient || e.Reason == reasonInsufficientPerms
+}
+
+// IsQuotaExceeded reports whether the request was refused because a quota or
+// rate limit was used up
+func (e *GoogleAPIError) IsQuotaExceeded() bool {
+	switch e.Reason {
+	case reasonRateLimitExceeded, reasonRateLimitLegacy, reasonUserRateLimit, reasonQuotaExceeded:
+		return true
+	}
+	return e.StatusCode == http.StatusTooManyRequests || e.Status == "RESOURCE_EXHAUSTED"
+}
+
+// IsETagMismatch reports whether an update was rejected because the contact
+// changed at Google since its ETag was read
+func (e *GoogleAPIError) IsETagMismatch() bool {
+	if e.StatusCode == http.StatusConflict || e.Status == "FAILED_PRECONDITION" {
+		return true
+	}
+	return e.StatusCode == http.StatusBadRequest && strings.Contains(strings.ToLower(e.Message), "etag")
+}
+
+// IsTimeout reports whether Google gave up on the request before answering,
+// which happens with very large pages
+func (e *GoogleAPIError) IsTimeout() bool {
+	return e.StatusCode == http.StatusGatewayTimeout || e.StatusCode == http.StatusRequestTimeout ||
+		e.Status == "DEADLINE_EXCEEDED"
+}
+
+func (e *GoogleAPIError) Error() string {
+	switch {
+	case e.IsAPIDisabled():
+		url := e.ActivateURL
+		if url == "" {
+			url = peopleAPIEnableURL
+		}
+		return fmt.Sprintf("People API not enabled for your Google Cloud project — enable it at %s and retry", url)
+	case e.IsInsufficientScope():
+		return "insufficient permissions for Google Contacts — re-run 'dunbar contacts init' to re-authorize"
+	case e.IsQuotaExceeded():
+		limit := ""
+		if e.QuotaLimit != "" {
+			limit = " (" + e.QuotaLimit
+			if e.QuotaLimitValue != "" {
+				limit += ": " + e.QuotaLimitValue
+			}
+			limit += ")"
+		}
+		return fmt.Sprintf("Google API quota exceeded%s — retry after %s, or request more quota at %s",
+			limit, formatRetryTime(e.RetryAt, time.Now()), PeopleAPIQuotaURL)
+	case e.Message != "":
+		if e.Status != "" {
+			return fmt.Sprintf("Google API error (status %d %s): %s", e.StatusCode, e.Status, e.Message)
+		}
+		return fmt.Sprintf("Google API error (status %d): %s", e.StatusCode, e.Message)
+	default:
+		return fmt.Sprintf("Google API error (status %d): %s", e.StatusCode, e.Body)
+	}
+}
+
+// formatRetryTime describes a retry time in local time, with the day only if
+// it isn't today, e.g. "15:04 (in 45s)"
+func formatRetryTime(at, now time.Time) string {
+	if at.IsZero() {
+		return "a minute"
+	}
+	at = at.Local()
+	layout := "15:04"
+	if y, m, d := at.Date(); y != now.Year() || m != now.Month() || d != now.Day() {
+		layout = "Jan 2 15:04"
+	}
+	return fmt.Sprintf("%s (in %s)", at.Format(layout), max(at.Sub(now), 0).Round(time.Second))
+}
@@ -0,0 +1,518 @@
+package contacts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/arjungandhi/dunbar/pkg/config"
+)
+
+// roundTrip converts a contact to the People API format and back, as a
+// write followed by a sync would
+func roundTrip(t *testing.T, contact Contact) Contact {
+	t.Helper()
+	data, err := json.Marshal(convertContactToPeopleAPI(contact))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var person peopleAPIPerson
+	if err := json.Unmarshal(data, &person); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return convertPeopleAPIToContact(person)
+}
+
+func TestGoogleProviderKeepsDataDir(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("DUNBAR_DIR", "")
+
+	first, err := config.OpenIn("")
+	if err != nil {
+		t.Fatalf("OpenIn() error = %v", err)
+	}
+	g, err := NewGoogleContactsProvider(first.DunbarDir)
+	if err != nil {
+		t.Fatalf("NewGoogleContactsProvider() error = %v", err)
+	}
+	if err := g.SaveCredentials(&GoogleCredentials{}); err != nil {
+		t.Fatalf("SaveCredentials() error = %v", err)
+	}
+	if err := g.SaveSyncToken("token"); err != nil {
+		t.Fatalf("SaveSyncToken() error = %v", err)
+	}
+
+	second, err := config.OpenIn("")
+	if err != nil {
+		t.Fatalf("OpenIn() error = %v", err)
+	}
+	if second.DataDir != first.DataDir {
+		t.Errorf("DataDir moved from %s to %s after creating the provider", first.DataDir, second.DataDir)
+	}
+}
+
+func TestGoogleProviderMovesLegacyCredentials(t *testing.T) {
+	dir := t.TempDir()
+	legacy := filepath.Join(dir, "contacts", "google_creds.json")
+	os.MkdirAll(filepath.Dir(legacy), 0755)
+	if err := os.WriteFile(legacy, []byte(`{"client_id": "id"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := NewGoogleContactsProvider(dir)
+	if err != nil {
+		t.Fatalf("NewGoogleContactsProvider() error = %v", err)
+	}
+	creds, err := g.LoadCredentials()
+	if err != nil || creds.ClientID != "id" {
+		t.Fatalf("LoadCredentials() = %+v, %v, want the legacy credentials", creds, err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("legacy credentials still at %s", legacy)
+	}
+}
+
+func TestPeopleAPIRoundTripKeepsNickname(t *testing.T) {
+	anniversary := time.Date(2015, time.June, 20, 0, 0, 0, 0, time.UTC)
+	got := roundTrip(t, Contact{
+		FullName:    "Ada Lovelace",
+		GivenName:   "Ada",
+		FamilyName:  "Lovelace",
+		Nickname:    "Countess",
+		Anniversary: &anniversary,
+	})
+
+	if got.Nickname != "Countess" {
+		t.Errorf("Nickname = %q, want %q", got.Nickname, "Countess")
+	}
+	if got.Anniversary == nil || !got.Anniversary.Equal(anniversary) {
+		t.Errorf("Anniversary = %v, want %v", got.Anniversary, anniversary)
+	}
+	if got.FullName != "Ada Lovelace" {
+		t.Errorf("FullName = %q, want %q", got.FullName, "Ada Lovelace")
+	}
+}
+
+func TestConvertPeopleAPIToContactFullName(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"given name only", `{"givenName": "Ada"}`, "Ada"},
+		{"family name only", `{"familyName": "Lovelace"}`, "Lovelace"},
+		{"given and family names", `{"givenName": "Ada", "familyName": "Lovelace"}`, "Ada Lovelace"},
+		{"blank display name", `{"displayName": " ", "givenName": "Ada"}`, "Ada"},
+		{"display name wins", `{"displayName": "Countess", "givenName": "Ada"}`, "Countess"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var person peopleAPIPerson
+			data := `{"resourceName": "people/c1", "names": [` + tt.json + `]}`
+			if err := json.Unmarshal([]byte(data), &person); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if got := convertPeopleAPIToContact(person).FullName; got != tt.want {
+				t.Errorf("FullName = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakePeopleAPI serves the People API connections list for people, paging
+// it by pageSize with the offset as the page token
+func fakePeopleAPI(people []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/people/me/connections" {
+			http.NotFound(w, r)
+			return
+		}
+		size, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+		start, _ := strconv.Atoi(r.URL.Query().Get("pageToken"))
+		end := min(start+size, len(people))
+
+		var connections []map[string]any
+		for i, name := range people[start:end] {
+			connections = append(connections, map[string]any{
+				"resourceName": fmt.Sprintf("people/c%d", start+i),
+				"names":        []map[string]string{{"displayName": name}},
+			})
+		}
+		next := ""
+		if end < len(people) {
+			next = strconv.Itoa(end)
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"connections":   connections,
+			"nextPageToken": next,
+			"totalPeople":   len(people),
+		})
+	})
+}
+
+// newTestGoogleProvider returns a provider authorized to make requests and
+// a context that sends them to api instead of Google
+func newTestGoogleProvider(t *testing.T, api http.Handler) (*GoogleContactsProvider, context.Context) {
+	t.Helper()
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	target, _ := url.Parse(srv.URL)
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient,
+		&http.Client{Transport: redirectTransport{target: target}})
+
+	g := &GoogleContactsProvider{
+		config:       &oauth2.Config{},
+		token:        &oauth2.Token{AccessToken: "test", Expiry: time.Now().Add(time.Hour)},
+		personFields: DefaultGooglePersonFields,
+		pageSize:     2,
+	}
+	return g, ctx
+}
+
+// redirectTransport sends every request to a test server
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (rt redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = rt.target.Scheme
+	req.URL.Host = rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestFetchContactPagesFollowsPageTokens(t *testing.T) {
+	people := []string{"Ada", "Grace", "Alan", "Barbara", "Edsger"}
+	tests := []struct {
+		name        string
+		maxContacts int
+		wantPages   [][]string
+		wantTotal   int
+	}{
+		{"all pages", 0, [][]string{{"Ada", "Grace"}, {"Alan", "Barbara"}, {"Edsger"}}, 5},
+		{"stops at max contacts", 3, [][]string{{"Ada", "Grace"}, {"Alan"}}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, ctx := newTestGoogleProvider(t, fakePeopleAPI(people))
+			g.personFields = []string{"names"} // Without memberships, so no contact groups
+			g.SetMaxContacts(tt.maxContacts)
+
+			var pages [][]string
+			err := g.FetchContactPages(ctx, func(page []Contact, total int) error {
+				if total != tt.wantTotal {
+					t.Errorf("page %d total = %d, want %d", len(pages)+1, total, tt.wantTotal)
+				}
+				var names []string
+				for _, c := range page {
+					names = append(names, c.FullName)
+				}
+				pages = append(pages, names)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("FetchContactPages() error = %v", err)
+			}
+			if !slices.EqualFunc(pages, tt.wantPages, slices.Equal[[]string]) {
+				t.Errorf("pages = %v, want %v", pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestWriteContactReturnsWrittenETag(t *testing.T) {
+	tests := []struct {
+		name    string
+		contact Contact
+		want    string
+		calls   []string
+	}{
+		{"update", Contact{ProviderID: "c1", ETag: "etag-1", FullName: "Ada"}, "etag-updated",
+			[]string{"PATCH /v1/people/c1:updateContact"}},
+		{"create", Contact{FullName: "Ada"}, "etag-created",
+			[]string{"POST /v1/people:createContact"}},
+		{"update with photo", Contact{ProviderID: "c1", ETag: "etag-1", FullName: "Ada", PhotoData: []byte{1}, photoChanged: true}, "etag-photo",
+			[]string{"PATCH /v1/people/c1:updateContact", "PATCH /v1/people/c1:updateContactPhoto"}},
+		{"create with photo", Contact{FullName: "Ada", PhotoData: []byte{1}, photoChanged: true}, "etag-photo",
+			[]string{"POST /v1/people:createContact", "PATCH /v1/people/c1:updateContactPhoto"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			g, ctx := newTestGoogleProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, r.Method+" "+r.URL.Path)
+				switch {
+				case strings.HasSuffix(r.URL.Path, ":updateContactPhoto"):
+					json.NewEncoder(w).Encode(map[string]any{"person": map[string]string{"resourceName": "people/c1", "etag": "etag-photo"}})
+				case strings.HasSuffix(r.URL.Path, ":updateContact"):
+					json.NewEncoder(w).Encode(map[string]string{"resourceName": "people/c1", "etag": "etag-updated"})
+				case strings.HasSuffix(r.URL.Path, ":createContact"):
+					json.NewEncoder(w).Encode(map[string]string{"resourceName": "people/c1", "etag": "etag-created"})
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+
+			etag, err := g.WriteContact(ctx, tt.contact)
+			if err != nil {
+				t.Fatalf("WriteContact() error = %v", err)
+			}
+			if etag != tt.want {
+				t.Errorf("WriteContact() ETag = %q, want %q", etag, tt.want)
+			}
+			if !slices.Equal(calls, tt.calls) {
+				t.Errorf("calls = %q, want %q", calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestWriteContactSendsEveryEvent(t *testing.T) {
+	date := func(y int, m time.Month, d int) *time.Time {
+		t := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	met := ContactEvent{Type: "first met", Date: *date(0, time.May, 4)}
+	stored := Contact{
+		UID: "u1", ProviderID: "c1", ETag: "etag-1", Source: SourceGoogle, FullName: "Ada",
+		Anniversary: date(2015, time.June, 20),
+		OtherEvents: []ContactEvent{met},
+	}
+
+	tests := []struct {
+		name        string
+		anniversary *time.Time
+		wantSent    bool     // Whether events are in updatePersonFields
+		wantEvents  []string // Types of the events sent
+	}{
+		{"unchanged", stored.Anniversary, false, nil},
+		{"anniversary changed", date(2016, time.June, 20), true, []string{"anniversary", "first met"}},
+		{"anniversary removed", nil, true, []string{"first met"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields string
+			var sent struct {
+				Events []peopleAPIEvent `json:"events"`
+			}
+			g, ctx := newTestGoogleProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fields = r.URL.Query().Get("updatePersonFields")
+				json.NewDecoder(r.Body).Decode(&sent)
+				json.NewEncoder(w).Encode(map[string]string{"resourceName": "people/c1", "etag": "etag-2"})
+			}))
+			cm := newTestManager(t, g)
+			if err := cm.store.Put(stored); err != nil {
+				t.Fatal(err)
+			}
+
+			edited := stored
+			edited.Anniversary = tt.anniversary
+			if err := cm.WriteContact(ctx, edited); err != nil {
+				t.Fatalf("WriteContact() error = %v", err)
+			}
+
+			if got := slices.Contains(strings.Split(fields, ","), "events"); got != tt.wantSent {
+				t.Errorf("updatePersonFields = %q, want events included %v", fields, tt.wantSent)
+			}
+			if !tt.wantSent {
+				return
+			}
+			var types []string
+			for _, event := range sent.Events {
+				types = append(types, event.Type)
+			}
+			if !slices.Equal(types, tt.wantEvents) {
+				t.Fatalf("events sent = %q, want %q", types, tt.wantEvents)
+			}
+			if last := sent.Events[len(sent.Events)-1]; last.Date != (peopleAPIDate{Month: 5, Day: 4}) {
+				t.Errorf("first met sent as %+v, want May 4 with no year", last.Date)
+			}
+		})
+	}
+}
+
+func TestPeopleAPIRoundTripKeepsOtherEvents(t *testing.T) {
+	var person peopleAPIPerson
+	data := `{"resourceName": "people/c1", "events": [
+		{"type": "first met", "date": {"month": 5, "day": 4}},
+		{"type": "anniversary", "date": {"year": 2015, "month": 6, "day": 20}},
+		{"type": "anniversary", "date": {"year": 2020, "month": 1, "day": 2}}
+	]}`
+	if err := json.Unmarshal([]byte(data), &person); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	contact := convertPeopleAPIToContact(person)
+	if contact.Anniversary == nil || contact.Anniversary.Year() != 2015 {
+		t.Errorf("Anniversary = %v, want the first one", contact.Anniversary)
+	}
+	if len(contact.OtherEvents) != 2 {
+		t.Fatalf("OtherEvents = %+v, want the other two", contact.OtherEvents)
+	}
+
+	got := roundTrip(t, contact)
+	if dateValue(got.Anniversary) != dateValue(contact.Anniversary) ||
+		!slices.EqualFunc(got.OtherEvents, contact.OtherEvents, func(a, b ContactEvent) bool {
+			return a.Type == b.Type && a.Date.Equal(b.Date)
+		}) {
+		t.Errorf("round trip = %v, %+v, want %v, %+v", got.Anniversary, got.OtherEvents, contact.Anniversary, contact.OtherEvents)
+	}
+}
+
+func TestWriteContactSendsOnlyFetchedFields(t *testing.T) {
+	contact := Contact{
+		UID: "u1", ProviderID: "c1", ETag: "etag-1", FullName: "Ada Lovelace",
+		PhoneNumbers: []PhoneNumber{{Value: "+1 555 0100", Type: "mobile"}},
+		Addresses:    []Address{{City: "London", Type: "home"}},
+		Notes:        "Met at a conference",
+	}
+	tests := []struct {
+		name       string
+		fields     []string
+		wantFields string // updatePersonFields, or "" for no update
+		wantETag   string
+	}{
+		{"default fields", DefaultGooglePersonFields, "names,nicknames,phoneNumbers,emailAddresses,addresses,organizations,birthdays,biographies", "etag-2"},
+		{"reduced fields", []string{"names", "phoneNumbers", "memberships"}, "names,phoneNumbers", "etag-2"},
+		{"nothing updatable", []string{"memberships", "photos"}, "", "etag-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields string
+			var sent map[string]any
+			calls := 0
+			g, ctx := newTestGoogleProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				fields = r.URL.Query().Get("updatePersonFields")
+				json.NewDecoder(r.Body).Decode(&sent)
+				json.NewEncoder(w).Encode(map[string]string{"resourceName": "people/c1", "etag": "etag-2"})
+			}))
+			if err := g.SetPersonFields(tt.fields); err != nil {
+				t.Fatal(err)
+			}
+
+			etag, err := g.WriteContact(ctx, contact)
+			if err != nil {
+				t.Fatalf("WriteContact() error = %v", err)
+			}
+			if etag != tt.wantETag {
+				t.Errorf("WriteContact() ETag = %q, want %q", etag, tt.wantETag)
+			}
+			if tt.wantFields == "" {
+				if calls != 0 {
+					t.Errorf("made %d requests, want none", calls)
+				}
+				return
+			}
+			if fields != tt.wantFields {
+				t.Errorf("updatePersonFields = %q, want %q", fields, tt.wantFields)
+			}
+			for key := range sent {
+				if key != "etag" && !strings.Contains(","+fields+",", ","+key+",") {
+					t.Errorf("sent %q, which isn't being updated", key)
+				}
+			}
+		})
+	}
+}
+
+func TestSyncContactsKeepsUnfetchedFields(t *testing.T) {
+	g, ctx := newTestGoogleProvider(t, fakePeopleAPI([]string{"Ada King"}))
+	if err := g.SetPersonFields([]string{"names", "biographies"}); err != nil {
+		t.Fatal(err)
+	}
+	cm := newTestManager(t, g)
+	if err := cm.store.Put(Contact{
+		UID: "c0", ProviderID: "c0", Source: SourceGoogle, FullName: "Ada Lovelace",
+		Addresses: []Address{{City: "London", Type: "home"}},
+		Notes:     "Met at a conference",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cm.SyncContacts(ctx); err != nil {
+		t.Fatalf("SyncContacts() error = %v", err)
+	}
+	got, err := cm.GetContact("c0")
+	if err != nil || got == nil {
+		t.Fatalf("GetContact() = %v, %v", got, err)
+	}
+	if got.FullName != "Ada King" {
+		t.Errorf("FullName = %q, want the fetched name", got.FullName)
+	}
+	if len(got.Addresses) != 1 || got.Addresses[0].City != "London" {
+		t.Errorf("Addresses = %+v, want the local ones kept, since they weren't fetched", got.Addresses)
+	}
+	if got.Notes != "" {
+		t.Errorf("Notes = %q, want them cleared, since they were fetched and Google has none", got.Notes)
+	}
+}
+
+func TestFetchContactCachesContactGroups(t *testing.T) {
+	groupRequests := 0
+	groupName := "Family"
+	person := map[string]any{
+		"resourceName": "people/c1",
+		"names":        []map[string]string{{"displayName": "Ada"}},
+		"memberships": []map[string]any{
+			{"contactGroupMembership": map[string]string{"contactGroupResourceName": "contactGroups/family"}},
+		},
+	}
+	g, ctx := newTestGoogleProvider(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/contactGroups":
+			groupRequests++
+			json.NewEncoder(w).Encode(map[string]any{
+				"contactGroups": []map[string]string{
+					{"resourceName": "contactGroups/family", "name": groupName, "groupType": "USER_CONTACT_GROUP"},
+				},
+			})
+		case "/v1/people/c1":
+			json.NewEncoder(w).Encode(person)
+		case "/v1/people/me/connections":
+			json.NewEncoder(w).Encode(map[string]any{"connections": []any{person}, "totalPeople": 1})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	fetch := func(want string) {
+		t.Helper()
+		contact, err := g.FetchContact(ctx, "c1")
+		if err != nil {
+			t.Fatalf("FetchContact: %v", err)
+		}
+		if !slices.Equal(contact.Groups, []string{want}) {
+			t.Errorf("Groups = %v, want [%s]", contact.Groups, want)
+		}
+	}
+
+	fetch("Family")
+	fetch("Family")
+	if groupRequests != 1 {
+		t.Errorf("two single-contact reads fetched the contact groups %d times, want once", groupRequests)
+	}
+
+	// A full fetch picks up renamed groups for later reads
+	groupName = "Relatives"
+	if _, err := g.FetchContacts(ctx); err != nil {
+		t.Fatalf("FetchContacts: %v", err)
+	}
+	fetch("Relatives")
+	if groupRequests != 2 {
+		t.Errorf("contact groups fetched %d times after a full fetch, want 2", groupRequests)
+	}
+}
@@ -0,0 +1,60 @@
+package contacts
+
+import (
+	"fmt"
+
+	"github.com/arjungandhi/dunbar/pkg/config"
+)
+
+// IgnoreContact keeps a contact out of dunbar: its local copy is removed,
+// leaving the provider's untouched, and syncs skip it until UnignoreContact
+// is called. Its notes are kept for if it comes back.
+func (cm *ContactManager) IgnoreContact(uid string) error {
+	contact, err := cm.GetContact(uid)
+	if err != nil {
+		return err
+	}
+	item := config.IgnoredItem{ID: uid}
+	if contact != nil {
+		item.Name = contact.DisplayName()
+	}
+
+	list, err := cm.config.LoadIgnoreList()
+	if err != nil {
+		return err
+	}
+	if config.AddIgnored(&list.Contacts, item) {
+		if err := cm.config.SaveIgnoreList(list); err != nil {
+			return err
+		}
+	}
+
+	if contact != nil {
+		if err := cm.store.Delete(uid); err != nil {
+			return fmt.Errorf("failed to remove contact: %w", err)
+		}
+	}
+	return nil
+}
+
+// UnignoreContact takes a contact off the ignore list, so the next sync
+// fetches it again
+func (cm *ContactManager) UnignoreContact(uid string) error {
+	list, err := cm.config.LoadIgnoreList()
+	if err != nil {
+		return err
+	}
+	if !config.RemoveIgnored(&list.Contacts, uid) {
+		return fmt.Errorf("contact not ignored: %s", uid)
+	}
+	return cm.config.SaveIgnoreList(list)
+}
+
+// ignoredUIDs returns the set of UIDs of ignored contacts
+func (cm *ContactManager) ignoredUIDs() (map[string]bool, error) {
+	list, err := cm.config.LoadIgnoreList()
+	if err != nil {
+		return nil, err
+	}
+	return config.IgnoredIDs(list.Contacts), nil
+}
@@ -0,0 +1,147 @@
+package contacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Note is a dated entry in a contact's local activity log. Notes are stored
+// separately from the contact so they never round-trip through the provider.
+type Note struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Text      string    `json:"text"`
+}
+
+// AddNote appends a timestamped note to a contact's log
+func (cm *ContactManager) AddNote(uid string, text string) (*Note, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("note cannot be empty")
+	}
+
+	contact, err := cm.GetContact(uid)
+	if err != nil {
+		return nil, err
+	}
+	if contact == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, uid)
+	}
+
+	notes, err := cm.readNotes(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	note := Note{
+		ID:        uuid.New().String(),
+		Timestamp: time.Now(),
+		Text:      text,
+	}
+	notes = append(notes, note)
+
+	if err := cm.writeNotes(uid, notes); err != nil {
+		return nil, err
+	}
+
+	return &note, nil
+}
+
+// ListNotes returns a contact's notes, newest first
+func (cm *ContactManager) ListNotes(uid string) ([]Note, error) {
+	notes, err := cm.readNotes(uid)
+	if err != nil {
+		return nil, err
+	}
+	sortNotesNewestFirst(notes)
+	return notes, nil
+}
+
+// ListAllNotes returns every contact's notes keyed by contact UID, newest first
+func (cm *ContactManager) ListAllNotes() (map[string][]Note, error) {
+	entries, err := os.ReadDir(cm.notesPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notes directory: %w", err)
+	}
+
+	all := make(map[string][]Note)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		uid := strings.TrimSuffix(entry.Name(), ".json")
+		notes, err := cm.readNotes(uid)
+		if err != nil {
+			return nil, err
+		}
+		sortNotesNewestFirst(notes)
+		all[uid] = notes
+	}
+
+	return all, nil
+}
+
+// readNotes reads a contact's notes file, returning nil if it doesn't exist
+func (cm *ContactManager) readNotes(uid string) ([]Note, error) {
+	data, err := os.ReadFile(filepath.Join(cm.notesPath, uid+".json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read notes file: %w", err)
+	}
+
+	var notes []Note
+	if err := json.Unmarshal(data, &notes); err != nil {
+		return nil, fmt.Errorf("failed to parse notes file: %w", err)
+	}
+
+	return notes, nil
+}
+
+// writeNotes writes a contact's notes file
+func (cm *ContactManager) writeNotes(uid string, notes []Note) error {
+	data, err := json.MarshalIndent(notes, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal notes: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(cm.notesPath, uid+".json"), data, 0644); err != nil {
+		return fmt.Errorf("failed to write notes file: %w", err)
+	}
+
+	return nil
+}
+
+// removeNotes deletes a contact's notes file, if it has one
+func (cm *ContactManager) removeNotes(uid string) error {
+	err := os.Remove(filepath.Join(cm.notesPath, uid+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete notes file: %w", err)
+	}
+	return nil
+}
+
+// moveNotes gives the notes kept under one contact UID to another, if there
+// are any
+func (cm *ContactManager) moveNotes(from, to string) error {
+	err := os.Rename(filepath.Join(cm.notesPath, from+".json"), filepath.Join(cm.notesPath, to+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to move notes file: %w", err)
+	}
+	return nil
+}
+
+func sortNotesNewestFirst(notes []Note) {
+	sort.SliceStable(notes, func(i, j int) bool {
+		return notes[i].Timestamp.After(notes[j].Timestamp)
+	})
+}
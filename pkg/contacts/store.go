@@ -0,0 +1,448 @@
+package contacts
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Storage backends selectable with the contacts_store config option
+const (
+	StoreFiles = "files" // One JSON file per contact (default)
+	StoreJSON  = "json"  // A single JSON index holding every contact
+)
+
+// ContactStore persists contacts locally, keyed by UID
+type ContactStore interface {
+	// Get returns the contact with the given UID, or nil if there is none
+	Get(uid string) (*Contact, error)
+	// List returns every stored contact
+	List() ([]Contact, error)
+	// Put adds or replaces contacts
+	Put(contacts ...Contact) error
+	// Delete removes a contact, returning an error wrapping os.ErrNotExist
+	// if there is none
+	Delete(uid string) error
+}
+
+// newContactStore creates the configured store for a set of contacts. dir is
+// the per-file directory; the JSON index lives next to it as dir + ".json".
+// Contacts stored per-file are moved into a newly created JSON index. With
+// compact set, contacts are written as compact rather than indented JSON;
+// either is read back.
+func newContactStore(kind, dir string, compact bool) (ContactStore, error) {
+	switch kind {
+	case "", StoreFiles:
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create contacts directory: %w", err)
+		}
+		return &fileStore{dir: dir, compact: compact}, nil
+
+	case StoreJSON:
+		store := &jsonStore{path: dir + ".json", compact: compact}
+		if err := migrateToJSONStore(dir, store); err != nil {
+			return nil, err
+		}
+		return store, nil
+
+	default:
+		return nil, fmt.Errorf("unknown contacts store %q (expected %q or %q)", kind, StoreFiles, StoreJSON)
+	}
+}
+
+// migrateToJSONStore moves contacts from the per-file layout into a JSON index
+// that doesn't exist yet
+func migrateToJSONStore(dir string, store *jsonStore) error {
+	if _, err := os.Stat(store.path); err == nil {
+		return nil
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+
+	files := &fileStore{dir: dir, compact: store.compact}
+	contacts, err := files.List()
+	if err != nil {
+		return fmt.Errorf("failed to read contacts to migrate: %w", err)
+	}
+	if len(contacts) == 0 {
+		return nil
+	}
+
+	if err := store.Put(contacts...); err != nil {
+		return fmt.Errorf("failed to migrate contacts: %w", err)
+	}
+
+	// The index now holds everything, so the individual files can go
+	for _, c := range contacts {
+		if err := files.Delete(c.UID); err != nil {
+			slog.Warn("failed to remove migrated contact file", "uid", c.UID, "error", err)
+		}
+	}
+	slog.Info("migrated contacts to json store", "count", len(contacts), "path", store.path)
+	return nil
+}
+
+// marshalStored encodes contacts for storage, indented unless compact is set
+func marshalStored(v any, compact bool) ([]byte, error) {
+	if compact {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// fileStore stores each contact as DIR/<uid>.json
+type fileStore struct {
+	dir     string
+	compact bool // Write compact JSON rather than indented
+}
+
+func (s *fileStore) path(uid string) string {
+	return filepath.Join(s.dir, uid+".json")
+}
+
+func (s *fileStore) Get(uid string) (*Contact, error) {
+	filePath := s.path(uid)
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil // Contact not found
+		}
+		return nil, fmt.Errorf("failed to read contact file: %w", err)
+	}
+
+	contact, err := s.parse(filePath, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse contact file: %w", err)
+	}
+
+	return &contact, nil
+}
+
+func (s *fileStore) List() ([]Contact, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read contacts directory: %w", err)
+	}
+
+	var contacts []Contact
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		// Skip non-contact files
+		if entry.Name() == "google_creds.json" || entry.Name() == "config.json" {
+			continue
+		}
+
+		filePath := filepath.Join(s.dir, entry.Name())
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read contact file %s: %w", entry.Name(), err)
+		}
+
+		contact, err := s.parse(filePath, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse contact file %s: %w", entry.Name(), err)
+		}
+
+		contacts = append(contacts, contact)
+	}
+
+	return contacts, nil
+}
+
+func (s *fileStore) Put(contacts ...Contact) error {
+	for _, contact := range contacts {
+		data, err := marshalStored(contact, s.compact)
+		if err != nil {
+			return fmt.Errorf("failed to marshal contact: %w", err)
+		}
+
+		if err := os.WriteFile(s.path(contact.UID), data, 0644); err != nil {
+			return fmt.Errorf("failed to write contact file: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *fileStore) Delete(uid string) error {
+	if err := os.Remove(s.path(uid)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s: %w", ErrNotFound, uid, err)
+		}
+		return fmt.Errorf("failed to delete contact file: %w", err)
+	}
+	return nil
+}
+
+// parse decodes a contact file, migrating files written before contacts
+// recorded their provenance
+func (s *fileStore) parse(filePath string, data []byte) (Contact, error) {
+	var contact Contact
+	if err := json.Unmarshal(data, &contact); err != nil {
+		return Contact{}, err
+	}
+
+	if migrateProvenance(&contact) {
+		// Best effort: the migration is re-applied on the next load if this fails
+		if migrated, err := marshalStored(contact, s.compact); err == nil {
+			if err := os.WriteFile(filePath, migrated, 0644); err != nil {
+				slog.Warn("failed to save migrated contact", "uid", contact.UID, "error", err)
+			}
+		}
+	}
+
+	return contact, nil
+}
+
+// jsonStore keeps every contact in one JSON file, so listing is a single read
+// and a sync is a single write
+type jsonStore struct {
+	path    string
+	compact bool // Write compact JSON rather than indented
+}
+
+// load reads the index, returning an empty one if the file doesn't exist yet
+func (s *jsonStore) load() (map[string]Contact, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(map[string]Contact), nil
+		}
+		return nil, fmt.Errorf("failed to read contacts store: %w", err)
+	}
+
+	var contacts map[string]Contact
+	if err := json.Unmarshal(data, &contacts); err != nil {
+		return nil, fmt.Errorf("failed to parse contacts store %s: %w", s.path, err)
+	}
+	if contacts == nil {
+		contacts = make(map[string]Contact)
+	}
+
+	migrated := false
+	for uid, c := range contacts {
+		if migrateProvenance(&c) {
+			contacts[uid] = c
+			migrated = true
+		}
+	}
+	if migrated {
+		if err := s.save(contacts); err != nil {
+			slog.Warn("failed to save migrated contacts", "error", err)
+		}
+	}
+
+	return contacts, nil
+}
+
+// save atomically replaces the index
+func (s *jsonStore) save(contacts map[string]Contact) error {
+	data, err := marshalStored(contacts, s.compact)
+	if err != nil {
+		return fmt.Errorf("failed to marshal contacts: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write contacts store: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write contacts store: %w", err)
+	}
+	return nil
+}
+
+func (s *jsonStore) Get(uid string) (*Contact, error) {
+	contacts, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	contact, ok := contacts[uid]
+	if !ok {
+		return nil, nil // Contact not found
+	}
+	return &contact, nil
+}
+
+func (s *jsonStore) List() ([]Contact, error) {
+	contacts, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Contact, 0, len(contacts))
+	for _, c := range contacts {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].UID < list[j].UID
+	})
+	return list, nil
+}
+
+func (s *jsonStore) Put(contacts ...Contact) error {
+	existing, err := s.load()
+	if err != nil {
+		return err
+	}
+	for _, c := range contacts {
+		existing[c.UID] = c
+	}
+	return s.save(existing)
+}
+
+func (s *jsonStore) Delete(uid string) error {
+	contacts, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := contacts[uid]; !ok {
+		return fmt.Errorf("%w: %s: %w", ErrNotFound, uid, os.ErrNotExist)
+	}
+	delete(contacts, uid)
+	return s.save(contacts)
+}
+
+// cachedStore keeps an in-memory copy of another store's contacts, loaded on
+// first use and kept current by writing through. It assumes no other process
+// changes the underlying store while the manager is in use. Contacts are
+// cloned going in and out, so callers never share the cache's slices.
+type cachedStore struct {
+	inner ContactStore
+
+	mu       sync.Mutex
+	contacts map[string]Contact // nil until first loaded
+	order    []string           // UIDs in the inner store's list order
+}
+
+// newCachedStore wraps a store with an in-memory cache
+func newCachedStore(inner ContactStore) *cachedStore {
+	return &cachedStore{inner: inner}
+}
+
+// ensureLoaded fills the cache from the inner store. Callers hold s.mu.
+func (s *cachedStore) ensureLoaded() error {
+	if s.contacts != nil {
+		return nil
+	}
+
+	list, err := s.inner.List()
+	if err != nil {
+		return err
+	}
+
+	s.contacts = make(map[string]Contact, len(list))
+	s.order = make([]string, 0, len(list))
+	for _, c := range list {
+		s.contacts[c.UID] = c
+		s.order = append(s.order, c.UID)
+	}
+	return nil
+}
+
+// clone returns a copy of c that shares no slices or pointers with it
+func (c Contact) clone() Contact {
+	c.PhoneNumbers = slices.Clone(c.PhoneNumbers)
+	c.EmailAddresses = slices.Clone(c.EmailAddresses)
+	c.Addresses = slices.Clone(c.Addresses)
+	c.OtherEvents = slices.Clone(c.OtherEvents)
+	c.PhotoData = slices.Clone(c.PhotoData)
+	c.Tags = slices.Clone(c.Tags)
+	c.Groups = slices.Clone(c.Groups)
+	if c.Organization != nil {
+		org := *c.Organization
+		c.Organization = &org
+	}
+	c.Birthday = cloneTime(c.Birthday)
+	c.Anniversary = cloneTime(c.Anniversary)
+	c.LastModified = cloneTime(c.LastModified)
+	c.LastEdited = cloneTime(c.LastEdited)
+	c.LastSynced = cloneTime(c.LastSynced)
+	return c
+}
+
+// cloneTime returns a copy of t, or nil if t is nil
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
+
+func (s *cachedStore) Get(uid string) (*Contact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ensureLoaded(); err != nil {
+		return nil, err
+	}
+	contact, ok := s.contacts[uid]
+	if !ok {
+		return nil, nil // Contact not found
+	}
+	contact = contact.clone()
+	return &contact, nil
+}
+
+func (s *cachedStore) List() ([]Contact, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ensureLoaded(); err != nil {
+		return nil, err
+	}
+
+	// Return fresh copies so callers can sort or modify them freely
+	list := make([]Contact, 0, len(s.order))
+	for _, uid := range s.order {
+		list = append(list, s.contacts[uid].clone())
+	}
+	return list, nil
+}
+
+func (s *cachedStore) Put(contacts ...Contact) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.inner.Put(contacts...); err != nil {
+		// The inner store may be partially written; reload on next use
+		s.contacts = nil
+		return err
+	}
+
+	if s.contacts != nil {
+		for _, c := range contacts {
+			if _, ok := s.contacts[c.UID]; !ok {
+				s.order = append(s.order, c.UID)
+			}
+			s.contacts[c.UID] = c.clone()
+		}
+	}
+	return nil
+}
+
+func (s *cachedStore) Delete(uid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.inner.Delete(uid); err != nil {
+		return err
+	}
+
+	if s.contacts != nil {
+		delete(s.contacts, uid)
+		s.order = slices.DeleteFunc(s.order, func(u string) bool { return u == uid })
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package contacts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestStoreDeleteMissingContact(t *testing.T) {
+	for _, kind := range []string{StoreFiles, StoreJSON} {
+		t.Run(kind, func(t *testing.T) {
+			inner, err := newContactStore(kind, filepath.Join(t.TempDir(), "people"), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := inner.Put(Contact{UID: "c1", FullName: "Ada Lovelace"}); err != nil {
+				t.Fatal(err)
+			}
+
+			for name, store := range map[string]ContactStore{"plain": inner, "cached": newCachedStore(inner)} {
+				err := store.Delete("missing")
+				if !errors.Is(err, ErrNotFound) || !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("%s Delete(missing) = %v, want ErrNotFound and os.ErrNotExist", name, err)
+				}
+			}
+			if err := inner.Delete("c1"); err != nil {
+				t.Errorf("Delete(c1) = %v", err)
+			}
+		})
+	}
+}
+
+func TestCachedStoreReturnsCopies(t *testing.T) {
+	inner, err := newContactStore(StoreFiles, filepath.Join(t.TempDir(), "people"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newCachedStore(inner)
+	tags := make([]string, 2, 4) // Room to append in place
+	tags[0], tags[1] = "family", "work"
+	put := Contact{UID: "c1", FullName: "Ada", Tags: tags, PhoneNumbers: []PhoneNumber{{Value: "1", Type: "mobile"}}}
+	if err := store.Put(put); err != nil {
+		t.Fatal(err)
+	}
+	put.Tags[0] = "changed after put"
+
+	got, _ := store.Get("c1")
+	got.Tags[1] = "changed after get"
+	got.PhoneNumbers[0].Value = "changed after get"
+	list, _ := store.List()
+	list[0].Tags = append(list[0].Tags[:1], "appended after list")
+
+	again, _ := store.Get("c1")
+	if !slices.Equal(again.Tags, []string{"family", "work"}) {
+		t.Errorf("cached Tags = %q, want them unchanged", again.Tags)
+	}
+	if again.PhoneNumbers[0].Value != "1" {
+		t.Errorf("cached phone = %q, want it unchanged", again.PhoneNumbers[0].Value)
+	}
+}
+
+func TestSetTagsLeavesListedContactsAlone(t *testing.T) {
+	cm := newTestManager(t, &fakeProvider{contacts: map[string]Contact{}})
+	if err := cm.store.Put(Contact{UID: "c1", FullName: "Ada", Tags: []string{"family", "work"}}); err != nil {
+		t.Fatal(err)
+	}
+	listed, err := cm.ListContacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cm.SetTags([]string{"c1"}, "family", false); err != nil {
+		t.Fatalf("SetTags() error = %v", err)
+	}
+	if !slices.Equal(listed[0].Tags, []string{"family", "work"}) {
+		t.Errorf("listed Tags = %q after SetTags, want the copy left alone", listed[0].Tags)
+	}
+	stored, _ := cm.GetContact("c1")
+	if !slices.Equal(stored.Tags, []string{"work"}) {
+		t.Errorf("stored Tags = %q, want [work]", stored.Tags)
+	}
+}
+
+// BenchmarkListContacts lists a 1000-contact store as the TUI does on each
+// keystroke, reading every contact from disk and with the cache in front.
+// Measured on a 1-core Xeon: files 17 ms uncached vs 0.5-0.75 ms cached, json
+// 6-8.5 ms vs 0.7 ms, the cached figures including cloning every contact.
+func BenchmarkListContacts(b *testing.B) {
+	list := make([]Contact, 1000)
+	for i := range list {
+		list[i] = Contact{
+			UID:            fmt.Sprintf("c%04d", i),
+			FullName:       fmt.Sprintf("Contact %04d", i),
+			EmailAddresses: []EmailAddress{{Value: fmt.Sprintf("contact%04d@example.com", i), Type: "home"}},
+			PhoneNumbers:   []PhoneNumber{{Value: fmt.Sprintf("+1 555 %04d", i), Type: "mobile"}},
+			Notes:          "Met at a conference",
+			Tags:           []string{"work"},
+		}
+	}
+
+	for _, kind := range []string{StoreFiles, StoreJSON} {
+		store, err := newContactStore(kind, filepath.Join(b.TempDir(), "people"), false)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := store.Put(list...); err != nil {
+			b.Fatal(err)
+		}
+
+		for _, cached := range []bool{false, true} {
+			name := kind + "/uncached"
+			s := store
+			if cached {
+				name = kind + "/cached"
+				s = newCachedStore(store)
+			}
+			b.Run(name, func(b *testing.B) {
+				for range b.N {
+					got, err := s.List()
+					if err != nil {
+						b.Fatal(err)
+					}
+					if len(got) != len(list) {
+						b.Fatalf("List() returned %d contacts, want %d", len(got), len(list))
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package contacts
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// Phone numbers have at most 15 digits (E.164); anything under 3 is not a number
+const (
+	minPhoneDigits = 3
+	maxPhoneDigits = 15
+)
+
+// ValidateFullName checks that a contact has a name
+func ValidateFullName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	return nil
+}
+
+// ValidateEmail checks that an email address is a single bare address such
+// as "ada@example.com"
+func ValidateEmail(email string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return fmt.Errorf("email is required")
+	}
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return fmt.Errorf("%q is not a valid email address", email)
+	}
+
+	// mail.ParseAddress accepts "a@b"; require a dot in the domain
+	domain := email[strings.LastIndex(email, "@")+1:]
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return fmt.Errorf("%q is not a valid email address", email)
+	}
+
+	return nil
+}
+
+// ValidatePhone checks that a phone number contains only phone characters and
+// normalizes to a plausible number of digits
+func ValidatePhone(phone string) error {
+	phone = strings.TrimSpace(phone)
+	if phone == "" {
+		return fmt.Errorf("phone number is required")
+	}
+
+	if strings.Trim(phone, "+0123456789 -().") != "" {
+		return fmt.Errorf("%q is not a valid phone number: use digits, spaces, and + - ( ) . only", phone)
+	}
+
+	digits := strings.TrimPrefix(NormalizePhone(phone), "+")
+	if len(digits) < minPhoneDigits || len(digits) > maxPhoneDigits {
+		return fmt.Errorf("%q is not a valid phone number: expected %d to %d digits", phone, minPhoneDigits, maxPhoneDigits)
+	}
+
+	return nil
+}
+
+// Validate checks the contact's name, email addresses, and phone numbers,
+// returning the first problem found
+func (c *Contact) Validate() error {
+	if err := ValidateFullName(c.FullName); err != nil {
+		return err
+	}
+	for _, e := range c.EmailAddresses {
+		if err := ValidateEmail(e.Value); err != nil {
+			return err
+		}
+	}
+	for _, p := range c.PhoneNumbers {
+		if err := ValidatePhone(p.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateChanges checks only what differs from before, the stored version
+// of the contact (nil for a new one): a changed name and added or edited
+// email addresses and phone numbers. Data that came from the provider
+// unchanged is never rejected, even if Validate would reject it.
+func (c *Contact) ValidateChanges(before *Contact) error {
+	if before == nil || c.FullName != before.FullName {
+		if err := ValidateFullName(c.FullName); err != nil {
+			return err
+		}
+	}
+
+	var oldEmails, oldPhones map[string]bool
+	if before != nil {
+		oldEmails = make(map[string]bool, len(before.EmailAddresses))
+		for _, e := range before.EmailAddresses {
+			oldEmails[e.Value] = true
+		}
+		oldPhones = make(map[string]bool, len(before.PhoneNumbers))
+		for _, p := range before.PhoneNumbers {
+			oldPhones[p.Value] = true
+		}
+	}
+	for _, e := range c.EmailAddresses {
+		if oldEmails[e.Value] {
+			continue
+		}
+		if err := ValidateEmail(e.Value); err != nil {
+			return err
+		}
+	}
+	for _, p := range c.PhoneNumbers {
+		if oldPhones[p.Value] {
+			continue
+		}
+		if err := ValidatePhone(p.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// FileName is the name of the log file inside the dunbar data directory
+const FileName = "dunbar.log"
+
+// MaxSize is how large the log file may grow before it's rotated to
+// FileName.1, replacing the previous one
+const MaxSize = 5 << 20
+
+// console is the level of the stderr handler, nil unless verbose
+var console *slog.LevelVar
+
+// Setup installs the default slog logger. Records at info level and above
+// are appended to DataDir/dunbar.log, rotated once it passes MaxSize; when
+// verbose is set, debug records such as provider requests are included and
+// everything is also written to stderr. Normal CLI output never goes through
+// the logger.
+func Setup(dataDir string, verbose bool) error {
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	path := filepath.Join(dataDir, FileName)
+	if err := rotate(path); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	level := slog.LevelInfo
+	if verbose {
+		level = slog.LevelDebug
+	}
+	handlers := []slog.Handler{
+		slog.NewTextHandler(file, &slog.HandlerOptions{Level: level}),
+	}
+
+	if verbose {
+		console = new(slog.LevelVar)
+		console.Set(slog.LevelDebug)
+		handlers = append(handlers, slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: console}))
+	}
+
+	slog.SetDefault(slog.New(multiHandler(handlers)))
+	return nil
+}
+
+// rotate moves the log file at path to path.1 if it has reached MaxSize
+func rotate(path string) error {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() < MaxSize {
+		return nil
+	}
+	if err := os.Rename(path, path+".1"); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	return nil
+}
+
+// DisableConsole stops log records from reaching the terminal. TUIs call this
+// before starting, since writing to the terminal would corrupt the screen.
+func DisableConsole() {
+	if console != nil {
+		// Higher than any level we log at
+		console.Set(slog.LevelError + 1)
+	}
+}
+
+// Discard installs a logger that drops everything, used when the log file
+// can't be opened
+func Discard() {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// multiHandler fans records out to several handlers
+type multiHandler []slog.Handler
+
+func (h multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h {
+		if handler.Enabled(ctx, r.Level) {
+			errs = append(errs, handler.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(multiHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (h multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make(multiHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return handlers
+}
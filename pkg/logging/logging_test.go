@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetupRotatesLargeLog(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	old := bytes.Repeat([]byte("x"), MaxSize)
+	if err := os.WriteFile(path, old, 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer slog.SetDefault(slog.Default())
+
+	if err := Setup(dir, false); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	slog.Info("after rotation")
+
+	rotated, err := os.ReadFile(path + ".1")
+	if err != nil || !bytes.Equal(rotated, old) {
+		t.Fatalf("%s.1 holds %d bytes (%v), want the old %d-byte log", FileName, len(rotated), err, len(old))
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "after rotation") || len(data) >= MaxSize {
+		t.Errorf("new log = %q, want only the new record", data)
+	}
+}
+
+func TestSetupLogsDebugOnlyWhenVerbose(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	for _, verbose := range []bool{false, true} {
+		dir := t.TempDir()
+		if err := Setup(dir, verbose); err != nil {
+			t.Fatalf("Setup: %v", err)
+		}
+		DisableConsole()
+		slog.Debug("request sent")
+		slog.Info("synced")
+
+		data, err := os.ReadFile(filepath.Join(dir, FileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(string(data), "request sent"); got != verbose {
+			t.Errorf("verbose %v: debug record logged = %v, want %v", verbose, got, verbose)
+		}
+		if !strings.Contains(string(data), "synced") {
+			t.Errorf("verbose %v: info record missing from %q", verbose, data)
+		}
+	}
+}
@@ -3,12 +3,22 @@ package messages
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/arjungandhi/dunbar/pkg/util"
 	beeperapi "github.com/beeper/desktop-api-go"
 	"github.com/beeper/desktop-api-go/option"
+	"golang.org/x/sync/errgroup"
 )
 
 // BeeperCredentials holds the Beeper access token
@@ -21,6 +31,84 @@ type BeeperProvider struct {
 	client      *beeperapi.Client
 	accessToken string
 	dunbarDir   string
+	progress    io.Writer // Where sync progress is printed
+	concurrency int       // How many chats to fetch messages for at once
+	accountIDs  []string  // Only sync these accounts; empty means all
+	networks    []string  // Only sync chats on these networks; empty means all
+}
+
+// DefaultBeeperConcurrency is how many chats are fetched at once by default.
+// The desktop API is local, so a few parallel requests are cheap.
+const DefaultBeeperConcurrency = 4
+
+// AccessTokenEnv names the environment variable used for the Beeper access
+// token when no credentials file has been saved
+const AccessTokenEnv = "BEEPER_ACCESS_TOKEN"
+
+// ErrBeeperNotRunning is returned when nothing answers at the Beeper Desktop
+// API address, which usually means the app isn't running
+var ErrBeeperNotRunning = errors.New("Beeper Desktop doesn't appear to be running — start it and retry")
+
+// defaultBeeperDesktopURL is where Beeper Desktop serves its local API,
+// unless BEEPER_DESKTOP_BASE_URL says otherwise
+const defaultBeeperDesktopURL = "http://localhost:23373/"
+
+// beeperDesktopAddr returns the host:port of the Beeper Desktop API
+func beeperDesktopAddr() string {
+	base := defaultBeeperDesktopURL
+	if env := os.Getenv("BEEPER_DESKTOP_BASE_URL"); env != "" {
+		base = env
+	}
+	u, err := url.Parse(base)
+	if err != nil || u.Host == "" {
+		return "localhost:23373"
+	}
+	if u.Port() == "" {
+		if u.Scheme == "https" {
+			return net.JoinHostPort(u.Hostname(), "443")
+		}
+		return net.JoinHostPort(u.Hostname(), "80")
+	}
+	return u.Host
+}
+
+// CheckBeeperRunning reports ErrBeeperNotRunning if nothing accepts
+// connections at the Beeper Desktop API address
+func CheckBeeperRunning(ctx context.Context) error {
+	addr := beeperDesktopAddr()
+	dialer := net.Dialer{Timeout: 2 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		slog.Debug("beeper desktop api unreachable", "addr", addr, "error", err)
+		return fmt.Errorf("%w (nothing is listening at %s)", ErrBeeperNotRunning, addr)
+	}
+	conn.Close()
+	return nil
+}
+
+// WaitForBeeper polls until Beeper Desktop accepts connections or ctx is
+// done, printing a note to progress if it has to wait
+func WaitForBeeper(ctx context.Context, progress io.Writer) error {
+	const pollInterval = 2 * time.Second
+	waiting := false
+	for {
+		err := CheckBeeperRunning(ctx)
+		if !errors.Is(err, ErrBeeperNotRunning) {
+			return err
+		}
+		if !waiting {
+			fmt.Fprintln(progress, "Waiting for Beeper Desktop to start...")
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
 }
 
 // BeeperConfig holds configuration for the Beeper provider
@@ -31,10 +119,50 @@ type BeeperConfig struct {
 // NewBeeperProvider creates a new Beeper message provider
 func NewBeeperProvider(dunbarDir string) (*BeeperProvider, error) {
 	return &BeeperProvider{
-		dunbarDir: dunbarDir,
+		dunbarDir:   dunbarDir,
+		progress:    io.Discard,
+		concurrency: DefaultBeeperConcurrency,
 	}, nil
 }
 
+// SetConcurrency sets how many chats are fetched at once (values below 1 use the default)
+func (p *BeeperProvider) SetConcurrency(n int) {
+	if n < 1 {
+		n = DefaultBeeperConcurrency
+	}
+	p.concurrency = n
+}
+
+// SetAccountFilter limits syncing to the given Beeper account IDs (empty for all)
+func (p *BeeperProvider) SetAccountFilter(accountIDs []string) {
+	p.accountIDs = accountIDs
+}
+
+// SetNetworkFilter limits syncing to chats on the given networks, such as
+// "whatsapp" or "telegram", compared case-insensitively (empty for all)
+func (p *BeeperProvider) SetNetworkFilter(networks []string) {
+	p.networks = networks
+}
+
+// wantsNetwork reports whether chats on a network pass the network filter
+func (p *BeeperProvider) wantsNetwork(network string) bool {
+	if len(p.networks) == 0 {
+		return true
+	}
+	for _, n := range p.networks {
+		if strings.EqualFold(strings.TrimSpace(n), network) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetProgressOutput sets where sync progress is printed. Nothing is printed
+// unless it's set.
+func (p *BeeperProvider) SetProgressOutput(w io.Writer) {
+	p.progress = w
+}
+
 // SaveCredentials saves Beeper credentials to disk
 func (p *BeeperProvider) SaveCredentials(creds *BeeperCredentials) error {
 	credsPath := filepath.Join(p.dunbarDir, "beeper_credentials.json")
@@ -69,7 +197,9 @@ func (p *BeeperProvider) LoadCredentials() (*BeeperCredentials, error) {
 	return &creds, nil
 }
 
-// Initialize initializes the Beeper provider with credentials
+// Initialize initializes the Beeper provider with credentials. A token saved
+// by 'dunbar messages init' takes precedence over BEEPER_ACCESS_TOKEN, so
+// ephemeral environments can sync without writing a credentials file.
 func (p *BeeperProvider) Initialize() error {
 	// Load credentials from file
 	creds, err := p.LoadCredentials()
@@ -77,111 +207,208 @@ func (p *BeeperProvider) Initialize() error {
 		return fmt.Errorf("failed to load credentials: %w", err)
 	}
 
-	if creds == nil || creds.AccessToken == "" {
-		return fmt.Errorf("no credentials found")
+	accessToken := ""
+	if creds != nil {
+		accessToken = creds.AccessToken
+	}
+	if accessToken == "" {
+		accessToken = strings.TrimSpace(os.Getenv(AccessTokenEnv))
+		if accessToken != "" {
+			slog.Debug("using beeper access token from environment", "env", AccessTokenEnv)
+		}
+	}
+	if accessToken == "" {
+		return fmt.Errorf("no credentials found and %s is not set", AccessTokenEnv)
 	}
 
-	p.accessToken = creds.AccessToken
+	p.accessToken = accessToken
 
 	// Initialize Beeper API client
 	client := beeperapi.NewClient(
-		option.WithAccessToken(creds.AccessToken),
+		option.WithAccessToken(accessToken),
 	)
 
 	p.client = &client
 	return nil
 }
 
-// Sync fetches all conversations and messages from Beeper
-func (p *BeeperProvider) Sync() ([]Conversation, []Message, error) {
-	ctx := context.Background()
+// ResolveAttachmentURL has Beeper Desktop download an mxc:// or
+// localmxc:// attachment and returns the file:// URL of its local copy
+func (p *BeeperProvider) ResolveAttachmentURL(ctx context.Context, srcURL string) (string, error) {
+	if p.client == nil {
+		if err := p.Initialize(); err != nil {
+			return "", fmt.Errorf("failed to initialize provider: %w. Run 'dunbar messages init' first", err)
+		}
+	}
 
-	var conversations []Conversation
-	var allMessages []Message
+	resp, err := p.client.Assets.Download(ctx, beeperapi.AssetDownloadParams{URL: srcURL})
+	if err != nil {
+		return "", err
+	}
+	if resp.Error != "" {
+		return "", errors.New(resp.Error)
+	}
+	if resp.SrcURL == "" {
+		return "", errors.New("Beeper Desktop returned no file")
+	}
+	return resp.SrcURL, nil
+}
+
+// Sync fetches all conversations and messages from Beeper. Chats are listed
+// first, then their messages are fetched concurrently. If ctx is cancelled
+// while fetching messages, everything fetched so far is returned along with
+// the error.
+func (p *BeeperProvider) Sync(ctx context.Context) ([]Conversation, []Message, error) {
+	// Initialize on first use so read-only commands never need credentials
+	if p.client == nil {
+		if err := p.Initialize(); err != nil {
+			return nil, nil, fmt.Errorf("failed to initialize provider: %w. Run 'dunbar messages init' first", err)
+		}
+	}
+
+	// A stopped desktop app otherwise shows up as an obscure connection error
+	if err := CheckBeeperRunning(ctx); err != nil {
+		return nil, nil, err
+	}
 
-	fmt.Println("Fetching conversations from Beeper...")
+	fmt.Fprintln(p.progress, "Fetching conversations from Beeper...")
 
 	// Fetch all chats/conversations using auto-paging
-	chatsIter := p.client.Chats.ListAutoPaging(ctx, beeperapi.ChatListParams{})
+	var chats []beeperapi.Chat
+	skipped := 0
+	chatsIter := p.client.Chats.ListAutoPaging(ctx, beeperapi.ChatListParams{AccountIDs: p.accountIDs})
+	for chatsIter.Next() {
+		chat := chatsIter.Current().Chat
+		if !p.wantsNetwork(chat.Network) {
+			skipped++
+			continue
+		}
+		chats = append(chats, chat)
+	}
+	if chatsIter.Err() != nil {
+		slog.Error("failed to fetch beeper chats", "fetched", len(chats), "error", chatsIter.Err())
+		return nil, nil, fmt.Errorf("failed to fetch chats: %w", chatsIter.Err())
+	}
+	if skipped > 0 {
+		slog.Info("skipped beeper chats on filtered networks", "skipped", skipped, "networks", p.networks)
+	}
 
-	conversationCount := 0
+	conversations := make([]Conversation, len(chats))
+	for i, chat := range chats {
+		conversations[i] = convertChat(chat)
+	}
 
-	// Process each chat
-	for chatsIter.Next() {
-		chat := chatsIter.Current()
-		conversationCount++
-
-		// Convert chat to Conversation
-		conv := Conversation{
-			ID:               chat.ID,
-			AccountID:        chat.AccountID,
-			Platform:         chat.Network,
-			Title:            chat.Title,
-			Type:             string(chat.Type),
-			ParticipantUIDs:  extractParticipantUIDs(chat.Participants.Items),
-			ParticipantCount: int(chat.Participants.Total),
-			UnreadCount:      chat.UnreadCount,
-			LastActivity:     chat.LastActivity,
-			IsArchived:       chat.IsArchived,
-			IsMuted:          chat.IsMuted,
-			IsPinned:         chat.IsPinned,
-		}
-		conversations = append(conversations, conv)
-
-		// Show progress (clear line with escape code)
-		fmt.Printf("\r\033[K[%d] Syncing: %s (%s)", conversationCount, truncateString(chat.Title, 50), chat.Network)
-
-		// Fetch messages for this chat
-		messagesIter := p.client.Messages.ListAutoPaging(ctx, chat.ID, beeperapi.MessageListParams{})
-
-		chatMessageCount := 0
-		for messagesIter.Next() {
-			msg := messagesIter.Current()
-			chatMessageCount++
-
-			// Convert Beeper message to Dunbar message
-			dunbarMsg := Message{
-				ID:              msg.ID,
-				ContactUID:      msg.SenderID,
-				Timestamp:       msg.Timestamp,
-				SenderUID:       msg.SenderID,
-				SenderName:      msg.SenderName,
-				ConversationUID: msg.ChatID,
-				ChatTitle:       chat.Title,
-				Text:            msg.Text,
-				Platform:        chat.Network,
-				PlatformID:      msg.ID,
-				IsSent:          msg.IsSender,
-				Attachments:     convertAttachments(msg.Attachments),
-				SortKey:         msg.SortKey,
+	// Fetch messages for several chats at once. Each chat's messages go in
+	// their own slot so results keep the chat order.
+	chatMessages := make([][]Message, len(chats))
+	chatErrs := make([]error, len(chats))
+
+	var mu sync.Mutex // Guards progress output
+	completed := 0
+
+	var g errgroup.Group
+	g.SetLimit(p.concurrency)
+	for i, chat := range chats {
+		g.Go(func() error {
+			msgs, err := p.fetchChatMessages(ctx, chat)
+			chatMessages[i] = msgs
+			if err != nil {
+				slog.Error("failed to fetch beeper messages", "chat", chat.ID, "network", chat.Network, "error", err)
+				chatErrs[i] = fmt.Errorf("failed to fetch messages for chat %s: %w", chat.ID, err)
+			} else {
+				slog.Debug("fetched beeper chat", "chat", chat.ID, "network", chat.Network, "messages", len(msgs))
 			}
 
-			allMessages = append(allMessages, dunbarMsg)
+			// Show progress (clear line with escape code)
+			mu.Lock()
+			completed++
+			fmt.Fprintf(p.progress, "\r\033[K[%d/%d] Synced: %s (%s) - %d messages",
+				completed, len(chats), util.Truncate(chat.Title, 50), chat.Network, len(msgs))
+			mu.Unlock()
 
-			// Update progress with message count
-			if chatMessageCount%10 == 0 {
-				fmt.Printf("\r\033[K[%d] Syncing: %s (%s) - %d messages", conversationCount, truncateString(chat.Title, 50), chat.Network, chatMessageCount)
-			}
-		}
+			// Keep going so one failing chat doesn't abort the rest
+			return nil
+		})
+	}
+	g.Wait()
+	fmt.Fprintln(p.progress) // New line after progress
 
-		if messagesIter.Err() != nil {
-			fmt.Println() // New line after progress
-			return nil, nil, fmt.Errorf("failed to fetch messages for chat %s: %w", chat.ID, messagesIter.Err())
-		}
+	var allMessages []Message
+	for _, msgs := range chatMessages {
+		allMessages = append(allMessages, msgs...)
 	}
 
-	// Check for errors in chat iteration
-	if chatsIter.Err() != nil {
-		fmt.Println() // New line after progress
-		return nil, nil, fmt.Errorf("failed to fetch chats: %w", chatsIter.Err())
+	// Interrupted: hand back the partial results so they can be saved
+	if ctx.Err() != nil {
+		slog.Warn("beeper sync interrupted", "conversations", len(conversations), "messages", len(allMessages))
+		return conversations, allMessages, fmt.Errorf("sync interrupted: %w", ctx.Err())
+	}
+
+	// Likewise when some chats failed: the others were fetched in full
+	if err := errors.Join(chatErrs...); err != nil {
+		slog.Warn("beeper sync incomplete", "conversations", len(conversations), "messages", len(allMessages))
+		return conversations, allMessages, err
 	}
 
 	// Print final summary
-	fmt.Printf("\n\n✓ Synced %d conversations with %d total messages\n", len(conversations), len(allMessages))
+	slog.Info("beeper sync fetched", "conversations", len(conversations), "messages", len(allMessages))
+	fmt.Fprintf(p.progress, "\n✓ Synced %d conversations with %d total messages\n", len(conversations), len(allMessages))
 
 	return conversations, allMessages, nil
 }
 
+// convertChat converts a Beeper chat to a Conversation
+func convertChat(chat beeperapi.Chat) Conversation {
+	return Conversation{
+		ID:               chat.ID,
+		AccountID:        chat.AccountID,
+		Platform:         chat.Network,
+		Title:            chat.Title,
+		Type:             string(chat.Type),
+		ParticipantUIDs:  extractParticipantUIDs(chat.Participants.Items),
+		ParticipantCount: int(chat.Participants.Total),
+		Participants:     convertParticipants(chat.Participants.Items),
+		UnreadCount:      chat.UnreadCount,
+		LastActivity:     chat.LastActivity,
+		IsArchived:       chat.IsArchived,
+		IsMuted:          chat.IsMuted,
+		IsPinned:         chat.IsPinned,
+	}
+}
+
+// fetchChatMessages fetches every message in a chat, in API order
+func (p *BeeperProvider) fetchChatMessages(ctx context.Context, chat beeperapi.Chat) ([]Message, error) {
+	var msgs []Message
+
+	messagesIter := p.client.Messages.ListAutoPaging(ctx, chat.ID, beeperapi.MessageListParams{})
+	for messagesIter.Next() {
+		msg := messagesIter.Current()
+
+		// Convert Beeper message to Dunbar message
+		msgs = append(msgs, Message{
+			ID:              msg.ID,
+			ContactUID:      msg.SenderID,
+			Timestamp:       msg.Timestamp,
+			SenderUID:       msg.SenderID,
+			SenderName:      msg.SenderName,
+			ConversationUID: msg.ChatID,
+			ChatTitle:       chat.Title,
+			Text:            msg.Text,
+			Platform:        chat.Network,
+			PlatformID:      msg.ID,
+			IsSent:          msg.IsSender,
+			Attachments:     convertAttachments(msg.Attachments),
+			SortKey:         msg.SortKey,
+			Reactions:       convertReactions(msg.Reactions),
+			ReplyToID:       extractReplyToID(msg),
+			IsDeleted:       extractIsDeleted(msg),
+			Status:          extractStatus(msg),
+		})
+	}
+
+	return msgs, messagesIter.Err()
+}
+
 // extractParticipantUIDs extracts user IDs from participant list
 func extractParticipantUIDs(participants []beeperapi.User) []string {
 	uids := make([]string, len(participants))
@@ -191,6 +418,25 @@ func extractParticipantUIDs(participants []beeperapi.User) []string {
 	return uids
 }
 
+// convertParticipants converts Beeper users to Dunbar participants
+func convertParticipants(users []beeperapi.User) []Participant {
+	participants := make([]Participant, len(users))
+	for i, u := range users {
+		name := u.FullName
+		if name == "" {
+			name = u.Username
+		}
+		participants[i] = Participant{
+			UID:         u.ID,
+			Name:        name,
+			PhoneNumber: u.PhoneNumber,
+			Email:       u.Email,
+			IsSelf:      u.IsSelf,
+		}
+	}
+	return participants
+}
+
 // convertAttachments converts Beeper attachments to Dunbar attachments
 func convertAttachments(beeperAttachments []beeperapi.Attachment) []Attachment {
 	attachments := make([]Attachment, len(beeperAttachments))
@@ -212,13 +458,77 @@ func convertAttachments(beeperAttachments []beeperapi.Attachment) []Attachment {
 	return attachments
 }
 
-// truncateString truncates a string to a maximum length
-func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
+// convertReactions converts Beeper reactions to Dunbar reactions
+func convertReactions(beeperReactions []beeperapi.Reaction) []Reaction {
+	reactions := make([]Reaction, len(beeperReactions))
+	for i, r := range beeperReactions {
+		reactions[i] = Reaction{
+			ID:            r.ID,
+			ParticipantID: r.ParticipantID,
+			Key:           r.ReactionKey,
+			IsEmoji:       r.Emoji,
+			ImgURL:        r.ImgURL,
+		}
+	}
+	return reactions
+}
+
+// Fields Beeper may include on a message that are not part of the typed API model.
+// They are read from the message's extra fields when present.
+var (
+	replyToFields = []string{"linkedMessageID", "replyToMessageID", "replyToID"}
+	deletedFields = []string{"isDeleted", "deleted"}
+	statusFields  = []string{"status", "sendStatus", "deliveryStatus"}
+)
+
+// extractReplyToID returns the ID of the message this message replies to, if any
+func extractReplyToID(msg beeperapi.Message) string {
+	var id string
+	extractExtraField(msg, replyToFields, &id)
+	return id
+}
+
+// extractIsDeleted reports whether the message was deleted (unsent) on the platform
+func extractIsDeleted(msg beeperapi.Message) bool {
+	var deleted bool
+	extractExtraField(msg, deletedFields, &deleted)
+	return deleted
+}
+
+// extractStatus returns the delivery state of a message you sent, normalized
+// to one of the Status constants. It's empty for received messages and for
+// states that don't map onto one, such as a send still pending.
+func extractStatus(msg beeperapi.Message) string {
+	if !msg.IsSender {
+		return ""
 	}
-	if maxLen <= 3 {
-		return s[:maxLen]
+	var status string
+	if !extractExtraField(msg, statusFields, &status) {
+		return ""
+	}
+	switch strings.ToLower(status) {
+	case "failed", "failure", "error":
+		return StatusFailed
+	case "read", "seen":
+		return StatusRead
+	case "delivered":
+		return StatusDelivered
+	case "sent", "success":
+		return StatusSent
+	}
+	return ""
+}
+
+// extractExtraField decodes the first present, non-null extra field from names into dest
+func extractExtraField(msg beeperapi.Message, names []string, dest any) bool {
+	for _, name := range names {
+		field, ok := msg.JSON.ExtraFields[name]
+		if !ok || !field.Valid() {
+			continue
+		}
+		if err := json.Unmarshal([]byte(field.Raw()), dest); err == nil {
+			return true
+		}
 	}
-	return s[:maxLen-3] + "..."
+	return false
 }
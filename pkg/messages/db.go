@@ -3,12 +3,21 @@ package messages
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
-	_ "modernc.org/sqlite" // SQLite driver
+	"modernc.org/sqlite" // SQLite driver
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
+// ErrCorruptDB is returned by OpenDB when the database file is damaged or is
+// not a SQLite database. Messages can be re-synced, so the usual fix is
+// BackupCorruptDB followed by a fresh sync.
+var ErrCorruptDB = errors.New("messages database is corrupted")
+
 // DB wraps a SQLite database for storing messages and conversations
 type DB struct {
 	db *sql.DB
@@ -21,6 +30,19 @@ func OpenDB(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
+	d, err := setupDB(db)
+	if err != nil {
+		db.Close()
+		if isCorruptErr(err) {
+			return nil, fmt.Errorf("%w: %s: %v", ErrCorruptDB, dbPath, err)
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+// setupDB configures a freshly opened database and brings its schema up to date
+func setupDB(db *sql.DB) (*DB, error) {
 	// Enable foreign keys and WAL mode for better performance
 	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
 		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
@@ -30,13 +52,69 @@ func OpenDB(dbPath string) (*DB, error) {
 	}
 
 	d := &DB{db: db}
+
+	// Databases created before attachments had their own table need it filled in
+	hasAttachments, err := d.tableExists("attachments")
+	if err != nil {
+		return nil, err
+	}
+
+	// Likewise databases created before conversations counted their messages
+	hasMessageCount, err := d.columnExists("conversations", "message_count")
+	if err != nil {
+		return nil, err
+	}
+
 	if err := d.createTables(); err != nil {
 		return nil, err
 	}
+	if err := d.migrate(); err != nil {
+		return nil, err
+	}
+	if !hasAttachments {
+		if err := d.backfillAttachments(); err != nil {
+			return nil, err
+		}
+	}
+	if !hasMessageCount {
+		if _, err := d.db.Exec(`UPDATE conversations SET message_count = ` + countMessages); err != nil {
+			return nil, fmt.Errorf("failed to count messages: %w", err)
+		}
+	}
 
 	return d, nil
 }
 
+// isCorruptErr reports whether err is SQLite saying the file is damaged or
+// isn't a database at all
+func isCorruptErr(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	switch sqliteErr.Code() & 0xff { // Primary result code
+	case sqlite3.SQLITE_CORRUPT, sqlite3.SQLITE_NOTADB:
+		return true
+	}
+	return false
+}
+
+// BackupCorruptDB moves a damaged database, and its WAL files, aside to
+// <dbPath>.corrupt-<timestamp> so a new one can be created in its place. It
+// returns the backup path.
+func BackupCorruptDB(dbPath string) (string, error) {
+	backup := dbPath + ".corrupt-" + time.Now().Format("20060102-150405")
+	if err := os.Rename(dbPath, backup); err != nil {
+		return "", fmt.Errorf("failed to back up database: %w", err)
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Rename(dbPath+suffix, backup+suffix); err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to back up database: %w", err)
+		}
+	}
+	return backup, nil
+}
+
 // Close closes the database connection
 func (d *DB) Close() error {
 	return d.db.Close()
@@ -57,7 +135,9 @@ func (d *DB) createTables() error {
 		last_activity INTEGER NOT NULL, -- Unix timestamp
 		is_archived BOOLEAN NOT NULL DEFAULT 0,
 		is_muted BOOLEAN NOT NULL DEFAULT 0,
-		is_pinned BOOLEAN NOT NULL DEFAULT 0
+		is_pinned BOOLEAN NOT NULL DEFAULT 0,
+		participants TEXT NOT NULL DEFAULT '', -- JSON array
+		message_count INTEGER NOT NULL DEFAULT 0 -- Kept up to date by SaveMessages
 	);
 
 	CREATE TABLE IF NOT EXISTS messages (
@@ -74,13 +154,56 @@ func (d *DB) createTables() error {
 		is_sent BOOLEAN NOT NULL,
 		attachments TEXT, -- JSON array
 		sort_key TEXT NOT NULL,
+		reactions TEXT NOT NULL DEFAULT '', -- JSON array
+		reply_to_id TEXT NOT NULL DEFAULT '',
+		is_deleted BOOLEAN NOT NULL DEFAULT 0,
+		status TEXT NOT NULL DEFAULT '',
 		FOREIGN KEY (conversation_uid) REFERENCES conversations(id)
 	);
 
+	-- Attachments are also kept in messages.attachments; this table indexes
+	-- them so they can be listed without decoding every message
+	CREATE TABLE IF NOT EXISTS attachments (
+		message_id TEXT NOT NULL,
+		position INTEGER NOT NULL, -- Index within the message's attachments
+		conversation_uid TEXT NOT NULL,
+		timestamp INTEGER NOT NULL, -- Unix timestamp of the message
+		type TEXT NOT NULL,
+		file_name TEXT NOT NULL,
+		file_size REAL NOT NULL,
+		mime_type TEXT NOT NULL,
+		src_url TEXT NOT NULL,
+		PRIMARY KEY (message_id, position),
+		FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+	);
+
+	-- Local titles set with 'dunbar messages rename'. They live apart from
+	-- conversations so syncs, which replace conversation rows, keep them.
+	CREATE TABLE IF NOT EXISTS conversation_aliases (
+		conversation_uid TEXT PRIMARY KEY,
+		title TEXT NOT NULL
+	);
+
+	-- Conversations pinned to the top of the TUI, in the order chosen
+	CREATE TABLE IF NOT EXISTS conversation_pins (
+		conversation_uid TEXT PRIMARY KEY,
+		position INTEGER NOT NULL
+	);
+
+	-- Conversations merged with 'dunbar messages merge' (the same chat synced
+	-- through two accounts). The merged conversation's row is kept so syncs
+	-- can update it, but it's hidden and its messages are stored under into_uid.
+	CREATE TABLE IF NOT EXISTS conversation_merges (
+		merged_uid TEXT PRIMARY KEY,
+		into_uid TEXT NOT NULL
+	);
+
 	CREATE INDEX IF NOT EXISTS idx_messages_conversation ON messages(conversation_uid);
+	CREATE INDEX IF NOT EXISTS idx_messages_conversation_time ON messages(conversation_uid, timestamp DESC, sort_key DESC);
 	CREATE INDEX IF NOT EXISTS idx_messages_contact ON messages(contact_uid);
 	CREATE INDEX IF NOT EXISTS idx_messages_timestamp ON messages(timestamp DESC);
 	CREATE INDEX IF NOT EXISTS idx_messages_sender ON messages(sender_uid);
+	CREATE INDEX IF NOT EXISTS idx_attachments_conversation ON attachments(conversation_uid, timestamp);
 	`
 
 	if _, err := d.db.Exec(schema); err != nil {
@@ -90,6 +213,115 @@ func (d *DB) createTables() error {
 	return nil
 }
 
+// columnMigration describes a column added to a table after its initial schema
+type columnMigration struct {
+	table      string
+	column     string
+	definition string
+}
+
+// columnMigrations lists columns that databases created by older versions may lack
+var columnMigrations = []columnMigration{
+	{"conversations", "participants", "TEXT NOT NULL DEFAULT ''"},
+	{"conversations", "message_count", "INTEGER NOT NULL DEFAULT 0"},
+	{"messages", "reactions", "TEXT NOT NULL DEFAULT ''"},
+	{"messages", "reply_to_id", "TEXT NOT NULL DEFAULT ''"},
+	{"messages", "is_deleted", "BOOLEAN NOT NULL DEFAULT 0"},
+	{"messages", "status", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// migrate adds any columns missing from databases created by older versions
+func (d *DB) migrate() error {
+	for _, m := range columnMigrations {
+		exists, err := d.columnExists(m.table, m.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)
+		if _, err := d.db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", m.table, m.column, err)
+		}
+	}
+
+	return nil
+}
+
+// tableExists reports whether the database has a table with the given name
+func (d *DB) tableExists(table string) (bool, error) {
+	var n int
+	err := d.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("failed to read schema: %w", err)
+	}
+	return n > 0, nil
+}
+
+// backfillAttachments indexes the attachments of messages stored before the
+// attachments table existed
+func (d *DB) backfillAttachments() error {
+	_, err := d.db.Exec(`
+		INSERT OR IGNORE INTO attachments (
+			message_id, position, conversation_uid, timestamp,
+			type, file_name, file_size, mime_type, src_url
+		)
+		SELECT m.id, a.key, m.conversation_uid, m.timestamp,
+		       COALESCE(json_extract(a.value, '$.type'), ''),
+		       COALESCE(json_extract(a.value, '$.file_name'), ''),
+		       COALESCE(json_extract(a.value, '$.file_size'), 0),
+		       COALESCE(json_extract(a.value, '$.mime_type'), ''),
+		       COALESCE(json_extract(a.value, '$.src_url'), '')
+		FROM messages m, json_each(m.attachments) a
+		WHERE m.attachments LIKE '[%'
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to index attachments: %w", err)
+	}
+	return nil
+}
+
+// countMessages is a subquery counting the messages stored under the
+// conversation being updated, for setting message_count
+const countMessages = `(SELECT COUNT(*) FROM messages WHERE conversation_uid = conversations.id)`
+
+// latestMessage returns a subquery giving the time of the newest message
+// stored under the conversation whose ID is the SQL expression id, or NULL if
+// it has none, for setting last_activity
+func latestMessage(id string) string {
+	return `(SELECT MAX(timestamp) FROM messages WHERE conversation_uid = ` + id + `)`
+}
+
+// columnExists reports whether a table has a column with the given name
+func (d *DB) columnExists(table, column string) (bool, error) {
+	rows, err := d.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("failed to read schema for %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    bool
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return false, fmt.Errorf("failed to scan schema for %s: %w", table, err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
+
 // SaveConversations upserts conversations into the database
 func (d *DB) SaveConversations(conversations []Conversation) error {
 	tx, err := d.db.Begin()
@@ -103,8 +335,11 @@ func (d *DB) SaveConversations(conversations []Conversation) error {
 			id, account_id, platform, title, type,
 			participant_uids, participant_count,
 			unread_count, last_activity,
-			is_archived, is_muted, is_pinned
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			is_archived, is_muted, is_pinned, participants, message_count
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?,
+			COALESCE(` + latestMessage("?") + `, ?),
+			?, ?, ?, ?,
+			COALESCE((SELECT message_count FROM conversations WHERE id = ?), 0))
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
@@ -112,25 +347,41 @@ func (d *DB) SaveConversations(conversations []Conversation) error {
 	defer stmt.Close()
 
 	for _, conv := range conversations {
+		// Never store a local alias as the platform title
+		title := conv.Title
+		if conv.OriginalTitle != "" {
+			title = conv.OriginalTitle
+		}
+
 		// Convert participant UIDs to JSON
 		participantUIDs, err := json.Marshal(conv.ParticipantUIDs)
 		if err != nil {
 			return fmt.Errorf("failed to marshal participant UIDs: %w", err)
 		}
 
+		participants, err := json.Marshal(conv.Participants)
+		if err != nil {
+			return fmt.Errorf("failed to marshal participants: %w", err)
+		}
+
 		_, err = stmt.Exec(
 			conv.ID,
 			conv.AccountID,
 			conv.Platform,
-			conv.Title,
+			title,
 			conv.Type,
 			string(participantUIDs),
 			conv.ParticipantCount,
 			conv.UnreadCount,
+			// The newest stored message is more reliable than the platform's
+			// last activity, which is only kept when there are no messages
+			conv.ID,
 			conv.LastActivity.Unix(),
 			conv.IsArchived,
 			conv.IsMuted,
 			conv.IsPinned,
+			string(participants),
+			conv.ID, // Keep the message count, which only SaveMessages changes
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert conversation %s: %w", conv.ID, err)
@@ -140,7 +391,9 @@ func (d *DB) SaveConversations(conversations []Conversation) error {
 	return tx.Commit()
 }
 
-// SaveMessages inserts new messages into the database (ignores duplicates)
+// SaveMessages upserts messages into the database. Messages already stored are
+// updated in place so edits, deletions, and new reactions from the platform are reflected.
+// The message counts of the conversations they belong to are updated to match.
 func (d *DB) SaveMessages(messages []Message) error {
 	tx, err := d.db.Begin()
 	if err != nil {
@@ -149,24 +402,69 @@ func (d *DB) SaveMessages(messages []Message) error {
 	defer tx.Rollback()
 
 	stmt, err := tx.Prepare(`
-		INSERT OR IGNORE INTO messages (
+		INSERT INTO messages (
 			id, contact_uid, timestamp, sender_uid, sender_name,
 			conversation_uid, chat_title, content, platform, platform_id,
-			is_sent, attachments, sort_key
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			is_sent, attachments, sort_key, reactions, reply_to_id, is_deleted,
+			status
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			sender_name = excluded.sender_name,
+			chat_title = excluded.chat_title,
+			content = excluded.content,
+			attachments = excluded.attachments,
+			reactions = excluded.reactions,
+			reply_to_id = excluded.reply_to_id,
+			is_deleted = excluded.is_deleted,
+			status = excluded.status
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
+	// Store messages of merged conversations under the one they were merged into
+	mergedInto, err := mergedConversations(tx)
+	if err != nil {
+		return err
+	}
+
+	clearAttachments, err := tx.Prepare(`DELETE FROM attachments WHERE message_id = ?`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer clearAttachments.Close()
+
+	insertAttachment, err := tx.Prepare(`
+		INSERT INTO attachments (
+			message_id, position, conversation_uid, timestamp,
+			type, file_name, file_size, mime_type, src_url
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer insertAttachment.Close()
+
+	counted := make(map[string]bool) // Conversations whose message count needs updating
 	for _, msg := range messages {
+		if into, ok := mergedInto[msg.ConversationUID]; ok {
+			msg.ConversationUID = into
+		}
+		counted[msg.ConversationUID] = true
+
 		// Convert attachments to JSON
 		attachmentsJSON, err := json.Marshal(msg.Attachments)
 		if err != nil {
 			return fmt.Errorf("failed to marshal attachments: %w", err)
 		}
 
+		// Convert reactions to JSON
+		reactionsJSON, err := json.Marshal(msg.Reactions)
+		if err != nil {
+			return fmt.Errorf("failed to marshal reactions: %w", err)
+		}
+
 		_, err = stmt.Exec(
 			msg.ID,
 			msg.ContactUID,
@@ -181,24 +479,94 @@ func (d *DB) SaveMessages(messages []Message) error {
 			msg.IsSent,
 			string(attachmentsJSON),
 			msg.SortKey,
+			string(reactionsJSON),
+			msg.ReplyToID,
+			msg.IsDeleted,
+			msg.Status,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert message %s: %w", msg.ID, err)
 		}
+
+		// Re-index the message's attachments, which may have changed with an edit
+		if _, err := clearAttachments.Exec(msg.ID); err != nil {
+			return fmt.Errorf("failed to update attachments for message %s: %w", msg.ID, err)
+		}
+		for i, att := range msg.Attachments {
+			_, err := insertAttachment.Exec(
+				msg.ID,
+				i,
+				msg.ConversationUID,
+				msg.Timestamp.Unix(),
+				att.Type,
+				att.FileName,
+				att.FileSize,
+				att.MimeType,
+				att.SrcURL,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to insert attachment for message %s: %w", msg.ID, err)
+			}
+		}
+	}
+
+	// Recount rather than add, since messages already stored are only
+	// updated. Last activity follows the newest message, as the platform's
+	// can lag behind, especially for bridged networks.
+	for id := range counted {
+		if _, err := tx.Exec(`UPDATE conversations SET message_count = `+countMessages+`,
+			last_activity = COALESCE(`+latestMessage("conversations.id")+`, last_activity) WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("failed to count messages for conversation %s: %w", id, err)
+		}
 	}
 
 	return tx.Commit()
 }
 
+// ListAttachments returns the attachments in a conversation, oldest first
+func (d *DB) ListAttachments(conversationUID string) ([]AttachmentRef, error) {
+	rows, err := d.db.Query(`
+		SELECT message_id, timestamp, type, file_name, file_size, mime_type, src_url
+		FROM attachments
+		WHERE conversation_uid = ?
+		ORDER BY timestamp, message_id, position
+	`, conversationUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var refs []AttachmentRef
+	for rows.Next() {
+		var ref AttachmentRef
+		var timestampUnix int64
+		err := rows.Scan(
+			&ref.MessageID,
+			&timestampUnix,
+			&ref.Type,
+			&ref.FileName,
+			&ref.FileSize,
+			&ref.MimeType,
+			&ref.SrcURL,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		ref.Timestamp = time.Unix(timestampUnix, 0)
+		refs = append(refs, ref)
+	}
+
+	return refs, rows.Err()
+}
+
 // GetMessagesForContact retrieves all messages for a specific contact
 func (d *DB) GetMessagesForContact(contactUID string) ([]Message, error) {
 	rows, err := d.db.Query(`
-		SELECT id, contact_uid, timestamp, sender_uid, sender_name,
-		       conversation_uid, chat_title, content, platform, platform_id,
-		       is_sent, attachments, sort_key
-		FROM messages
-		WHERE contact_uid = ?
-		ORDER BY timestamp DESC
+		SELECT `+messageColumns+`
+		FROM messages m
+		LEFT JOIN messages r ON r.id = m.reply_to_id
+		WHERE m.contact_uid = ?
+		ORDER BY m.timestamp DESC
 	`, contactUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query messages: %w", err)
@@ -230,110 +598,82 @@ func (d *DB) GetLastContactDate(contactUID string) (*time.Time, error) {
 
 // GetConversation retrieves a specific conversation by ID
 func (d *DB) GetConversation(conversationUID string) (*Conversation, error) {
-	var conv Conversation
-	var participantUIDs string
-	var lastActivityUnix int64
-
-	err := d.db.QueryRow(`
-		SELECT id, account_id, platform, title, type,
-		       participant_uids, participant_count,
-		       unread_count, last_activity,
-		       is_archived, is_muted, is_pinned
-		FROM conversations
-		WHERE id = ?
-	`, conversationUID).Scan(
-		&conv.ID,
-		&conv.AccountID,
-		&conv.Platform,
-		&conv.Title,
-		&conv.Type,
-		&participantUIDs,
-		&conv.ParticipantCount,
-		&conv.UnreadCount,
-		&lastActivityUnix,
-		&conv.IsArchived,
-		&conv.IsMuted,
-		&conv.IsPinned,
-	)
-
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
+	rows, err := d.db.Query(`
+		SELECT `+conversationColumns+`
+		FROM conversations c
+		`+conversationJoins+`
+		WHERE c.id = ?
+	`, conversationUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query conversation: %w", err)
 	}
+	defer rows.Close()
 
-	// Parse participant UIDs from JSON
-	if err := json.Unmarshal([]byte(participantUIDs), &conv.ParticipantUIDs); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal participant UIDs: %w", err)
+	conversations, err := scanConversations(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(conversations) == 0 {
+		return nil, nil
 	}
 
-	conv.LastActivity = time.Unix(lastActivityUnix, 0)
-
-	return &conv, nil
+	return &conversations[0], nil
 }
 
 // GetConversationsForContact retrieves all conversations that include a specific contact
 func (d *DB) GetConversationsForContact(contactUID string) ([]Conversation, error) {
 	rows, err := d.db.Query(`
-		SELECT DISTINCT c.id, c.account_id, c.platform, c.title, c.type,
-		       c.participant_uids, c.participant_count,
-		       c.unread_count, c.last_activity,
-		       c.is_archived, c.is_muted, c.is_pinned
+		SELECT DISTINCT `+conversationColumns+`
 		FROM conversations c
+		`+conversationJoins+`
 		WHERE c.participant_uids LIKE ?
+		  AND `+notMerged+`
 	`, "%"+contactUID+"%") // Simple LIKE search in JSON array
 	if err != nil {
 		return nil, fmt.Errorf("failed to query conversations: %w", err)
 	}
 	defer rows.Close()
 
-	var conversations []Conversation
-	for rows.Next() {
-		var conv Conversation
-		var participantUIDs string
-		var lastActivityUnix int64
-
-		err := rows.Scan(
-			&conv.ID,
-			&conv.AccountID,
-			&conv.Platform,
-			&conv.Title,
-			&conv.Type,
-			&participantUIDs,
-			&conv.ParticipantCount,
-			&conv.UnreadCount,
-			&lastActivityUnix,
-			&conv.IsArchived,
-			&conv.IsMuted,
-			&conv.IsPinned,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan conversation: %w", err)
-		}
+	return scanConversations(rows)
+}
 
-		// Parse participant UIDs
-		if err := json.Unmarshal([]byte(participantUIDs), &conv.ParticipantUIDs); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal participant UIDs: %w", err)
-		}
+// ConversationFilter narrows ListAllConversations. Empty fields match
+// everything; set fields are combined with AND.
+type ConversationFilter struct {
+	Platform  string // e.g. "whatsapp"
+	Type      string // "single" or "group"
+	AccountID string
+}
 
-		conv.LastActivity = time.Unix(lastActivityUnix, 0)
-		conversations = append(conversations, conv)
+// where builds the WHERE clause and arguments for the filter
+func (f ConversationFilter) where() (string, []any) {
+	conditions := []string{notMerged}
+	var args []any
+	if f.Platform != "" {
+		conditions = append(conditions, "c.platform = ? COLLATE NOCASE")
+		args = append(args, f.Platform)
 	}
-
-	return conversations, rows.Err()
+	if f.Type != "" {
+		conditions = append(conditions, "c.type = ? COLLATE NOCASE")
+		args = append(args, f.Type)
+	}
+	if f.AccountID != "" {
+		conditions = append(conditions, "c.account_id = ?")
+		args = append(args, f.AccountID)
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
 }
 
-// ListAllConversations retrieves all conversations from the database
-func (d *DB) ListAllConversations() ([]Conversation, error) {
+// ListAllConversations retrieves the conversations matching filter from the database
+func (d *DB) ListAllConversations(filter ConversationFilter) ([]Conversation, error) {
+	where, args := filter.where()
 	rows, err := d.db.Query(`
-		SELECT id, account_id, platform, title, type,
-		       participant_uids, participant_count,
-		       unread_count, last_activity,
-		       is_archived, is_muted, is_pinned
-		FROM conversations
-		ORDER BY last_activity DESC
-	`)
+		SELECT `+conversationColumns+`
+		FROM conversations c
+		`+conversationJoins+`
+		`+where+`
+		ORDER BY MAX(c.last_activity, COALESCE(mg.last_activity, 0)) DESC
+	`, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query conversations: %w", err)
 	}
@@ -345,12 +685,11 @@ func (d *DB) ListAllConversations() ([]Conversation, error) {
 // GetMessagesForConversation retrieves all messages for a specific conversation
 func (d *DB) GetMessagesForConversation(conversationUID string) ([]Message, error) {
 	rows, err := d.db.Query(`
-		SELECT id, contact_uid, timestamp, sender_uid, sender_name,
-		       conversation_uid, chat_title, content, platform, platform_id,
-		       is_sent, attachments, sort_key
-		FROM messages
-		WHERE conversation_uid = ?
-		ORDER BY timestamp DESC
+		SELECT `+messageColumns+`
+		FROM messages m
+		LEFT JOIN messages r ON r.id = m.reply_to_id
+		WHERE m.conversation_uid = ?
+		ORDER BY m.timestamp DESC
 	`, conversationUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query messages: %w", err)
@@ -360,13 +699,262 @@ func (d *DB) GetMessagesForConversation(conversationUID string) ([]Message, erro
 	return scanMessages(rows)
 }
 
+// EachMessage calls fn with each of a conversation's messages sent at or
+// after since, oldest first, reading them from the database one at a time.
+// It stops at the first error fn returns.
+func (d *DB) EachMessage(conversationUID string, since time.Time, fn func(Message) error) error {
+	rows, err := d.db.Query(`
+		SELECT `+messageColumns+`
+		FROM messages m
+		LEFT JOIN messages r ON r.id = m.reply_to_id
+		WHERE m.conversation_uid = ? AND m.timestamp >= ?
+		ORDER BY `+messagePosition+`
+	`, conversationUID, since.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to query messages: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		msg, err := scanMessage(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(msg); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// SearchMessages retrieves the messages whose text contains query, ignoring
+// case, newest first. Deleted messages are skipped.
+func (d *DB) SearchMessages(query string) ([]Message, error) {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(query)
+	rows, err := d.db.Query(`
+		SELECT `+messageColumns+`
+		FROM messages m
+		LEFT JOIN messages r ON r.id = m.reply_to_id
+		WHERE m.content LIKE ? ESCAPE '\' AND NOT m.is_deleted
+		ORDER BY m.timestamp DESC
+	`, "%"+escaped+"%")
+	if err != nil {
+		return nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+	defer rows.Close()
+
+	return scanMessages(rows)
+}
+
+// messagePosition is where a message falls in its conversation: by
+// timestamp, then by sort key compared as a number (a shorter string of
+// digits is smaller, so "999" comes before "1000"), then by ID, so that no
+// two messages share a position. Queries select from messages aliased as m.
+const messagePosition = `m.timestamp, length(m.sort_key), m.sort_key, m.id`
+
+// GetMessagesForConversationPage retrieves up to limit messages from a
+// conversation, newest first, that come before the message with the given
+// ID. An empty beforeID starts from the most recent message.
+func (d *DB) GetMessagesForConversationPage(conversationUID, beforeID string, limit int) ([]Message, error) {
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages m
+		LEFT JOIN messages r ON r.id = m.reply_to_id
+		WHERE m.conversation_uid = ?`
+	args := []any{conversationUID}
+
+	if beforeID != "" {
+		// Every message has its own position, so messages sharing a
+		// timestamp aren't skipped or repeated between pages
+		query += `
+		  AND (` + messagePosition + `) < (
+			SELECT ` + messagePosition + ` FROM messages m
+			WHERE m.conversation_uid = ? AND m.id = ?
+		  )`
+		args = append(args, conversationUID, beforeID)
+	}
+
+	query += `
+		ORDER BY m.timestamp DESC, length(m.sort_key) DESC, m.sort_key DESC, m.id DESC
+		LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query messages: %w", err)
+	}
+	defer rows.Close()
+
+	return scanMessages(rows)
+}
+
+// conversationColumns is the column list scanned by scanConversations.
+// Queries select from conversations aliased as c.
+const conversationColumns = `c.id, c.account_id, c.platform, c.title, c.type,
+		       c.participant_uids, c.participant_count,
+		       c.unread_count + COALESCE(mg.unread_count, 0),
+		       MAX(c.last_activity, COALESCE(mg.last_activity, 0)),
+		       c.is_archived, c.is_muted, c.is_pinned, c.participants,
+		       COALESCE(a.title, ''), COALESCE(p.position, 0), c.message_count`
+
+// conversationJoins joins each conversation to its local alias and pin, if
+// any, and to the totals of the conversations merged into it, for
+// conversationColumns
+const conversationJoins = `LEFT JOIN conversation_aliases a ON a.conversation_uid = c.id
+		LEFT JOIN conversation_pins p ON p.conversation_uid = c.id
+		LEFT JOIN (
+			SELECT cm.into_uid, SUM(s.unread_count) AS unread_count, MAX(s.last_activity) AS last_activity
+			FROM conversation_merges cm
+			JOIN conversations s ON s.id = cm.merged_uid
+			GROUP BY cm.into_uid
+		) mg ON mg.into_uid = c.id`
+
+// notMerged is a condition that leaves out conversations merged into another
+const notMerged = `c.id NOT IN (SELECT merged_uid FROM conversation_merges)`
+
+// mergedConversations maps each merged conversation to the one it was merged into
+func mergedConversations(tx *sql.Tx) (map[string]string, error) {
+	rows, err := tx.Query(`SELECT merged_uid, into_uid FROM conversation_merges`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query merged conversations: %w", err)
+	}
+	defer rows.Close()
+
+	merged := make(map[string]string)
+	for rows.Next() {
+		var from, into string
+		if err := rows.Scan(&from, &into); err != nil {
+			return nil, fmt.Errorf("failed to scan merged conversation: %w", err)
+		}
+		merged[from] = into
+	}
+	return merged, rows.Err()
+}
+
+// MergeConversations merges conversation from into conversation into. Its
+// messages move to into, so a message stored under both is kept once (message
+// IDs are unique), and the merge is recorded so later syncs store its messages
+// under into as well. Conversations already merged into from follow it.
+func (d *DB) MergeConversations(into, from string) error {
+	if into == from {
+		return fmt.Errorf("cannot merge a conversation into itself")
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	merged, err := mergedConversations(tx)
+	if err != nil {
+		return err
+	}
+	if target, ok := merged[into]; ok {
+		return fmt.Errorf("conversation %s is already merged into %s", into, target)
+	}
+	if target, ok := merged[from]; ok {
+		return fmt.Errorf("conversation %s is already merged into %s", from, target)
+	}
+
+	steps := []struct {
+		query string
+		args  []any
+	}{
+		{`UPDATE conversation_merges SET into_uid = ? WHERE into_uid = ?`, []any{into, from}},
+		{`INSERT INTO conversation_merges (merged_uid, into_uid) VALUES (?, ?)`, []any{from, into}},
+		{`UPDATE messages SET conversation_uid = ? WHERE conversation_uid = ?`, []any{into, from}},
+		{`UPDATE attachments SET conversation_uid = ? WHERE conversation_uid = ?`, []any{into, from}},
+		{`DELETE FROM conversation_pins WHERE conversation_uid = ?`, []any{from}},
+		{`UPDATE conversations SET message_count = ` + countMessages + ` WHERE id IN (?, ?)`, []any{into, from}},
+	}
+	for _, step := range steps {
+		if _, err := tx.Exec(step.query, step.args...); err != nil {
+			return fmt.Errorf("failed to merge conversation %s: %w", from, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SetPinnedConversations replaces the pinned conversations with the given
+// ones, in that order
+func (d *DB) SetPinnedConversations(conversationUIDs []string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM conversation_pins`); err != nil {
+		return fmt.Errorf("failed to clear pinned conversations: %w", err)
+	}
+	for i, id := range conversationUIDs {
+		if _, err := tx.Exec(`INSERT INTO conversation_pins (conversation_uid, position) VALUES (?, ?)`, id, i+1); err != nil {
+			return fmt.Errorf("failed to pin conversation %s: %w", id, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteConversation removes a conversation with its messages, attachments,
+// local title, pin, and merges, so a conversation synced again under the
+// same ID starts afresh
+func (d *DB) DeleteConversation(conversationUID string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range []string{
+		`DELETE FROM messages WHERE conversation_uid = ?`, // Attachments cascade
+		`DELETE FROM conversation_aliases WHERE conversation_uid = ?`,
+		`DELETE FROM conversation_pins WHERE conversation_uid = ?`,
+		`DELETE FROM conversation_merges WHERE merged_uid = ?1 OR into_uid = ?1`,
+		`DELETE FROM conversations WHERE id = ?`,
+	} {
+		if _, err := tx.Exec(stmt, conversationUID); err != nil {
+			return fmt.Errorf("failed to delete conversation: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// SetConversationAlias sets the local title shown for a conversation, or
+// removes it when title is empty
+func (d *DB) SetConversationAlias(conversationUID, title string) error {
+	if title == "" {
+		if _, err := d.db.Exec(`DELETE FROM conversation_aliases WHERE conversation_uid = ?`, conversationUID); err != nil {
+			return fmt.Errorf("failed to remove conversation alias: %w", err)
+		}
+		return nil
+	}
+
+	_, err := d.db.Exec(`
+		INSERT INTO conversation_aliases (conversation_uid, title) VALUES (?, ?)
+		ON CONFLICT(conversation_uid) DO UPDATE SET title = excluded.title
+	`, conversationUID, title)
+	if err != nil {
+		return fmt.Errorf("failed to save conversation alias: %w", err)
+	}
+	return nil
+}
+
 // scanConversations is a helper to scan conversation rows
 func scanConversations(rows *sql.Rows) ([]Conversation, error) {
 	var conversations []Conversation
 	for rows.Next() {
 		var conv Conversation
 		var participantUIDs string
+		var participantsJSON string
 		var lastActivityUnix int64
+		var alias string
 
 		err := rows.Scan(
 			&conv.ID,
@@ -381,6 +969,10 @@ func scanConversations(rows *sql.Rows) ([]Conversation, error) {
 			&conv.IsArchived,
 			&conv.IsMuted,
 			&conv.IsPinned,
+			&participantsJSON,
+			&alias,
+			&conv.PinOrder,
+			&conv.MessageCount,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan conversation: %w", err)
@@ -391,6 +983,18 @@ func scanConversations(rows *sql.Rows) ([]Conversation, error) {
 			return nil, fmt.Errorf("failed to unmarshal participant UIDs: %w", err)
 		}
 
+		// Parse participant details
+		if participantsJSON != "" {
+			if err := json.Unmarshal([]byte(participantsJSON), &conv.Participants); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal participants: %w", err)
+			}
+		}
+
+		if alias != "" {
+			conv.OriginalTitle = conv.Title
+			conv.Title = alias
+		}
+
 		conv.LastActivity = time.Unix(lastActivityUnix, 0)
 		conversations = append(conversations, conv)
 	}
@@ -398,45 +1002,74 @@ func scanConversations(rows *sql.Rows) ([]Conversation, error) {
 	return conversations, rows.Err()
 }
 
+// messageColumns is the column list scanned by scanMessages. Queries select from
+// messages aliased as m, left-joined to the replied-to message aliased as r.
+const messageColumns = `m.id, m.contact_uid, m.timestamp, m.sender_uid, m.sender_name,
+		       m.conversation_uid, m.chat_title, m.content, m.platform, m.platform_id,
+		       m.is_sent, m.attachments, m.sort_key, m.reactions, m.reply_to_id,
+		       m.is_deleted, m.status, COALESCE(r.content, '')`
+
 // scanMessages is a helper to scan message rows
 func scanMessages(rows *sql.Rows) ([]Message, error) {
 	var messages []Message
 	for rows.Next() {
-		var msg Message
-		var timestampUnix int64
-		var attachmentsJSON string
-
-		err := rows.Scan(
-			&msg.ID,
-			&msg.ContactUID,
-			&timestampUnix,
-			&msg.SenderUID,
-			&msg.SenderName,
-			&msg.ConversationUID,
-			&msg.ChatTitle,
-			&msg.Text,
-			&msg.Platform,
-			&msg.PlatformID,
-			&msg.IsSent,
-			&attachmentsJSON,
-			&msg.SortKey,
-		)
+		msg, err := scanMessage(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan message: %w", err)
+			return nil, err
 		}
+		messages = append(messages, msg)
+	}
 
-		// Parse timestamp
-		msg.Timestamp = time.Unix(timestampUnix, 0)
+	return messages, rows.Err()
+}
 
-		// Parse attachments
-		if attachmentsJSON != "" {
-			if err := json.Unmarshal([]byte(attachmentsJSON), &msg.Attachments); err != nil {
-				return nil, fmt.Errorf("failed to unmarshal attachments: %w", err)
-			}
+// scanMessage scans the current row of a query selecting messageColumns
+func scanMessage(rows *sql.Rows) (Message, error) {
+	var msg Message
+	var timestampUnix int64
+	var attachmentsJSON string
+	var reactionsJSON string
+
+	err := rows.Scan(
+		&msg.ID,
+		&msg.ContactUID,
+		&timestampUnix,
+		&msg.SenderUID,
+		&msg.SenderName,
+		&msg.ConversationUID,
+		&msg.ChatTitle,
+		&msg.Text,
+		&msg.Platform,
+		&msg.PlatformID,
+		&msg.IsSent,
+		&attachmentsJSON,
+		&msg.SortKey,
+		&reactionsJSON,
+		&msg.ReplyToID,
+		&msg.IsDeleted,
+		&msg.Status,
+		&msg.ReplyToText,
+	)
+	if err != nil {
+		return msg, fmt.Errorf("failed to scan message: %w", err)
+	}
+
+	// Parse timestamp
+	msg.Timestamp = time.Unix(timestampUnix, 0)
+
+	// Parse attachments
+	if attachmentsJSON != "" {
+		if err := json.Unmarshal([]byte(attachmentsJSON), &msg.Attachments); err != nil {
+			return msg, fmt.Errorf("failed to unmarshal attachments: %w", err)
 		}
+	}
 
-		messages = append(messages, msg)
+	// Parse reactions
+	if reactionsJSON != "" {
+		if err := json.Unmarshal([]byte(reactionsJSON), &msg.Reactions); err != nil {
+			return msg, fmt.Errorf("failed to unmarshal reactions: %w", err)
+		}
 	}
 
-	return messages, rows.Err()
+	return msg, nil
 }
@@ -0,0 +1,25 @@
+// Package messages keeps a local SQLite copy of conversations and messages
+// synced from a provider such as Beeper Desktop. Like package contacts, it
+// can be embedded in other front-ends: nothing in it writes to the terminal
+// unless asked to, and syncing takes a context.
+//
+// The entry point is MessageManager, created with NewMessageManager from a
+// MessageProvider (e.g. NewBeeperProvider) and a config.Config:
+//
+//   - Sync fetches from the provider and saves the results. A
+//     BeeperProvider prints progress only to the writer given to
+//     SetProgressOutput.
+//   - Import saves the results of a one-off provider the same way, such as
+//     a WhatsAppExport reading a chat exported from WhatsApp.
+//   - ListAllConversations, GetConversation, GetMessagesForConversation,
+//     GetMessagesForConversationPage, GetUnifiedTimelineForContact, and
+//     SearchConversations read the local database. EachMessage streams a
+//     conversation's messages for exports too large to load at once.
+//   - RenameConversation, SetPinnedConversations, and MergeConversations
+//     change local-only data that's kept across syncs. IgnoreConversation
+//     deletes a conversation and keeps syncs from saving it again, until
+//     UnignoreConversation.
+//
+// Call Close when done to close the database. Errors are returned rather
+// than printed; diagnostics go to log/slog.
+package messages
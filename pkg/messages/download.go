@@ -0,0 +1,235 @@
+package messages
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AttachmentResolver is implemented by providers whose attachment URLs need
+// resolving before they can be downloaded, such as Beeper's mxc:// URLs
+type AttachmentResolver interface {
+	// ResolveAttachmentURL returns a file://, http://, or https:// URL for
+	// an attachment's SrcURL
+	ResolveAttachmentURL(ctx context.Context, srcURL string) (string, error)
+}
+
+// downloadAttempts is how many times each attachment is tried before it's
+// reported as failed. Every retry resumes from what was already saved.
+const downloadAttempts = 3
+
+// DownloadResult reports how downloading one attachment went
+type DownloadResult struct {
+	Ref     AttachmentRef
+	Path    string // Where the attachment was saved
+	Skipped bool   // It had already been downloaded
+	Resumed int64  // Bytes kept from an earlier, interrupted download
+	Err     error  // Why it couldn't be downloaded
+}
+
+// DownloadAttachments downloads attachments into dir, calling report after
+// each. Files are written to a .part file first and only renamed into place
+// once complete, so attachments already downloaded are skipped and
+// interrupted downloads resume where they left off. A failed attachment is
+// reported and the rest are still downloaded; only a cancelled ctx stops
+// the batch, returning its error.
+func (mm *MessageManager) DownloadAttachments(ctx context.Context, refs []AttachmentRef, dir string, report func(DownloadResult)) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create download directory: %w", err)
+	}
+
+	resolver, _ := mm.provider.(AttachmentResolver)
+	for _, ref := range refs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		result := DownloadResult{Ref: ref, Path: filepath.Join(dir, AttachmentFileName(ref))}
+		if _, err := os.Stat(result.Path); err == nil {
+			result.Skipped = true
+			report(result)
+			continue
+		}
+
+		for attempt := 1; attempt <= downloadAttempts; attempt++ {
+			var resumed int64
+			resumed, result.Err = downloadAttachment(ctx, resolver, ref, result.Path)
+			if attempt == 1 {
+				result.Resumed = resumed
+			}
+			if result.Err == nil || ctx.Err() != nil {
+				break
+			}
+			slog.Warn("attachment download failed", "message", ref.MessageID, "file", ref.FileName,
+				"attempt", attempt, "error", result.Err)
+			if attempt < downloadAttempts {
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Duration(attempt) * time.Second):
+				}
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		report(result)
+	}
+
+	return nil
+}
+
+// AttachmentFileName returns the name an attachment is saved under: its
+// date, a short hash identifying it, and its original file name, e.g.
+// "2024-03-01_1a2b3c4d_photo.jpg". The name is the same on every run, which
+// is how finished downloads are recognized.
+func AttachmentFileName(ref AttachmentRef) string {
+	sum := sha256.Sum256([]byte(ref.MessageID + "\x00" + ref.SrcURL))
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < ' ' || strings.ContainsRune(`:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, filepath.Base(ref.FileName))
+	if name == "" || name == "." || name == ".." {
+		name = "attachment"
+		if exts, _ := mime.ExtensionsByType(ref.MimeType); len(exts) > 0 {
+			name += exts[0]
+		}
+	}
+	return ref.Timestamp.Format("2006-01-02") + "_" + hex.EncodeToString(sum[:4]) + "_" + name
+}
+
+// downloadAttachment downloads one attachment to path by way of path.part,
+// resuming from the .part file if an earlier download left one. It returns
+// how many bytes were resumed from.
+func downloadAttachment(ctx context.Context, resolver AttachmentResolver, ref AttachmentRef, path string) (int64, error) {
+	src := ref.SrcURL
+	if src == "" {
+		return 0, errors.New("attachment has no source URL")
+	}
+	if resolver != nil && (strings.HasPrefix(src, "mxc://") || strings.HasPrefix(src, "localmxc://")) {
+		resolved, err := resolver.ResolveAttachmentURL(ctx, src)
+		if err != nil {
+			return 0, fmt.Errorf("failed to resolve %s: %w", src, err)
+		}
+		src = resolved
+	}
+
+	part := path + ".part"
+	var offset int64
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+
+	size := int64(ref.FileSize)
+	if size <= 0 || offset < size {
+		body, start, err := openAttachment(ctx, src, offset)
+		if err != nil {
+			return 0, err
+		}
+		defer body.Close()
+		if start < offset {
+			// The source can't resume, so start over
+			offset = start
+		}
+
+		flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		if offset == 0 {
+			flags |= os.O_TRUNC
+		}
+		f, err := os.OpenFile(part, flags, 0644)
+		if err != nil {
+			return offset, fmt.Errorf("failed to open %s: %w", part, err)
+		}
+		_, copyErr := io.Copy(f, body)
+		if err := f.Close(); err != nil && copyErr == nil {
+			copyErr = err
+		}
+		if copyErr != nil {
+			return offset, fmt.Errorf("download interrupted: %w", copyErr)
+		}
+	}
+
+	info, err := os.Stat(part)
+	if err != nil {
+		return offset, fmt.Errorf("failed to check download: %w", err)
+	}
+	if size > 0 && info.Size() != size {
+		if info.Size() > size {
+			// Too big to be resumed; the next try starts over
+			os.Remove(part)
+		}
+		return offset, fmt.Errorf("downloaded %d bytes, expected %d", info.Size(), size)
+	}
+
+	if err := os.Rename(part, path); err != nil {
+		return offset, fmt.Errorf("failed to save %s: %w", path, err)
+	}
+	return offset, nil
+}
+
+// openAttachment opens src for reading from offset onward, and returns the
+// offset it actually starts at: 0 if the source can't start part way
+func openAttachment(ctx context.Context, src string, offset int64) (io.ReadCloser, int64, error) {
+	u, err := url.Parse(src)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid attachment URL %s: %w", src, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to create request: %w", err)
+		}
+		if offset > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to download %s: %w", src, err)
+		}
+		switch resp.StatusCode {
+		case http.StatusPartialContent:
+			return resp.Body, offset, nil
+		case http.StatusOK:
+			return resp.Body, 0, nil
+		case http.StatusRequestedRangeNotSatisfiable:
+			// What was saved doesn't match the file any more
+			resp.Body.Close()
+			return openAttachment(ctx, src, 0)
+		default:
+			resp.Body.Close()
+			return nil, 0, fmt.Errorf("failed to download %s: %s", src, resp.Status)
+		}
+
+	case "file", "":
+		path := u.Path
+		if u.Scheme == "" {
+			path = src
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to open attachment: %w", err)
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, 0, fmt.Errorf("failed to open attachment: %w", err)
+		}
+		return f, offset, nil
+
+	default:
+		return nil, 0, fmt.Errorf("can't download %s URLs", u.Scheme)
+	}
+}
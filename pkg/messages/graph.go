@@ -0,0 +1,123 @@
+package messages
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/arjungandhi/dunbar/pkg/contacts"
+)
+
+// GraphNode is a contact in a ContactGraph
+type GraphNode struct {
+	UID  string `json:"uid"`
+	Name string `json:"name"`
+}
+
+// GraphEdge connects two contacts who are both in one or more group conversations
+type GraphEdge struct {
+	From          string   `json:"from"`          // Contact UID
+	To            string   `json:"to"`            // Contact UID
+	Conversations []string `json:"conversations"` // Titles of the shared group conversations
+}
+
+// ContactGraph shows which contacts know each other through group conversations
+type ContactGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// BuildContactGraph links contacts who share a group conversation. Group
+// participants are matched to contacts by phone number or email; participants
+// who aren't contacts are left out. Every contact is a node, connected or not.
+func BuildContactGraph(contactsList []contacts.Contact, conversations []Conversation) ContactGraph {
+	graph := ContactGraph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	for _, c := range contactsList {
+		graph.Nodes = append(graph.Nodes, GraphNode{UID: c.UID, Name: c.DisplayName()})
+	}
+
+	type pair struct{ from, to string }
+	shared := make(map[pair][]string)
+
+	for _, conv := range conversations {
+		if conv.Type != "group" {
+			continue
+		}
+
+		// Contacts in this conversation, each once
+		seen := make(map[string]bool)
+		var members []string
+		for _, p := range conv.Participants {
+			if p.IsSelf {
+				continue
+			}
+			contact := contacts.FindByPhoneOrEmail(contactsList, p.PhoneNumber, p.Email)
+			if contact == nil || seen[contact.UID] {
+				continue
+			}
+			seen[contact.UID] = true
+			members = append(members, contact.UID)
+		}
+
+		sort.Strings(members)
+		for i := range members {
+			for _, to := range members[i+1:] {
+				key := pair{members[i], to}
+				shared[key] = append(shared[key], conv.Title)
+			}
+		}
+	}
+
+	for key, titles := range shared {
+		graph.Edges = append(graph.Edges, GraphEdge{From: key.from, To: key.to, Conversations: titles})
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].From != graph.Edges[j].From {
+			return graph.Edges[i].From < graph.Edges[j].From
+		}
+		return graph.Edges[i].To < graph.Edges[j].To
+	})
+
+	return graph
+}
+
+// WriteDOT writes the graph in Graphviz DOT format. Edges are weighted and
+// labelled by the number of shared conversations.
+func (g ContactGraph) WriteDOT(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("graph dunbar {\n")
+	sb.WriteString("\tnode [shape=box, style=rounded];\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&sb, "\t%s [label=%s];\n", dotQuote(n.UID), dotQuote(n.Name))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&sb, "\t%s -- %s [weight=%d, label=\"%d\", tooltip=%s];\n",
+			dotQuote(e.From), dotQuote(e.To), len(e.Conversations), len(e.Conversations),
+			dotQuote(strings.Join(e.Conversations, ", ")))
+	}
+	sb.WriteString("}\n")
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("failed to write graph: %w", err)
+	}
+	return nil
+}
+
+// WriteJSON writes the graph as indented JSON
+func (g ContactGraph) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal graph: %w", err)
+	}
+	if _, err := fmt.Fprintln(w, string(data)); err != nil {
+		return fmt.Errorf("failed to write graph: %w", err)
+	}
+	return nil
+}
+
+// dotQuote quotes a string as a DOT identifier
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
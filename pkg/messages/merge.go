@@ -0,0 +1,65 @@
+package messages
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FindDuplicateConversations groups conversations that look like the same
+// chat synced through different accounts: same platform, type, and title,
+// but a different account. Each group has at least two conversations, most
+// recently active first.
+func FindDuplicateConversations(conversations []Conversation) [][]Conversation {
+	groups := make(map[string][]Conversation)
+	var keys []string
+	for _, conv := range conversations {
+		title := conv.Title
+		if conv.OriginalTitle != "" {
+			title = conv.OriginalTitle
+		}
+		key := strings.ToLower(conv.Platform + "\x00" + conv.Type + "\x00" + strings.TrimSpace(title))
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], conv)
+	}
+
+	var duplicates [][]Conversation
+	for _, key := range keys {
+		group := groups[key]
+		accounts := make(map[string]bool)
+		for _, conv := range group {
+			accounts[conv.AccountID] = true
+		}
+		if len(accounts) < 2 {
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool {
+			return group[i].LastActivity.After(group[j].LastActivity)
+		})
+		duplicates = append(duplicates, group)
+	}
+
+	return duplicates
+}
+
+// MergeConversations merges conversation from into conversation into: from's
+// messages are shown under into, into's unread count and last activity
+// include from's, and from is no longer listed. The merge is kept across syncs.
+func (mm *MessageManager) MergeConversations(into, from string) error {
+	for _, id := range []string{into, from} {
+		conv, err := mm.db.GetConversation(id)
+		if err != nil {
+			return err
+		}
+		if conv == nil {
+			return fmt.Errorf("conversation not found: %s", id)
+		}
+	}
+
+	// Cached messages of into are missing from's
+	defer mm.clearCache()
+
+	return mm.db.MergeConversations(into, from)
+}
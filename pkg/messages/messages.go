@@ -1,6 +1,14 @@
 package messages
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/arjungandhi/dunbar/pkg/config"
@@ -21,6 +29,36 @@ type Attachment struct {
 	IsVoiceNote bool    `json:"is_voice_note"` // True if voice note
 }
 
+// AttachmentRef is an attachment along with the message it belongs to, as
+// listed by ListAttachments
+type AttachmentRef struct {
+	MessageID string    `json:"message_id"` // ID of the message carrying the attachment
+	Timestamp time.Time `json:"timestamp"`  // When the message was sent
+	Type      string    `json:"type"`       // "img", "video", "audio", "unknown"
+	FileName  string    `json:"file_name"`  // Original filename
+	FileSize  float64   `json:"file_size"`  // Size in bytes
+	MimeType  string    `json:"mime_type"`  // MIME type (e.g., 'image/png')
+	SrcURL    string    `json:"src_url"`    // URL or path to file
+}
+
+// Reaction represents a reaction left on a message by a participant
+type Reaction struct {
+	ID            string `json:"id"`             // Reaction ID on the platform
+	ParticipantID string `json:"participant_id"` // UID of the participant who reacted
+	Key           string `json:"key"`            // Emoji or platform-specific reaction key
+	IsEmoji       bool   `json:"is_emoji"`       // True if Key is an emoji
+	ImgURL        string `json:"img_url"`        // URL to the reaction image (custom reactions)
+}
+
+// Participant represents a member of a conversation
+type Participant struct {
+	UID         string `json:"uid"`                    // Platform user ID
+	Name        string `json:"name"`                   // Display name on the platform
+	PhoneNumber string `json:"phone_number,omitempty"` // Phone number in E.164 format, if known
+	Email       string `json:"email,omitempty"`        // Email address, if known
+	IsSelf      bool   `json:"is_self"`                // True if this is your own account
+}
+
 // Conversation represents a chat or conversation thread
 type Conversation struct {
 	// Conversation identification
@@ -29,24 +67,31 @@ type Conversation struct {
 	Platform  string `json:"platform"`   // Platform name (WhatsApp, Telegram, etc.)
 
 	// Conversation details
-	Title string `json:"title"` // Display name/title of conversation
-	Type  string `json:"type"`  // "single" for DMs, "group" for group chats
+	Title         string `json:"title"`                    // Display name/title of conversation
+	OriginalTitle string `json:"original_title,omitempty"` // Platform title, set when Title is a local alias
+	Type          string `json:"type"`                     // "single" for DMs, "group" for group chats
 
 	// Participants
-	ParticipantUIDs  []string `json:"participant_uids"`  // List of participant UIDs
-	ParticipantCount int      `json:"participant_count"` // Total number of participants
+	ParticipantUIDs  []string      `json:"participant_uids"`  // List of participant UIDs
+	ParticipantCount int           `json:"participant_count"` // Total number of participants
+	Participants     []Participant `json:"participants"`      // Participant details (names, handles)
 
 	// Status
 	UnreadCount  int64     `json:"unread_count"`  // Number of unread messages
-	LastActivity time.Time `json:"last_activity"` // Last message timestamp
+	MessageCount int64     `json:"message_count"` // Number of messages stored locally, (including any merged into it)
+	LastActivity time.Time `json:"last_activity"` // Newest stored message's timestamp, or the platform's last activity if none are stored
 
 	// Settings
-	IsArchived bool `json:"is_archived"` // True if archived
-	IsMuted    bool `json:"is_muted"`    // True if muted
-	IsPinned   bool `json:"is_pinned"`   // True if pinned
+	IsArchived bool `json:"is_archived"`         // True if archived
+	IsMuted    bool `json:"is_muted"`            // True if muted
+	IsPinned   bool `json:"is_pinned"`           // True if pinned
+	PinOrder   int  `json:"pin_order,omitempty"` // Local pin position (1, 2, ...); zero if not pinned locally
 }
 
-// Message represents a communication event with a contact
+// Message represents a communication event with a contact. Its JSON form,
+// given by the struct tags below, is also the schema of JSON Lines exports
+// ('dunbar messages export --format jsonl'): one object per line, with
+// timestamps in RFC 3339.
 type Message struct {
 	// Message identification
 	ID string `json:"id"` // Unique identifier for the message
@@ -58,7 +103,7 @@ type Message struct {
 	SenderName      string    `json:"sender_name"`      // Display name of sender
 	ConversationUID string    `json:"conversation_uid"` // UID of the conversation thread
 	ChatTitle       string    `json:"chat_title"`       // Name of the conversation
-	Text            string    `json:"content"`          // Message text content
+	Text            string    `json:"text"`             // Message text content
 	Platform        string    `json:"platform"`         // Platform used (WhatsApp, Telegram, etc.)
 	PlatformID      string    `json:"platform_id"`      // ID on the platform
 
@@ -66,16 +111,63 @@ type Message struct {
 	IsSent      bool         `json:"is_sent"`     // True if you sent this message
 	Attachments []Attachment `json:"attachments"` // Files, images, videos attached
 	SortKey     string       `json:"sort_key"`    // Platform-specific sort key for ordering
+
+	// Reactions and replies
+	Reactions   []Reaction `json:"reactions"`               // Reactions left on this message
+	ReplyToID   string     `json:"reply_to_id"`             // ID of the message this one replies to
+	ReplyToText string     `json:"reply_to_text,omitempty"` // Text of the replied-to message (resolved on read)
+
+	IsDeleted bool   `json:"is_deleted"`       // True if the message was deleted (unsent) on the platform
+	Status    string `json:"status,omitempty"` // Delivery state of a sent message (see the Status constants); empty if unknown
+}
+
+// Delivery states recorded in Message.Status for messages you sent
+const (
+	StatusSent      = "sent"      // Accepted by the platform
+	StatusDelivered = "delivered" // Reached the recipient's device
+	StatusRead      = "read"      // Seen by the recipient
+	StatusFailed    = "failed"    // Could not be sent
+)
+
+// UnmarshalJSON decodes a message. Text was serialized under the "content"
+// key before it became "text", so JSON saved by older versions is still read.
+func (m *Message) UnmarshalJSON(data []byte) error {
+	type message Message // Without this method, to avoid recursion
+	aux := struct {
+		*message
+		Content *string `json:"content"`
+	}{message: (*message)(m)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if m.Text == "" && aux.Content != nil {
+		m.Text = *aux.Content
+	}
+	return nil
 }
 
 type MessageManager struct {
 	provider MessageProvider
 	db       *DB
+	dbPath   string
 	config   config.Config
+
+	// cacheMu guards messageCache, which holds conversations' messages once
+	// loaded so TUI navigation doesn't re-query the database. Sync clears it.
+	cacheMu      sync.Mutex
+	messageCache map[string][]Message
 }
 
+// MessageProvider is a remote source of messages. Sync should stop when ctx
+// is cancelled and may return partial results alongside the error.
 type MessageProvider interface {
-	Sync() ([]Conversation, []Message, error)
+	Sync(ctx context.Context) ([]Conversation, []Message, error)
+}
+
+// DBPath returns where the messages database is stored
+func DBPath(config config.Config) string {
+	return filepath.Join(config.DataDir, "messages.db")
 }
 
 func NewMessageManager(provider MessageProvider, config config.Config) (*MessageManager, error) {
@@ -84,8 +176,8 @@ func NewMessageManager(provider MessageProvider, config config.Config) (*Message
 		return nil, err
 	}
 
-	// Open database at DunbarDir/messages.db
-	dbPath := config.DunbarDir + "/messages.db"
+	// Open database at DataDir/messages.db
+	dbPath := DBPath(config)
 	db, err := OpenDB(dbPath)
 	if err != nil {
 		return nil, err
@@ -94,7 +186,10 @@ func NewMessageManager(provider MessageProvider, config config.Config) (*Message
 	return &MessageManager{
 		provider: provider,
 		db:       db,
+		dbPath:   dbPath,
 		config:   config,
+
+		messageCache: make(map[string][]Message),
 	}, nil
 }
 
@@ -103,25 +198,80 @@ func (mm *MessageManager) Close() error {
 	return mm.db.Close()
 }
 
-// Sync fetches data from the provider and saves it to the database
-func (mm *MessageManager) Sync() error {
+// Sync fetches data from the provider and saves it to the database. Partial
+// results from an interrupted sync are saved before the error is returned.
+func (mm *MessageManager) Sync(ctx context.Context) error {
+	// Whatever was saved makes cached messages stale
+	defer mm.clearCache()
+
 	// Fetch from provider
-	conversations, messages, err := mm.provider.Sync()
+	conversations, messages, syncErr := mm.provider.Sync(ctx)
+	if syncErr != nil {
+		slog.Error("messages sync failed", "conversations", len(conversations), "messages", len(messages), "error", syncErr)
+		if len(conversations) == 0 {
+			return syncErr
+		}
+	}
+
+	conversations, messages, err := mm.save(conversations, messages)
 	if err != nil {
 		return err
 	}
 
+	if syncErr != nil {
+		return syncErr
+	}
+
+	slog.Info("messages sync complete", "conversations", len(conversations), "messages", len(messages))
+	return nil
+}
+
+// Import saves everything from a one-off source, such as a WhatsAppExport,
+// as Sync does from the manager's own provider, and returns the
+// conversations saved and how many messages they hold from it
+func (mm *MessageManager) Import(ctx context.Context, provider MessageProvider) ([]Conversation, int, error) {
+	defer mm.clearCache()
+
+	conversations, messages, err := provider.Sync(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	conversations, messages, err = mm.save(conversations, messages)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	slog.Info("messages import complete", "conversations", len(conversations), "messages", len(messages))
+	return conversations, len(messages), nil
+}
+
+// save stores conversations and their messages, leaving out ignored ones,
+// and returns what was stored
+func (mm *MessageManager) save(conversations []Conversation, messages []Message) ([]Conversation, []Message, error) {
+	// Ignored conversations are never stored
+	ignore, err := mm.config.LoadIgnoreList()
+	if err != nil {
+		return nil, nil, err
+	}
+	if ignored := config.IgnoredIDs(ignore.Conversations); len(ignored) > 0 {
+		conversations = slices.DeleteFunc(conversations, func(c Conversation) bool { return ignored[c.ID] })
+		messages = slices.DeleteFunc(messages, func(m Message) bool { return ignored[m.ConversationUID] })
+	}
+
 	// Save conversations to database
 	if err := mm.db.SaveConversations(conversations); err != nil {
-		return err
+		slog.Error("failed to save conversations", "count", len(conversations), "error", err)
+		return nil, nil, err
 	}
 
 	// Save messages to database
 	if err := mm.db.SaveMessages(messages); err != nil {
-		return err
+		slog.Error("failed to save messages", "count", len(messages), "error", err)
+		return nil, nil, err
 	}
 
-	return nil
+	return conversations, messages, nil
 }
 
 // Query methods that use the database
@@ -138,14 +288,127 @@ func (mm *MessageManager) GetConversation(conversationUID string) (*Conversation
 	return mm.db.GetConversation(conversationUID)
 }
 
+// RenameConversation gives a conversation a local title that's shown instead
+// of the platform's and kept across syncs. An empty title removes the alias.
+func (mm *MessageManager) RenameConversation(conversationUID, title string) error {
+	return mm.db.SetConversationAlias(conversationUID, title)
+}
+
+// IgnoreConversation keeps a conversation out of dunbar: it's deleted from
+// the local database, and syncs skip it until UnignoreConversation is called
+func (mm *MessageManager) IgnoreConversation(conversationUID string) error {
+	conv, err := mm.db.GetConversation(conversationUID)
+	if err != nil {
+		return err
+	}
+	item := config.IgnoredItem{ID: conversationUID}
+	if conv != nil {
+		item.Name = conv.Title
+	}
+
+	list, err := mm.config.LoadIgnoreList()
+	if err != nil {
+		return err
+	}
+	if config.AddIgnored(&list.Conversations, item) {
+		if err := mm.config.SaveIgnoreList(list); err != nil {
+			return err
+		}
+	}
+
+	defer mm.clearCache()
+	return mm.db.DeleteConversation(conversationUID)
+}
+
+// UnignoreConversation takes a conversation off the ignore list, so the
+// next sync fetches it again
+func (mm *MessageManager) UnignoreConversation(conversationUID string) error {
+	list, err := mm.config.LoadIgnoreList()
+	if err != nil {
+		return err
+	}
+	if !config.RemoveIgnored(&list.Conversations, conversationUID) {
+		return fmt.Errorf("conversation not ignored: %s", conversationUID)
+	}
+	return mm.config.SaveIgnoreList(list)
+}
+
+// SetPinnedConversations pins the given conversations locally in that order
+// and unpins every other conversation. Pins are kept across syncs.
+func (mm *MessageManager) SetPinnedConversations(conversationUIDs []string) error {
+	return mm.db.SetPinnedConversations(conversationUIDs)
+}
+
 func (mm *MessageManager) GetConversationsForContact(contactUID string) ([]Conversation, error) {
 	return mm.db.GetConversationsForContact(contactUID)
 }
 
-func (mm *MessageManager) ListAllConversations() ([]Conversation, error) {
-	return mm.db.ListAllConversations()
+func (mm *MessageManager) ListAllConversations(filter ConversationFilter) ([]Conversation, error) {
+	return mm.db.ListAllConversations(filter)
 }
 
+// GetMessagesForConversation returns a conversation's messages, newest
+// first. Results are cached; callers get their own copy to modify.
 func (mm *MessageManager) GetMessagesForConversation(conversationUID string) ([]Message, error) {
-	return mm.db.GetMessagesForConversation(conversationUID)
+	mm.cacheMu.Lock()
+	cached, ok := mm.messageCache[conversationUID]
+	mm.cacheMu.Unlock()
+	if ok {
+		return slices.Clone(cached), nil
+	}
+
+	msgs, err := mm.db.GetMessagesForConversation(conversationUID)
+	if err != nil {
+		return nil, err
+	}
+
+	mm.cacheMu.Lock()
+	mm.messageCache[conversationUID] = msgs
+	mm.cacheMu.Unlock()
+
+	return slices.Clone(msgs), nil
+}
+
+// GetMessagesForConversationPage returns up to limit of a conversation's
+// messages, newest first, that come before the message with the given ID.
+// Pass an empty ID for the most recent page, then the ID of the last message
+// returned to fetch the next older page. Pages aren't cached.
+func (mm *MessageManager) GetMessagesForConversationPage(conversationUID, beforeID string, limit int) ([]Message, error) {
+	return mm.db.GetMessagesForConversationPage(conversationUID, beforeID, limit)
+}
+
+// EachMessage calls fn with each of a conversation's messages sent at or
+// after since (every message if since is zero), oldest first. Messages are
+// streamed from the database rather than loaded at once, and aren't cached.
+func (mm *MessageManager) EachMessage(conversationUID string, since time.Time, fn func(Message) error) error {
+	return mm.db.EachMessage(conversationUID, since, fn)
+}
+
+// ListAttachments returns the attachments in a conversation, oldest first,
+// without downloading them
+func (mm *MessageManager) ListAttachments(conversationUID string) ([]AttachmentRef, error) {
+	return mm.db.ListAttachments(conversationUID)
+}
+
+// clearCache drops cached messages after the database changes
+func (mm *MessageManager) clearCache() {
+	mm.cacheMu.Lock()
+	defer mm.cacheMu.Unlock()
+	clear(mm.messageCache)
+}
+
+// Stats summarizes the stored messages, with daily counts covering the given number of days
+func (mm *MessageManager) Stats(days int) (*Stats, error) {
+	stats, err := mm.db.Stats(days)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(mm.dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat database: %w", err)
+	}
+	stats.DBSize = info.Size()
+
+	return stats, nil
 }
@@ -0,0 +1,55 @@
+package messages
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMessageJSONUsesTextKey(t *testing.T) {
+	data, err := json.Marshal(Message{ID: "m1", Text: "hello"})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Unmarshal into map: %v", err)
+	}
+	if fields["text"] != "hello" {
+		t.Errorf(`"text" = %v, want "hello" in %s`, fields["text"], data)
+	}
+	if _, ok := fields["content"]; ok {
+		t.Errorf(`unexpected "content" key in %s`, data)
+	}
+
+	var decoded Message
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if decoded.Text != "hello" {
+		t.Errorf("round trip Text = %q, want %q", decoded.Text, "hello")
+	}
+}
+
+func TestMessageJSONReadsLegacyContentKey(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"content only", `{"id": "m1", "content": "old"}`, "old"},
+		{"text wins", `{"id": "m1", "text": "new", "content": "old"}`, "new"},
+		{"neither", `{"id": "m1"}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var msg Message
+			if err := json.Unmarshal([]byte(tt.json), &msg); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if msg.Text != tt.want {
+				t.Errorf("Text = %q, want %q", msg.Text, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package messages
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ConversationMatch is a conversation found by SearchConversations
+type ConversationMatch struct {
+	Conversation Conversation
+	TitleMatch   bool     // True if the query is in the conversation's title
+	Messages     int      // How many of its messages contain the query
+	Latest       *Message // The most recent message containing the query, if any
+}
+
+// SearchConversations finds the conversations whose title or message text
+// contains query, ignoring case. Conversations with a matching title come
+// first, then the rest by their most recent matching message.
+func (mm *MessageManager) SearchConversations(query string) ([]ConversationMatch, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, nil
+	}
+
+	conversations, err := mm.db.ListAllConversations(ConversationFilter{})
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := mm.db.SearchMessages(query)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make(map[string]*ConversationMatch)
+	var order []string
+	match := func(conv Conversation) *ConversationMatch {
+		if m, ok := matches[conv.ID]; ok {
+			return m
+		}
+		matches[conv.ID] = &ConversationMatch{Conversation: conv}
+		order = append(order, conv.ID)
+		return matches[conv.ID]
+	}
+
+	needle := strings.ToLower(query)
+	byID := make(map[string]Conversation, len(conversations))
+	for _, conv := range conversations {
+		byID[conv.ID] = conv
+		if strings.Contains(strings.ToLower(conv.Title), needle) {
+			match(conv).TitleMatch = true
+		}
+	}
+
+	// Messages are newest first, so the first one seen is the latest
+	for i, msg := range msgs {
+		conv, ok := byID[msg.ConversationUID]
+		if !ok {
+			continue
+		}
+		m := match(conv)
+		m.Messages++
+		if m.Latest == nil {
+			m.Latest = &msgs[i]
+		}
+	}
+
+	results := make([]ConversationMatch, len(order))
+	for i, id := range order {
+		results[i] = *matches[id]
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].TitleMatch != results[j].TitleMatch {
+			return results[i].TitleMatch
+		}
+		return latestActivity(results[i]).After(latestActivity(results[j]))
+	})
+	return results, nil
+}
+
+// latestActivity returns when a match's most recent matching message was
+// sent, or the conversation's last activity if only its title matched
+func latestActivity(m ConversationMatch) time.Time {
+	if m.Latest != nil {
+		return m.Latest.Timestamp
+	}
+	return m.Conversation.LastActivity
+}
+
+// Reason summarizes why a conversation matched, e.g. "title, 3 messages"
+func (m ConversationMatch) Reason() string {
+	var parts []string
+	if m.TitleMatch {
+		parts = append(parts, "title")
+	}
+	switch m.Messages {
+	case 0:
+	case 1:
+		parts = append(parts, "1 message")
+	default:
+		parts = append(parts, fmt.Sprintf("%d messages", m.Messages))
+	}
+	return strings.Join(parts, ", ")
+}
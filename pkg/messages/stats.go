@@ -0,0 +1,132 @@
+package messages
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Stats summarizes the whole message corpus
+type Stats struct {
+	Conversations int                `json:"conversations"`
+	Messages      int                `json:"messages"`
+	Platforms     []PlatformStats    `json:"platforms"`
+	Busiest       *ConversationStats `json:"busiest_conversation,omitempty"`
+	Daily         []DayStats         `json:"daily"`         // One entry per day, oldest first
+	DBSize        int64              `json:"db_size_bytes"` // Size of messages.db on disk
+}
+
+// PlatformStats counts conversations and messages on one platform
+type PlatformStats struct {
+	Platform      string `json:"platform"`
+	Conversations int    `json:"conversations"`
+	Messages      int    `json:"messages"`
+}
+
+// ConversationStats counts messages in one conversation
+type ConversationStats struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Platform string `json:"platform"`
+	Messages int    `json:"messages"`
+}
+
+// DayStats counts messages sent or received on one day
+type DayStats struct {
+	Date     string `json:"date"` // YYYY-MM-DD in local time
+	Messages int    `json:"messages"`
+}
+
+// Stats computes corpus statistics with aggregate queries, including a daily
+// message count for the given number of days up to and including today
+func (d *DB) Stats(days int) (*Stats, error) {
+	stats := &Stats{}
+
+	if err := d.db.QueryRow(`SELECT COUNT(*) FROM conversations c WHERE ` + notMerged).Scan(&stats.Conversations); err != nil {
+		return nil, fmt.Errorf("failed to count conversations: %w", err)
+	}
+	if err := d.db.QueryRow(`SELECT COUNT(*) FROM messages`).Scan(&stats.Messages); err != nil {
+		return nil, fmt.Errorf("failed to count messages: %w", err)
+	}
+
+	// Per-platform breakdown
+	rows, err := d.db.Query(`
+		SELECT c.platform, COUNT(*), COALESCE(SUM(mc.count), 0)
+		FROM conversations c
+		LEFT JOIN (
+			SELECT conversation_uid, COUNT(*) AS count
+			FROM messages
+			GROUP BY conversation_uid
+		) mc ON mc.conversation_uid = c.id
+		WHERE ` + notMerged + `
+		GROUP BY c.platform
+		ORDER BY 3 DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query platform stats: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p PlatformStats
+		if err := rows.Scan(&p.Platform, &p.Conversations, &p.Messages); err != nil {
+			return nil, fmt.Errorf("failed to scan platform stats: %w", err)
+		}
+		stats.Platforms = append(stats.Platforms, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read platform stats: %w", err)
+	}
+
+	// Busiest conversation
+	var busiest ConversationStats
+	err = d.db.QueryRow(`
+		SELECT c.id, c.title, c.platform, COUNT(*) AS count
+		FROM messages m
+		JOIN conversations c ON c.id = m.conversation_uid
+		GROUP BY c.id
+		ORDER BY count DESC
+		LIMIT 1
+	`).Scan(&busiest.ID, &busiest.Title, &busiest.Platform, &busiest.Messages)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to query busiest conversation: %w", err)
+	}
+	if err == nil {
+		stats.Busiest = &busiest
+	}
+
+	// Messages per day, filling in days with no messages
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, -(days - 1))
+
+	dayRows, err := d.db.Query(`
+		SELECT strftime('%Y-%m-%d', timestamp, 'unixepoch', 'localtime') AS day, COUNT(*)
+		FROM messages
+		WHERE timestamp >= ?
+		GROUP BY day
+	`, start.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily stats: %w", err)
+	}
+	defer dayRows.Close()
+
+	counts := make(map[string]int)
+	for dayRows.Next() {
+		var day string
+		var count int
+		if err := dayRows.Scan(&day, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan daily stats: %w", err)
+		}
+		counts[day] = count
+	}
+	if err := dayRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read daily stats: %w", err)
+	}
+
+	for i := 0; i < days; i++ {
+		day := start.AddDate(0, 0, i).Format("2006-01-02")
+		stats.Daily = append(stats.Daily, DayStats{Date: day, Messages: counts[day]})
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,89 @@
+package messages
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/arjungandhi/dunbar/pkg/contacts"
+)
+
+// GetConversationsWithContact returns the direct conversations, on any
+// platform, whose other participant has one of the contact's phone numbers
+// or email addresses
+func (mm *MessageManager) GetConversationsWithContact(contact contacts.Contact) ([]Conversation, error) {
+	conversations, err := mm.db.ListAllConversations(ConversationFilter{Type: "single"})
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []contacts.Contact{contact}
+	var linked []Conversation
+	for _, conv := range conversations {
+		for _, p := range conv.Participants {
+			if p.IsSelf {
+				continue
+			}
+			if contacts.FindByPhoneOrEmail(candidates, p.PhoneNumber, p.Email) != nil {
+				linked = append(linked, conv)
+				break
+			}
+		}
+	}
+	return linked, nil
+}
+
+// LastContacted returns when each contact's most recently active direct
+// conversation last had activity, keyed by contact UID. Contacts without a
+// linked conversation are left out.
+func (mm *MessageManager) LastContacted(contactsList []contacts.Contact) (map[string]time.Time, error) {
+	conversations, err := mm.db.ListAllConversations(ConversationFilter{Type: "single"})
+	if err != nil {
+		return nil, err
+	}
+
+	last := make(map[string]time.Time)
+	for _, conv := range conversations {
+		for _, p := range conv.Participants {
+			if p.IsSelf {
+				continue
+			}
+			if c := contacts.FindByPhoneOrEmail(contactsList, p.PhoneNumber, p.Email); c != nil {
+				if conv.LastActivity.After(last[c.UID]) {
+					last[c.UID] = conv.LastActivity
+				}
+				break
+			}
+		}
+	}
+	return last, nil
+}
+
+// GetUnifiedTimelineForContact merges the messages from every conversation
+// linked to a contact into one timeline, newest first. Each message carries
+// its conversation's platform.
+func (mm *MessageManager) GetUnifiedTimelineForContact(contact contacts.Contact) ([]Message, error) {
+	conversations, err := mm.GetConversationsWithContact(contact)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find conversations: %w", err)
+	}
+
+	var timeline []Message
+	for _, conv := range conversations {
+		msgs, err := mm.GetMessagesForConversation(conv.ID)
+		if err != nil {
+			return nil, err
+		}
+		for i := range msgs {
+			if msgs[i].Platform == "" {
+				msgs[i].Platform = conv.Platform
+			}
+		}
+		timeline = append(timeline, msgs...)
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].Timestamp.After(timeline[j].Timestamp)
+	})
+	return timeline, nil
+}
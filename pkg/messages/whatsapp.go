@@ -0,0 +1,459 @@
+package messages
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// whatsAppExportPrefix starts the IDs of conversations, messages, and
+// participants imported from WhatsApp exports, so they never collide with
+// synced ones
+const whatsAppExportPrefix = "whatsapp-export:"
+
+// Date orders of the dates in a WhatsApp export, which follow the exporting
+// phone's locale
+const (
+	DateOrderDMY = "dmy" // 31/12/2023
+	DateOrderMDY = "mdy" // 12/31/2023
+)
+
+// WhatsAppExport is a MessageProvider that reads a chat exported with
+// WhatsApp's "Export chat": the _chat.txt (or "WhatsApp Chat with NAME.txt")
+// file, or the .zip it came in. Each Sync returns the one conversation the
+// export holds, so it's meant for MessageManager.Import rather than regular
+// syncing. Importing the same chat again, or a later export of it, updates
+// the messages already imported instead of duplicating them.
+type WhatsAppExport struct {
+	path      string
+	self      string // Your name in the export, to mark messages you sent
+	title     string // Conversation title, overriding the one from the file name
+	dateOrder string // DateOrderDMY or DateOrderMDY; empty to guess
+}
+
+// NewWhatsAppExport creates a provider for the export at path
+func NewWhatsAppExport(path string) *WhatsAppExport {
+	return &WhatsAppExport{path: path}
+}
+
+// SetSelf sets your name as it appears in the export. Without it, you're
+// only recognized in a one-to-one chat whose title names the other person.
+func (w *WhatsAppExport) SetSelf(name string) {
+	w.self = strings.TrimSpace(name)
+}
+
+// SetTitle sets the conversation title, which is otherwise taken from the
+// export's file name
+func (w *WhatsAppExport) SetTitle(title string) {
+	w.title = strings.TrimSpace(title)
+}
+
+// SetDateOrder sets whether dates are day or month first (DateOrderDMY or
+// DateOrderMDY). By default it's guessed from the dates in the export.
+func (w *WhatsAppExport) SetDateOrder(order string) error {
+	switch order {
+	case "", DateOrderDMY, DateOrderMDY:
+		w.dateOrder = order
+		return nil
+	default:
+		return fmt.Errorf("unknown date order %q (use %s or %s)", order, DateOrderDMY, DateOrderMDY)
+	}
+}
+
+// Sync parses the export
+func (w *WhatsAppExport) Sync(ctx context.Context) ([]Conversation, []Message, error) {
+	lines, mediaDir, err := w.readLines()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	entries := parseWhatsAppLines(lines)
+	if len(entries) == 0 {
+		return nil, nil, fmt.Errorf("no WhatsApp messages found in %s", w.path)
+	}
+
+	order := w.dateOrder
+	if order == "" {
+		order = guessDateOrder(entries)
+	}
+
+	title := w.title
+	if title == "" {
+		title = whatsAppChatTitle(w.path)
+	}
+	convID := whatsAppExportPrefix + shortHash(strings.ToLower(title))
+
+	// Everyone who wrote, in order of first message
+	var senders []string
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if e.sender != "" && !seen[e.sender] {
+			seen[e.sender] = true
+			senders = append(senders, e.sender)
+		}
+	}
+
+	self := w.self
+	if self == "" && len(senders) == 2 {
+		// In a one-to-one chat named after the other person, the other
+		// sender is you
+		for i, s := range senders {
+			if strings.EqualFold(s, title) {
+				self = senders[1-i]
+			}
+		}
+	}
+
+	conv := Conversation{
+		ID:        convID,
+		AccountID: strings.TrimSuffix(whatsAppExportPrefix, ":"),
+		Platform:  "whatsapp",
+		Title:     title,
+		Type:      "single",
+	}
+	if len(senders) > 2 {
+		conv.Type = "group"
+	}
+	for _, s := range senders {
+		uid := whatsAppExportPrefix + s
+		conv.ParticipantUIDs = append(conv.ParticipantUIDs, uid)
+		conv.Participants = append(conv.Participants, Participant{UID: uid, Name: s, IsSelf: strings.EqualFold(s, self)})
+	}
+	conv.ParticipantCount = len(senders)
+
+	var msgs []Message
+	occurrences := map[int64]int{} // Messages so far per timestamp, to tell apart ones sharing it
+	for _, e := range entries {
+		if e.sender == "" || isWhatsAppNotice(e.text) {
+			// System notices, such as the end-to-end encryption banner
+			continue
+		}
+		ts, err := e.time(order)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse the date on line %d: %w", e.line, err)
+		}
+
+		n := occurrences[ts.Unix()]
+		occurrences[ts.Unix()]++
+		sortKey := fmt.Sprintf("%d.%04d", ts.Unix(), n)
+
+		text, attachments, deleted := whatsAppContent(e.text, mediaDir)
+		sum := sha256.Sum256([]byte(strings.Join([]string{convID, sortKey, e.sender, e.text}, "\x00")))
+		id := whatsAppExportPrefix + hex.EncodeToString(sum[:12])
+
+		senderUID := whatsAppExportPrefix + e.sender
+		msgs = append(msgs, Message{
+			ID:              id,
+			ContactUID:      senderUID,
+			Timestamp:       ts,
+			SenderUID:       senderUID,
+			SenderName:      e.sender,
+			ConversationUID: convID,
+			ChatTitle:       title,
+			Text:            text,
+			Platform:        conv.Platform,
+			PlatformID:      id,
+			IsSent:          strings.EqualFold(e.sender, self),
+			Attachments:     attachments,
+			SortKey:         sortKey,
+			IsDeleted:       deleted,
+		})
+		if ts.After(conv.LastActivity) {
+			conv.LastActivity = ts
+		}
+	}
+
+	return []Conversation{conv}, msgs, nil
+}
+
+// readLines reads the chat text, from inside the zip if the export is one.
+// Alongside it returns the directory the export's media files are in, or ""
+// if they can't be linked to (a zip's are only inside the zip).
+func (w *WhatsAppExport) readLines() ([]string, string, error) {
+	if strings.EqualFold(filepath.Ext(w.path), ".zip") {
+		zr, err := zip.OpenReader(w.path)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to open %s: %w", w.path, err)
+		}
+		defer zr.Close()
+
+		var chat *zip.File
+		for _, f := range zr.File {
+			if strings.Contains(f.Name, "/") || !strings.EqualFold(filepath.Ext(f.Name), ".txt") {
+				continue
+			}
+			if chat == nil || f.Name == "_chat.txt" {
+				chat = f
+			}
+		}
+		if chat == nil {
+			return nil, "", fmt.Errorf("no chat text file in %s", w.path)
+		}
+		r, err := chat.Open()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read %s in %s: %w", chat.Name, w.path, err)
+		}
+		defer r.Close()
+		lines, err := scanLines(r)
+		return lines, "", err
+	}
+
+	f, err := os.Open(w.path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open %s: %w", w.path, err)
+	}
+	defer f.Close()
+	lines, err := scanLines(f)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", w.path, err)
+	}
+	dir, err := filepath.Abs(filepath.Dir(w.path))
+	if err != nil {
+		dir = ""
+	}
+	return lines, dir, nil
+}
+
+// scanLines reads r line by line, dropping the byte order mark and the
+// left-to-right marks WhatsApp puts around names and media notices
+func scanLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimPrefix(scanner.Text(), "\ufeff")
+		line = strings.ReplaceAll(line, "\u200e", "")
+		lines = append(lines, strings.TrimRight(line, "\r"))
+	}
+	return lines, scanner.Err()
+}
+
+// whatsAppLine matches the first line of a message, in either the iOS
+// format ("[31/12/2023, 21:05:09] Name: text") or the Android one
+// ("31/12/2023, 21:05 - Name: text"), with any date separator and an
+// optional AM/PM
+var whatsAppLine = regexp.MustCompile(`^\[?(\d{1,4})[./-](\d{1,2})[./-](\d{1,4}),?\s+(\d{1,2})[:.](\d{2})(?:[:.](\d{2}))?(?:[\s\x{202f}]*([AaPp])\.?\s?[Mm]\.?)?(?:\]|\s+-)\s(.*)$`)
+
+// whatsAppEntry is one message or system notice from an export, before its
+// date is interpreted
+type whatsAppEntry struct {
+	line   int    // Line number the entry starts on
+	fields [6]int // The date's three numbers as written, then hour, minute, second
+	pm     string // "a" or "p" for 12-hour times, else ""
+	sender string // Empty for system notices
+	text   string
+}
+
+// parseWhatsAppLines splits an export into entries. Lines that don't start
+// a message continue the one before.
+func parseWhatsAppLines(lines []string) []whatsAppEntry {
+	var entries []whatsAppEntry
+	for i, line := range lines {
+		m := whatsAppLine.FindStringSubmatch(line)
+		if m == nil {
+			if len(entries) > 0 {
+				entries[len(entries)-1].text += "\n" + line
+			}
+			continue
+		}
+
+		e := whatsAppEntry{line: i + 1, pm: strings.ToLower(m[7])}
+		for j := range 6 {
+			e.fields[j], _ = strconv.Atoi(m[j+1])
+		}
+		rest := m[8]
+		if sender, text, ok := strings.Cut(rest, ": "); ok {
+			e.sender, e.text = strings.TrimSpace(sender), text
+		} else if sender, ok := strings.CutSuffix(rest, ":"); ok {
+			e.sender = strings.TrimSpace(sender)
+		} else {
+			e.text = rest
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// isWhatsAppNotice reports whether text is a system notice that iOS exports
+// attribute to the chat, as if it were sent by someone
+func isWhatsAppNotice(text string) bool {
+	return strings.HasPrefix(text, "Messages and calls are end-to-end encrypted") ||
+		strings.HasPrefix(text, "Messages to this group are now secured")
+}
+
+// guessDateOrder tells day-first dates from month-first ones by looking for
+// a day past 12. When every date could be either, 12-hour times suggest a
+// US-style export.
+func guessDateOrder(entries []whatsAppEntry) string {
+	twelveHour := false
+	for _, e := range entries {
+		if e.fields[0] > 12 && e.fields[0] <= 31 {
+			return DateOrderDMY
+		}
+		if e.fields[1] > 12 {
+			return DateOrderMDY
+		}
+		if e.pm != "" {
+			twelveHour = true
+		}
+	}
+	if twelveHour {
+		return DateOrderMDY
+	}
+	return DateOrderDMY
+}
+
+// time interprets the entry's date and time, in local time as exports are
+// written in
+func (e whatsAppEntry) time(order string) (time.Time, error) {
+	a, b, c := e.fields[0], e.fields[1], e.fields[2]
+	var year, month, day int
+	switch {
+	case a > 31:
+		year, month, day = a, b, c
+	case order == DateOrderMDY:
+		month, day, year = a, b, c
+	default:
+		day, month, year = a, b, c
+	}
+	if year < 100 {
+		year += 2000
+	}
+
+	hour := e.fields[3]
+	switch e.pm {
+	case "a":
+		if hour == 12 {
+			hour = 0
+		}
+	case "p":
+		if hour < 12 {
+			hour += 12
+		}
+	}
+
+	if month < 1 || month > 12 || day < 1 || day > 31 || hour > 23 || e.fields[4] > 59 || e.fields[5] > 59 {
+		return time.Time{}, fmt.Errorf("invalid date %d/%d/%d %d:%02d", a, b, c, e.fields[3], e.fields[4])
+	}
+	return time.Date(year, time.Month(month), day, hour, e.fields[4], e.fields[5], 0, time.Local), nil
+}
+
+// whatsAppOmitted maps the notices WhatsApp leaves in place of media that
+// wasn't exported to the attachment they stand for
+var whatsAppOmitted = map[string]Attachment{
+	"<media omitted>":       {Type: "unknown"},
+	"image omitted":         {Type: "img"},
+	"video omitted":         {Type: "video"},
+	"audio omitted":         {Type: "audio", IsVoiceNote: true},
+	"sticker omitted":       {Type: "img", IsSticker: true},
+	"gif omitted":           {Type: "video", IsGif: true},
+	"document omitted":      {Type: "unknown"},
+	"contact card omitted":  {Type: "unknown"},
+	"<attached media>":      {Type: "unknown"},
+	"<media not included>":  {Type: "unknown"},
+	"media omitted":         {Type: "unknown"},
+	"voice message omitted": {Type: "audio", IsVoiceNote: true},
+}
+
+// whatsAppDeleted are the texts WhatsApp leaves in place of deleted messages
+var whatsAppDeleted = map[string]bool{
+	"this message was deleted":  true,
+	"this message was deleted.": true,
+	"you deleted this message":  true,
+	"you deleted this message.": true,
+}
+
+// whatsAppAttached matches a line naming an exported media file:
+// "<attached: 00000012-PHOTO-2023-12-31-21-05-09.jpg>" on iOS and
+// "IMG-20231231-WA0001.jpg (file attached)" on Android
+var whatsAppAttached = regexp.MustCompile(`^(?:<attached: (.+)>|(.+) \(file attached\))$`)
+
+// whatsAppContent separates a message's text from the media notices in it,
+// turning them into attachment placeholders. Media files found in mediaDir
+// are linked so they can be downloaded. It also reports whether the message
+// was deleted.
+func whatsAppContent(text, mediaDir string) (string, []Attachment, bool) {
+	if whatsAppDeleted[strings.ToLower(strings.TrimSpace(text))] {
+		return "", nil, true
+	}
+
+	var attachments []Attachment
+	var kept []string
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if a, ok := whatsAppOmitted[strings.ToLower(trimmed)]; ok {
+			attachments = append(attachments, a)
+			continue
+		}
+		if m := whatsAppAttached.FindStringSubmatch(trimmed); m != nil {
+			name := m[1] + m[2]
+			attachments = append(attachments, whatsAppAttachment(name, mediaDir))
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n"), attachments, false
+}
+
+// whatsAppAttachment describes an exported media file by its name
+func whatsAppAttachment(name, mediaDir string) Attachment {
+	a := Attachment{Type: "unknown", FileName: name}
+	a.MimeType = mime.TypeByExtension(strings.ToLower(filepath.Ext(name)))
+	switch {
+	case strings.HasPrefix(a.MimeType, "image/"):
+		a.Type = "img"
+		a.IsSticker = strings.EqualFold(filepath.Ext(name), ".webp")
+		a.IsGif = strings.EqualFold(filepath.Ext(name), ".gif")
+	case strings.HasPrefix(a.MimeType, "video/"):
+		a.Type = "video"
+	case strings.HasPrefix(a.MimeType, "audio/"), strings.EqualFold(filepath.Ext(name), ".opus"):
+		a.Type = "audio"
+		a.IsVoiceNote = strings.HasPrefix(name, "PTT-") || strings.Contains(name, "-AUDIO-")
+	}
+
+	if mediaDir != "" {
+		path := filepath.Join(mediaDir, filepath.Base(name))
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			a.FileSize = float64(info.Size())
+			a.SrcURL = (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+		}
+	}
+	return a
+}
+
+// whatsAppChatTitle names a conversation after its export's file name:
+// "WhatsApp Chat with Ada.txt" and "WhatsApp Chat - Ada.zip" give "Ada", and
+// a bare _chat.txt is named after the directory it's in
+func whatsAppChatTitle(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if name == "_chat" {
+		name = filepath.Base(filepath.Dir(path))
+	}
+	for _, prefix := range []string{"WhatsApp Chat with ", "WhatsApp Chat - "} {
+		if rest, ok := strings.CutPrefix(name, prefix); ok && rest != "" {
+			return rest
+		}
+	}
+	return name
+}
+
+// shortHash returns a short, stable hex digest of s
+func shortHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,130 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Truncate shortens s to at most maxWidth terminal columns, ending it with
+// "..." when there's room. Width is measured per grapheme cluster, so emoji
+// and CJK count as two columns, and neither multibyte characters nor
+// joined emoji sequences are ever split.
+func Truncate(s string, maxWidth int) string {
+	ellipsis := "..."
+	if maxWidth <= len(ellipsis) {
+		ellipsis = ""
+	}
+	return runewidth.Truncate(s, maxWidth, ellipsis)
+}
+
+// HumanBytes formats a byte count for display, e.g. "1.5 MB". It takes a
+// float64 because attachment sizes are reported that way.
+func HumanBytes(n float64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", int64(n))
+	}
+	div, exp := float64(unit), 0
+	for m := n / unit; m >= unit && exp < 5; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", n/div, "KMGTPE"[exp])
+}
+
+// HumanCount formats a count with thousands separators, e.g. "1,204"
+func HumanCount(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, s = "-", s[1:]
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return sign + s
+}
+
+// HumanDuration formats a length of time as a clock, e.g. "0:42" or "1:02:03"
+func HumanDuration(d time.Duration) string {
+	secs := int64(d.Round(time.Second).Seconds())
+	if secs < 0 {
+		secs = 0
+	}
+	h, m, s := secs/3600, secs/60%60, secs%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
+
+// TimeFormat controls how times and dates are displayed. The zero value
+// gives 12-hour US-style formats in the local time zone.
+type TimeFormat struct {
+	Hour24   bool           // "15:04" rather than "3:04 PM"
+	DayFirst bool           // "2 Jan" rather than "Jan 2"
+	Location *time.Location // Zone to display times in; nil means local time
+}
+
+// In converts t to the display time zone
+func (f TimeFormat) In(t time.Time) time.Time {
+	if f.Location == nil {
+		return t.Local()
+	}
+	return t.In(f.Location)
+}
+
+// Now returns the current time in the display time zone
+func (f TimeFormat) Now() time.Time {
+	return f.In(time.Now())
+}
+
+// Clock returns the layout for a time of day, e.g. "3:04 PM" or "15:04"
+func (f TimeFormat) Clock() string {
+	if f.Hour24 {
+		return "15:04"
+	}
+	return "3:04 PM"
+}
+
+// MonthDay returns the layout for a date without the year, e.g. "Jan 2" or "2 Jan"
+func (f TimeFormat) MonthDay() string {
+	if f.DayFirst {
+		return "2 Jan"
+	}
+	return "Jan 2"
+}
+
+// MonthDayYear returns the layout for a full date, e.g. "Jan 2, 2006" or "2 Jan 2006"
+func (f TimeFormat) MonthDayYear() string {
+	if f.DayFirst {
+		return "2 Jan 2006"
+	}
+	return "Jan 2, 2006"
+}
+
+// TimeAgo formats a time relative to now, e.g. "2m ago", "3h ago",
+// "yesterday", or the date once it's more than a month old
+func TimeAgo(t time.Time, f TimeFormat) string {
+	diff := time.Since(t)
+
+	switch {
+	case diff < time.Minute:
+		return "now"
+	case diff < time.Hour:
+		return fmt.Sprintf("%dm ago", int(diff.Minutes()))
+	case diff < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(diff.Hours()))
+	case diff < 48*time.Hour:
+		return "yesterday"
+	case diff < 7*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(diff.Hours()/24))
+	case diff < 30*24*time.Hour:
+		return fmt.Sprintf("%dw ago", int(diff.Hours()/24/7))
+	default:
+		return f.In(t).Format(f.MonthDay())
+	}
+}
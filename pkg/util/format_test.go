@@ -0,0 +1,47 @@
+package util
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+func TestTruncate(t *testing.T) {
+	const coder = "👩‍💻" // Woman technologist: two emoji joined by a ZWJ
+	tests := []struct {
+		name     string
+		s        string
+		maxWidth int
+		want     string
+	}{
+		{"ascii fits", "hello", 5, "hello"},
+		{"ascii", "hello world", 8, "hello..."},
+		{"no room for ellipsis", "hello", 3, "hel"},
+		{"zero width", "hello", 0, ""},
+		{"emoji fits", "hi 🎉", 5, "hi 🎉"},
+		{"emoji", "🎉🎉🎉🎉", 7, "🎉🎉..."},
+		{"emoji straddles the cut", "🎉🎉🎉🎉", 6, "🎉..."},
+		{"emoji wider than the limit", "🎉", 1, ""},
+		{"cjk", "日本語のテキスト", 9, "日本語..."},
+		{"cjk straddles the cut", "日本語のテキスト", 8, "日本..."},
+		{"zwj fits", coder + "ab", 4, coder + "ab"},
+		{"zwj kept whole", coder + coder + coder, 5, coder + "..."},
+		{"zwj not split", coder + coder, 3, coder},
+		{"zwj dropped whole", coder + "abcdef", 4, "..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Truncate(tt.s, tt.maxWidth)
+			if got != tt.want {
+				t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.maxWidth, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("Truncate(%q, %d) = %q, split a rune", tt.s, tt.maxWidth, got)
+			}
+			if w := runewidth.StringWidth(got); w > tt.maxWidth {
+				t.Errorf("Truncate(%q, %d) = %q, %d columns wide", tt.s, tt.maxWidth, got, w)
+			}
+		})
+	}
+}